@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+)
+
+var (
+	ErrTenantAdmin   = errors.New("the API keys of tenants cannot have the admin scope")
+	ErrExpiredAPIKey = errors.New("the API key must expire in the future")
+)
+
+func GetAPIKeyRepository(ctx *gin.Context) repository.Repository {
+	return ctx.MustGet("RepositoryRegistry").(*repository.RepositoryRegistry).MustRepository("APIKeyRepository")
+}
+
+func GetAPIKeys(ctx *gin.Context) {
+	var q = query{}
+
+	if err := ctx.ShouldBindQuery(&q); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	e, err := GetAPIKeyRepository(ctx).List(q.After, q.Limit)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteHAL(ctx, http.StatusOK, e.(model.APIKeyCollection).ToHAL(ctx.Request.URL.Path, ctx.Request.URL.Query()))
+}
+
+// apiKeyRequest is the body of CreateAPIKey, which leaves out the fields of
+// the key that are not for the client to set, such as its hash or revocation.
+type apiKeyRequest struct {
+	Name      string     `json:"name"`
+	TenantID  *uuid.UUID `json:"tenant_id"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreateAPIKey generates a new API key and returns it, which is the only time
+// it is shown, as only its hash is stored.
+func CreateAPIKey(ctx *gin.Context) {
+	body := apiKeyRequest{}
+
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	k := model.APIKey{Name: body.Name, TenantID: body.TenantID, Scopes: body.Scopes, ExpiresAt: body.ExpiresAt}
+
+	if err := validate.Struct(k); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if k.TenantID != nil && slices.Contains(k.Scopes, model.Admin) {
+		HandleError(ctx, ErrTenantAdmin)
+
+		return
+	}
+
+	if k.ExpiresAt != nil && !k.ExpiresAt.After(time.Now()) {
+		HandleError(ctx, ErrExpiredAPIKey)
+
+		return
+	}
+
+	key, err := model.GenerateAPIKey()
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	k.SetKey(key)
+
+	var apiKey *model.APIKey
+
+	err = Transactional(ctx, func(registry *repository.RepositoryRegistry) (func() error, error) {
+		e, err := registry.MustRepository("APIKeyRepository").Create(&k)
+		if err != nil {
+			return nil, err
+		}
+
+		apiKey = e.(*model.APIKey)
+
+		return nil, Audit(ctx, registry, model.ActionCreate, nil, apiKey)
+	})
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	selfHref, _ := url.JoinPath(ctx.Request.URL.Path, apiKey.ID.String())
+	resource := apiKey.ToHAL(selfHref)
+	resource.AddData(model.APIKeySecret{Key: key})
+	WriteHAL(ctx, http.StatusCreated, resource)
+}
+
+func GetAPIKey(ctx *gin.Context) {
+	p := params{}
+
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	e, err := GetAPIKeyRepository(ctx).Get(p.ID)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteHAL(ctx, http.StatusOK, e.(*model.APIKey).ToHAL(ctx.Request.URL.Path))
+}
+
+// RevokeAPIKey stops an API key from being accepted. The key is kept, so it
+// is still listed along with when it was revoked.
+func RevokeAPIKey(ctx *gin.Context) {
+	p := params{}
+
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	e, err := GetAPIKeyRepository(ctx).Get(p.ID)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	key := e.(*model.APIKey)
+
+	if key.RevokedAt == nil {
+		now := time.Now()
+
+		revoked := *key
+		revoked.RevokedAt = &now
+
+		err = Transactional(ctx, func(registry *repository.RepositoryRegistry) (func() error, error) {
+			if _, err := registry.MustRepository("APIKeyRepository").Update(key.ID, &model.APIKey{RevokedAt: &now}); err != nil {
+				return nil, err
+			}
+
+			return nil, Audit(ctx, registry, model.ActionRevoke, key, &revoked)
+		})
+		if err != nil {
+			HandleError(ctx, err)
+
+			return
+		}
+	}
+
+	WriteNoContent(ctx)
+}
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/thanhpk/randstr"
+)
+
+func TestGetAPIKeys(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	key := &model.APIKey{ID: uuid.New(), Name: randstr.String(16), Scopes: []string{model.TriggersRead}}
+	key.SetKey(newAPIKey())
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/admin/keys", nil)
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &APIKeyRepository{keys: model.APIKeyCollection{key}}))
+
+	GetAPIKeys(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Contains(t, r.Body.String(), `"prefix":"`+key.Prefix+`"`)
+	assert.NotContains(t, r.Body.String(), key.Hash)
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	keys, audit := &APIKeyRepository{}, &AuditEntryRepository{}
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/admin/keys", bytes.NewBufferString(`{"name":"ci","scopes":["triggers:read","triggers:run"]}`))
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, keys, audit))
+
+	CreateAPIKey(ctx)
+
+	assert.Equal(t, http.StatusCreated, r.Code)
+	assert.Equal(t, "application/hal+json", r.Header().Get("Content-Type"))
+	assert.Contains(t, r.Body.String(), `"key":"`+model.KeyPrefix)
+	assert.Equal(t, []string{model.TriggersRead, model.TriggersRun}, keys.created.Scopes)
+	assert.Len(t, keys.created.Hash, 64)
+	assert.True(t, strings.HasPrefix(keys.created.Prefix, model.KeyPrefix))
+	assert.Len(t, audit.created, 1)
+	assert.Equal(t, model.ResourceAPIKey, audit.created[0].Resource)
+}
+
+func TestCreateAPIKeyInvalidScope(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/admin/keys", bytes.NewBufferString(`{"name":"ci","scopes":["triggers:delete"]}`))
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &APIKeyRepository{}))
+
+	CreateAPIKey(ctx)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, r.Code)
+	assert.Equal(t, "application/problem+json", r.Header().Get("Content-Type"))
+}
+
+func TestCreateAPIKeyIgnoresServerFields(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	keys := &APIKeyRepository{}
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/admin/keys", bytes.NewBufferString(`{"name":"ci","scopes":["triggers:read"],"prefix":"sk_chosen","revoked_at":"2000-01-01T00:00:00Z","created_at":"2000-01-01T00:00:00Z"}`))
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, keys, &AuditEntryRepository{}))
+
+	CreateAPIKey(ctx)
+
+	assert.Equal(t, http.StatusCreated, r.Code)
+	assert.NotEqual(t, "sk_chosen", keys.created.Prefix)
+	assert.Nil(t, keys.created.RevokedAt)
+	assert.True(t, keys.created.CreatedAt.IsZero())
+}
+
+func TestCreateAPIKeyExpired(t *testing.T) {
+	for _, expiresAt := range []time.Time{time.Now().Add(-time.Hour), time.Now()} {
+		r := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(r)
+		keys := &APIKeyRepository{}
+		b, _ := json.Marshal(map[string]any{"name": "ci", "scopes": []string{model.TriggersRead}, "expires_at": expiresAt})
+		ctx.Request, _ = http.NewRequest(http.MethodPost, "/admin/keys", bytes.NewBuffer(b))
+
+		ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, keys))
+
+		CreateAPIKey(ctx)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, r.Code)
+		assert.Nil(t, keys.created)
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	keys, audit := &APIKeyRepository{keys: model.APIKeyCollection{{ID: id}}}, &AuditEntryRepository{}
+	ctx.Request, _ = http.NewRequest(http.MethodDelete, "/admin/keys/"+id.String(), nil)
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, keys, audit))
+
+	RevokeAPIKey(ctx)
+
+	assert.Equal(t, http.StatusNoContent, r.Code)
+	assert.NotNil(t, keys.updated.RevokedAt)
+	assert.WithinDuration(t, time.Now(), *keys.updated.RevokedAt, time.Second)
+	assert.Len(t, audit.created, 1)
+	assert.Equal(t, model.ActionRevoke, audit.created[0].Action)
+	assert.Equal(t, id, audit.created[0].ResourceID)
+}
+
+func newAPIKey() string {
+	key, _ := model.GenerateAPIKey()
+	return key
+}
@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+)
+
+type auditQuery struct {
+	query
+	Trigger    string `form:"trigger" binding:"omitempty,uuid"`
+	Resource   string `form:"resource" binding:"omitempty,oneof=trigger webhook tenant role_binding api_key"`
+	ResourceID string `form:"resource_id" binding:"omitempty,uuid"`
+	Actor      string `form:"actor"`
+	Action     string `form:"action" binding:"omitempty,oneof=create update run pause resume rotate_secret delete restore redeliver revoke"`
+}
+
+func GetAuditEntryRepository(ctx *gin.Context) repository.Repository {
+	return ctx.MustGet("RepositoryRegistry").(*repository.RepositoryRegistry).MustRepository("AuditEntryRepository")
+}
+
+// Audit records that the principal of the request did action to a resource,
+// changing it from before to after, either of which is a nil interface when
+// the resource is created or deleted. registry is the one of the transaction
+// of the change, so the entry is only kept if the change is.
+func Audit(ctx *gin.Context, registry *repository.RepositoryRegistry, action string, before, after model.Audited) error {
+	var actor string
+	if principal, ok := GetPrincipal(ctx); ok {
+		actor = principal.Subject
+	}
+
+	entry, err := model.NewAuditEntry(action, actor, ctx.ClientIP(), before, after)
+	if err != nil {
+		return err
+	}
+
+	audit, err := registry.Repository("AuditEntryRepository")
+	if err != nil {
+		return err
+	}
+
+	_, err = audit.Create(entry)
+
+	return err
+}
+
+// GetAuditEntries lists the audit log, optionally only the entries of a
+// trigger, a type of resource, a resource, an actor or an action.
+func GetAuditEntries(ctx *gin.Context) {
+	var q = auditQuery{}
+
+	if err := ctx.ShouldBindQuery(&q); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	var scopes []repository.Scope
+	if q.Trigger != "" {
+		scopes = append(scopes, repository.WhereResource(model.ResourceTrigger), repository.WhereResourceID(uuid.MustParse(q.Trigger)))
+	}
+
+	if q.Resource != "" {
+		scopes = append(scopes, repository.WhereResource(q.Resource))
+	}
+
+	if q.ResourceID != "" {
+		scopes = append(scopes, repository.WhereResourceID(uuid.MustParse(q.ResourceID)))
+	}
+
+	if q.Actor != "" {
+		scopes = append(scopes, repository.WhereActor(q.Actor))
+	}
+
+	if q.Action != "" {
+		scopes = append(scopes, repository.WhereAction(q.Action))
+	}
+
+	e, err := GetAuditEntryRepository(ctx).List(q.After, q.Limit, scopes...)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteHAL(ctx, http.StatusOK, e.(model.AuditEntryCollection).ToHAL(ctx.Request.URL.Path, ctx.Request.URL.Query()))
+}
+
+func GetAuditEntry(ctx *gin.Context) {
+	p := params{}
+
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	e, err := GetAuditEntryRepository(ctx).Get(p.ID)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteHAL(ctx, http.StatusOK, e.(*model.AuditEntry).ToHAL(ctx.Request.URL.Path))
+}
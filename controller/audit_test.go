@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type AuditEntryRepository struct {
+	err     error
+	entries model.AuditEntryCollection
+	scopes  int
+	created model.AuditEntryCollection
+}
+
+func (r *AuditEntryRepository) Configure(db *gorm.DB) {
+}
+
+func (r *AuditEntryRepository) List(after time.Time, limit int, scopes ...repository.Scope) (any, error) {
+	r.scopes = len(scopes)
+	return r.entries, r.err
+}
+
+func (r *AuditEntryRepository) Get(id any) (any, error) {
+	entry, _ := model.Last(r.entries)
+	return entry, r.err
+}
+
+func (r *AuditEntryRepository) Create(entity any) (any, error) {
+	r.created = append(r.created, entity.(*model.AuditEntry))
+	return entity, r.err
+}
+
+func (r *AuditEntryRepository) Update(id any, entity any) (bool, error) {
+	return false, repository.ErrAppendOnly
+}
+
+func (r *AuditEntryRepository) Delete(id any) (bool, error) {
+	return false, repository.ErrAppendOnly
+}
+
+func TestAudit(t *testing.T) {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request, _ = http.NewRequest(http.MethodPut, "/", nil)
+	ctx.Request.RemoteAddr = "203.0.113.7:4242"
+	ctx.Set("Principal", &model.Principal{Subject: "alice"})
+
+	audit := &AuditEntryRepository{}
+	before := &model.Trigger{ID: uuid.New(), Schedule: "* * * * *"}
+	after := &model.Trigger{ID: before.ID, Schedule: "*/5 * * * *"}
+
+	assert.NoError(t, Audit(ctx, repository.NewRepositoryRegistry(nil, audit), model.ActionUpdate, before, after))
+
+	assert.Len(t, audit.created, 1)
+	entry := audit.created[0]
+	assert.Equal(t, model.ResourceTrigger, entry.Resource)
+	assert.Equal(t, before.ID, entry.ResourceID)
+	assert.Equal(t, "alice", entry.Actor)
+	assert.Equal(t, "203.0.113.7", entry.IP)
+	assert.Equal(t, []model.Change{{Field: "schedule", Before: "* * * * *", After: "*/5 * * * *"}}, entry.Diff)
+}
+
+func TestGetAuditEntries(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	trigger := uuid.New()
+	entry := model.AuditEntry{ID: uuid.New(), Resource: model.ResourceTrigger, ResourceID: trigger, Action: model.ActionPause, Actor: "alice", CreatedAt: time.Now()}
+	audit := &AuditEntryRepository{entries: model.AuditEntryCollection{&entry}}
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/audit?action=pause&actor=alice&trigger="+trigger.String(), nil)
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, audit))
+
+	GetAuditEntries(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "application/hal+json", r.Header().Get("Content-Type"))
+	assert.Contains(t, r.Body.String(), `"action":"pause"`)
+	assert.Contains(t, r.Body.String(), `"href":"/audit/`+entry.ID.String()+`"`)
+	assert.Contains(t, r.Body.String(), `"next":{"href":"/audit?action=pause`)
+	assert.Equal(t, 4, audit.scopes)
+}
+
+func TestGetAuditEntriesInvalidFilter(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	audit := &AuditEntryRepository{}
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/audit?action=drop", nil)
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, audit))
+
+	GetAuditEntries(ctx)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, r.Code)
+	assert.Equal(t, "application/problem+json", r.Header().Get("Content-Type"))
+}
+
+func TestGetAuditEntry(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	trigger := &model.Trigger{ID: uuid.New(), Name: "nightly"}
+	entry, err := model.NewAuditEntry(model.ActionDelete, "alice", "203.0.113.7", trigger, nil)
+	assert.NoError(t, err)
+	entry.ID = uuid.New()
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/audit/"+entry.ID.String(), nil)
+	ctx.Params = []gin.Param{{Key: "uuid", Value: entry.ID.String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &AuditEntryRepository{entries: model.AuditEntryCollection{entry}}))
+
+	GetAuditEntry(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Contains(t, r.Body.String(), `"before":{`)
+	assert.Contains(t, r.Body.String(), `"href":"/triggers/`+trigger.ID.String()+`"`)
+}
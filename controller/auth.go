@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/oidc"
+	"github.com/skhaz/scheduler/repository"
+)
+
+var (
+	ErrUnauthenticated = errors.New("a valid API key or token is required")
+	ErrForbidden       = errors.New("the principal lacks the required scope")
+)
+
+// Authenticate resolves the bearer token of the request to the principal of
+// its API key. adminKey, when set, is an API key with the admin scope that is
+// not stored in the database, so the first keys can be created with it.
+// Requests without a valid key are let through without a principal, and
+// rejected by RequireScope on the routes that need one. When verifier is set,
+// bearer tokens that are JWTs are verified with it instead, see
+// TokenPrincipal, and requests with invalid ones are rejected.
+func Authenticate(adminKey string, verifier *oidc.Verifier) gin.HandlerFunc {
+	var adminHash string
+	if adminKey != "" {
+		adminHash = model.HashAPIKey(adminKey)
+	}
+
+	return func(ctx *gin.Context) {
+		scheme, token, _ := strings.Cut(ctx.GetHeader("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			ctx.Next()
+			return
+		}
+
+		if verifier != nil && oidc.IsJWT(token) {
+			principal, err := TokenPrincipal(verifier, token)
+			if err == nil {
+				err = BindRoles(ctx, principal)
+			}
+
+			if err != nil {
+				if errors.Is(err, ErrUnauthenticated) {
+					ctx.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+				}
+
+				HandleError(ctx, err)
+				ctx.Abort()
+				return
+			}
+
+			ctx.Set("Principal", principal)
+			ctx.Next()
+			return
+		}
+
+		hash := model.HashAPIKey(token)
+
+		if adminHash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(adminHash)) == 1 {
+			ctx.Set("Principal", &model.Principal{Subject: "admin", Scopes: []string{model.Admin}})
+			ctx.Next()
+			return
+		}
+
+		e, err := GetAPIKeyRepository(ctx).List(time.Time{}, 1, repository.WhereHash(hash))
+		if err != nil {
+			HandleError(ctx, err)
+			ctx.Abort()
+			return
+		}
+
+		if key, ok := model.Last(e.(model.APIKeyCollection)); ok && key.IsActive(time.Now()) {
+			principal := &model.Principal{Subject: model.KeySubject(key.ID), Scopes: key.Scopes, Tenant: key.TenantID}
+
+			if err := BindRoles(ctx, principal); err != nil {
+				HandleError(ctx, err)
+				ctx.Abort()
+				return
+			}
+
+			ctx.Set("Principal", principal)
+		}
+
+		ctx.Next()
+	}
+}
+
+// TokenPrincipal verifies a JWT and maps its claims to a principal: the
+// subject qualified by the issuer, the scopes, the known roles and the tenant, which must be a UUID.
+// A principal without a tenant is not restricted to any tenant, so tokens
+// without one are rejected unless they carry the admin role configured in
+// the verifier, which makes their principal an admin. The token is not taken
+// as invalid when the keys to verify it with cannot be read.
+func TokenPrincipal(verifier *oidc.Verifier, token string) (*model.Principal, error) {
+	claims, err := verifier.Verify(token)
+	if errors.Is(err, oidc.ErrKeySetUnavailable) {
+		return nil, err
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnauthenticated, err)
+	}
+
+	principal := &model.Principal{Subject: model.TokenSubject(claims.Issuer, claims.Subject), Scopes: claims.Scopes}
+
+	for _, role := range claims.Roles {
+		if _, ok := model.Roles[role]; ok {
+			principal.Roles = append(principal.Roles, role)
+		}
+	}
+
+	if claims.Tenant == "" {
+		if !claims.Admin {
+			return nil, fmt.Errorf("%w: the token has no tenant claim", ErrUnauthenticated)
+		}
+
+		if !slices.Contains(principal.Roles, model.Admin) {
+			principal.Roles = append(principal.Roles, model.Admin)
+		}
+
+		return principal, nil
+	}
+
+	tenant, err := uuid.Parse(claims.Tenant)
+	if err != nil {
+		return nil, fmt.Errorf("%w: the tenant claim is not a UUID", ErrUnauthenticated)
+	}
+
+	principal.Tenant = &tenant
+
+	return principal, nil
+}
+
+// BindRoles adds to principal the roles bound to its subject.
+func BindRoles(ctx *gin.Context, principal *model.Principal) error {
+	e, err := GetRoleBindingRepository(ctx).List(time.Time{}, len(model.Roles), repository.WhereSubject(principal.Subject))
+	if err != nil {
+		return err
+	}
+
+	for _, binding := range e.(model.RoleBindingCollection) {
+		principal.Roles = append(principal.Roles, binding.Role)
+	}
+
+	return nil
+}
+
+// ScopeTenant restricts the repositories of the requests made on behalf of a
+// tenant to the entities of the tenant.
+func ScopeTenant(ctx *gin.Context) {
+	if principal, ok := GetPrincipal(ctx); ok && principal.Tenant != nil {
+		ctx.Set("RepositoryRegistry", GetRepositoryRegistry(ctx).ForTenant(*principal.Tenant))
+	}
+
+	ctx.Next()
+}
+
+// RequireScope rejects the requests whose principal lacks scope, whether from
+// its API key or its roles.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		principal, ok := GetPrincipal(ctx)
+		if !ok {
+			ctx.Header("WWW-Authenticate", "Bearer")
+			HandleError(ctx, ErrUnauthenticated)
+			ctx.Abort()
+			return
+		}
+
+		if !principal.Allows(scope) {
+			HandleError(ctx, fmt.Errorf("%w: %s", ErrForbidden, scope))
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+func GetPrincipal(ctx *gin.Context) (*model.Principal, bool) {
+	if principal, ok := ctx.Get("Principal"); ok {
+		return principal.(*model.Principal), true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,253 @@
+package controller
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/oidc"
+	"github.com/skhaz/scheduler/repository"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"k8s.io/utils/clock"
+)
+
+type APIKeyRepository struct {
+	err     error
+	keys    model.APIKeyCollection
+	created *model.APIKey
+	updated *model.APIKey
+}
+
+func (r *APIKeyRepository) Configure(db *gorm.DB) {
+}
+
+// List ignores its scopes, as the keys of the tests are looked up by hash.
+func (r *APIKeyRepository) List(after time.Time, limit int, scopes ...repository.Scope) (any, error) {
+	return r.keys, r.err
+}
+
+func (r *APIKeyRepository) Get(id any) (any, error) {
+	key, _ := model.Last(r.keys)
+	return key, r.err
+}
+
+func (r *APIKeyRepository) Create(entity any) (any, error) {
+	r.created = entity.(*model.APIKey)
+	r.created.ID = uuid.New()
+	return r.created, r.err
+}
+
+func (r *APIKeyRepository) Update(id any, entity any) (bool, error) {
+	r.updated = entity.(*model.APIKey)
+	return true, r.err
+}
+
+func (r *APIKeyRepository) Delete(id any) (bool, error) {
+	return true, r.err
+}
+
+func newAuthenticatedRouter(authenticate gin.HandlerFunc, bindings model.RoleBindingCollection, keys ...*model.APIKey) *gin.Engine {
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &APIKeyRepository{keys: keys}, &RoleBindingRepository{bindings: bindings}))
+		ctx.Next()
+	})
+	router.Use(authenticate)
+
+	ok := func(ctx *gin.Context) { ctx.Status(http.StatusOK) }
+	router.GET("/triggers", RequireScope(model.TriggersRead), ok)
+	router.POST("/triggers", RequireScope(model.TriggersWrite), ok)
+	router.DELETE("/triggers", RequireScope(model.TriggersWrite), ok)
+	router.PUT("/triggers", RequireScope(model.TriggersOperate), ok)
+
+	return router
+}
+
+func serve(router *gin.Engine, method, token string) *httptest.ResponseRecorder {
+	r := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, "/triggers", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	router.ServeHTTP(r, req)
+
+	return r
+}
+
+func TestAuthenticate(t *testing.T) {
+	token, err := model.GenerateAPIKey()
+	assert.NoError(t, err)
+
+	key := &model.APIKey{ID: uuid.New(), Scopes: []string{model.TriggersRead}}
+	key.SetKey(token)
+
+	router := newAuthenticatedRouter(Authenticate("", nil), nil, key)
+
+	assert.Equal(t, http.StatusOK, serve(router, http.MethodGet, token).Code)
+
+	r := serve(router, http.MethodPost, token)
+	assert.Equal(t, http.StatusForbidden, r.Code)
+	assert.Equal(t, "application/problem+json", r.Header().Get("Content-Type"))
+	assert.Contains(t, r.Body.String(), model.TriggersWrite)
+}
+
+func TestAuthenticateMissingKey(t *testing.T) {
+	r := serve(newAuthenticatedRouter(Authenticate("", nil), nil), http.MethodGet, "")
+
+	assert.Equal(t, http.StatusUnauthorized, r.Code)
+	assert.Equal(t, "Bearer", r.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, "application/problem+json", r.Header().Get("Content-Type"))
+}
+
+func TestAuthenticateInactiveKey(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+
+	for _, key := range []*model.APIKey{
+		{Scopes: []string{model.TriggersRead}, RevokedAt: &past},
+		{Scopes: []string{model.TriggersRead}, ExpiresAt: &past},
+	} {
+		token, _ := model.GenerateAPIKey()
+		key.SetKey(token)
+
+		assert.Equal(t, http.StatusUnauthorized, serve(newAuthenticatedRouter(Authenticate("", nil), nil, key), http.MethodGet, token).Code)
+	}
+}
+
+func TestAuthenticateAdminKey(t *testing.T) {
+	admin, _ := model.GenerateAPIKey()
+	router := newAuthenticatedRouter(Authenticate(admin, nil), nil)
+
+	assert.Equal(t, http.StatusOK, serve(router, http.MethodPost, admin).Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(router, http.MethodPost, admin+"0").Code)
+}
+
+// newVerifier returns a verifier and a function issuing the tokens it accepts,
+// signed with an RSA key generated for the test. issue fills in the registered
+// claims missing from the given ones.
+func newVerifier(t *testing.T) (*oidc.Verifier, func(claims map[string]any) string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	encode := base64.RawURLEncoding.EncodeToString
+	jwks, _ := json.Marshal(oidc.JWKS{Keys: []oidc.JWK{{Kty: "RSA", Kid: "test", N: encode(key.N.Bytes()), E: encode(big.NewInt(int64(key.E)).Bytes())}}})
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(path, jwks, 0o600))
+
+	config := oidc.Config{Issuer: "https://id.example.com", Audience: "scheduler", TenantClaim: "tenant", RolesClaim: "roles", AdminRole: "scheduler-admin"}
+	verifier := oidc.NewVerifier(config, oidc.NewKeySet(path, http.DefaultClient, clock.RealClock{}), clock.RealClock{})
+
+	issue := func(claims map[string]any) string {
+		for name, value := range map[string]any{"iss": config.Issuer, "aud": config.Audience, "exp": time.Now().Add(time.Hour).Unix()} {
+			if _, ok := claims[name]; !ok {
+				claims[name] = value
+			}
+		}
+
+		header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test"})
+		payload, _ := json.Marshal(claims)
+		signed := encode(header) + "." + encode(payload)
+
+		digest := sha256.Sum256([]byte(signed))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		assert.NoError(t, err)
+
+		return signed + "." + encode(signature)
+	}
+
+	return verifier, issue
+}
+
+func TestAuthenticateToken(t *testing.T) {
+	verifier, issue := newVerifier(t)
+	tenant := uuid.New()
+
+	var principal *model.Principal
+
+	router := newAuthenticatedRouter(Authenticate("", verifier), model.RoleBindingCollection{{Subject: "oidc:https://id.example.com|alice", Role: model.Viewer}})
+	router.PATCH("/triggers", func(ctx *gin.Context) {
+		principal, _ = GetPrincipal(ctx)
+	})
+
+	token := issue(map[string]any{"sub": "alice", "scope": "openid", "roles": []string{model.Operator, "unknown"}, "tenant": tenant.String()})
+
+	assert.Equal(t, http.StatusOK, serve(router, http.MethodPut, token).Code)
+	assert.Equal(t, http.StatusForbidden, serve(router, http.MethodPost, token).Code)
+
+	serve(router, http.MethodPatch, token)
+	assert.Equal(t, "oidc:https://id.example.com|alice", principal.Subject)
+	assert.Equal(t, []string{model.Operator, model.Viewer}, principal.Roles)
+	assert.Equal(t, &tenant, principal.Tenant)
+}
+
+func TestAuthenticateTokenWithoutTenant(t *testing.T) {
+	verifier, issue := newVerifier(t)
+
+	var principal *model.Principal
+
+	router := newAuthenticatedRouter(Authenticate("", verifier), nil)
+	router.PATCH("/triggers", func(ctx *gin.Context) {
+		principal, _ = GetPrincipal(ctx)
+	})
+
+	// Without a tenant, a token would reach the triggers of every tenant.
+	for _, token := range []string{
+		issue(map[string]any{"sub": "alice", "scope": model.TriggersRead}),
+		issue(map[string]any{"sub": "alice", "scope": model.TriggersRead, "roles": []string{model.Editor}}),
+		issue(map[string]any{"sub": "alice", "scope": model.TriggersRead, "roles": []string{model.Admin}}),
+	} {
+		r := serve(router, http.MethodGet, token)
+		assert.Equal(t, http.StatusUnauthorized, r.Code)
+		assert.Equal(t, `Bearer error="invalid_token"`, r.Header().Get("WWW-Authenticate"))
+	}
+
+	token := issue(map[string]any{"sub": "root", "roles": []string{"scheduler-admin"}})
+	assert.Equal(t, http.StatusOK, serve(router, http.MethodPatch, token).Code)
+	assert.Equal(t, []string{model.Admin}, principal.Roles)
+	assert.Nil(t, principal.Tenant)
+	assert.True(t, principal.Allows(model.Admin))
+}
+
+func TestAuthenticateInvalidToken(t *testing.T) {
+	verifier, issue := newVerifier(t)
+	router := newAuthenticatedRouter(Authenticate("", verifier), nil)
+
+	for _, token := range []string{
+		issue(map[string]any{"sub": "alice", "scope": model.TriggersRead, "aud": "another"}),
+		issue(map[string]any{"sub": "alice", "scope": model.TriggersRead, "tenant": "acme"}),
+		"a.b.c",
+	} {
+		r := serve(router, http.MethodGet, token)
+		assert.Equal(t, http.StatusUnauthorized, r.Code)
+		assert.Equal(t, `Bearer error="invalid_token"`, r.Header().Get("WWW-Authenticate"))
+		assert.Equal(t, "application/problem+json", r.Header().Get("Content-Type"))
+	}
+}
+
+func TestAuthenticateKeySetUnavailable(t *testing.T) {
+	_, issue := newVerifier(t)
+
+	config := oidc.Config{Issuer: "https://id.example.com", Audience: "scheduler"}
+	keys := oidc.NewKeySet(filepath.Join(t.TempDir(), "missing.json"), http.DefaultClient, clock.RealClock{})
+	router := newAuthenticatedRouter(Authenticate("", oidc.NewVerifier(config, keys, clock.RealClock{})), nil)
+
+	r := serve(router, http.MethodGet, issue(map[string]any{"sub": "alice", "scope": model.TriggersRead}))
+	assert.Equal(t, http.StatusServiceUnavailable, r.Code)
+	assert.Empty(t, r.Header().Get("WWW-Authenticate"))
+	assert.Contains(t, r.Body.String(), `"type":"errors:auth/key-set-unavailable"`)
+}
@@ -1,35 +1,306 @@
 package controller
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/oidc"
+	"github.com/skhaz/scheduler/workflow"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"schneider.vip/problem"
 )
 
+// unprocessable are the errors of requests that are well formed but ask for
+// something that cannot be done, reported like the failed validations.
+var unprocessable = []error{ErrImmutableName, ErrTenantAdmin, ErrExpiredAPIKey, model.ErrNeverRuns}
+
+// InvalidParam is a field of the body or the query that failed validation,
+// by its JSON name.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// HandleError writes err as a problem. Errors of the client get a 4xx, and the
+// ones of the database or the cluster a 5xx. Unknown errors are logged with a
+// correlation ID, which is the only thing of them the client sees.
 func HandleError(ctx *gin.Context, err error) {
-	var p *problem.Problem
+	var (
+		p      *problem.Problem
+		status int
+		ve     validator.ValidationErrors
+		se     apierrors.APIStatus
+		we     *workflow.Error
+		qe     *model.QuotaError
+	)
 
 	switch {
 	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
 		p = problem.New(
 			problem.Title("Record Not Found"),
 			problem.Type("errors:database/record-not-found"),
 			problem.Detail(err.Error()),
-			problem.Status(http.StatusNotFound),
+			problem.Status(status),
 		)
-	default:
+	case errors.Is(err, ErrUnauthenticated):
+		status = http.StatusUnauthorized
+		p = problem.New(
+			problem.Title("Unauthorized"),
+			problem.Type("errors:auth/unauthenticated"),
+			problem.Detail(err.Error()),
+			problem.Status(status),
+		)
+	case errors.Is(err, oidc.ErrKeySetUnavailable):
+		GetLogger(ctx).Error("the key set is unavailable", zap.Error(err))
+
+		status = http.StatusServiceUnavailable
+		p = problem.New(
+			problem.Title("Service Unavailable"),
+			problem.Type("errors:auth/key-set-unavailable"),
+			problem.Detail("the token cannot be verified for now, try again later"),
+			problem.Status(status),
+		)
+	case errors.Is(err, ErrForbidden):
+		status = http.StatusForbidden
+		p = problem.New(
+			problem.Title("Forbidden"),
+			problem.Type("errors:auth/forbidden"),
+			problem.Detail(err.Error()),
+			problem.Status(status),
+		)
+	case errors.As(err, &qe):
+		status = http.StatusForbidden
+		p = problem.New(
+			problem.Title("Quota Exceeded"),
+			problem.Type("errors:tenant/quota-exceeded"),
+			problem.Detail(err.Error()),
+			problem.Status(status),
+			problem.Custom("quota", qe.Quota),
+			problem.Custom("limit", qe.Limit),
+		)
+	case errors.As(err, &ve):
+		status = http.StatusUnprocessableEntity
+		p = problem.New(
+			problem.Title("Unprocessable Entity"),
+			problem.Type("errors:http/invalid-params"),
+			problem.Detail("the request failed validation"),
+			problem.Status(status),
+			problem.Custom("invalid-params", InvalidParams(ve)),
+		)
+	case isAny(err, unprocessable...):
+		status = http.StatusUnprocessableEntity
+		p = problem.New(
+			problem.Title("Unprocessable Entity"),
+			problem.Type("errors:http/unprocessable-entity"),
+			problem.Detail(err.Error()),
+			problem.Status(status),
+		)
+	case IsMalformed(err):
+		status = http.StatusBadRequest
 		p = problem.New(
 			problem.Title("Bad Request"),
 			problem.Type("errors:http/bad-request"),
 			problem.Detail(err.Error()),
-			problem.Status(http.StatusBadRequest),
+			problem.Status(status),
+		)
+	case IsConflict(err):
+		detail := "the record conflicts with an existing one"
+		if isForeignKeyViolation(err) {
+			detail = "the record references, or is still referenced by, other records"
+		}
+
+		status = http.StatusConflict
+		p = problem.New(
+			problem.Title("Conflict"),
+			problem.Type("errors:database/conflict"),
+			problem.Detail(detail),
+			problem.Status(status),
+		)
+	case errors.As(err, &se):
+		reason := se.Status().Reason
+		status = ClusterStatus(reason)
+		p = problem.New(
+			problem.Title(http.StatusText(status)),
+			problem.Type("errors:workflow/cluster-error"),
+			problem.Detail(err.Error()),
+			problem.Status(status),
+			problem.Custom("reason", reason),
+		)
+	case errors.As(err, &we) && IsUnavailable(err):
+		status = http.StatusServiceUnavailable
+		p = problem.New(
+			problem.Title("Service Unavailable"),
+			problem.Type("errors:workflow/cluster-unavailable"),
+			problem.Detail(err.Error()),
+			problem.Status(status),
+		)
+	case errors.As(err, &we):
+		status = http.StatusBadGateway
+		p = problem.New(
+			problem.Title("Bad Gateway"),
+			problem.Type("errors:workflow/apply-failed"),
+			problem.Detail(err.Error()),
+			problem.Status(status),
+		)
+	case IsUnavailable(err):
+		GetLogger(ctx).Error("a dependency is unavailable", zap.Error(err))
+
+		status = http.StatusServiceUnavailable
+		p = problem.New(
+			problem.Title("Service Unavailable"),
+			problem.Type("errors:http/service-unavailable"),
+			problem.Detail("a dependency is unavailable, try again later"),
+			problem.Status(status),
+		)
+	default:
+		correlationID := uuid.NewString()
+		GetLogger(ctx).Error("unexpected error", zap.String("correlation_id", correlationID), zap.Error(err))
+
+		status = http.StatusInternalServerError
+		p = problem.New(
+			problem.Title("Internal Server Error"),
+			problem.Type("errors:http/internal-server-error"),
+			problem.Detail("an unexpected error occurred"),
+			problem.Status(status),
+			problem.Custom("correlation_id", correlationID),
 		)
 	}
 
+	ctx.Status(status)
+
 	if _, err := p.WriteTo(ctx.Writer); err != nil {
 		panic(err)
 	}
 }
+
+// InvalidParams lists the fields that failed validation. Their names are
+// relative to the validated struct, and their reasons tell the failed rule.
+func InvalidParams(errs validator.ValidationErrors) []InvalidParam {
+	params := make([]InvalidParam, 0, len(errs))
+
+	for _, fe := range errs {
+		// The namespaces start with the validated struct, and go through the
+		// embedded ones, like query, which are only told apart by their
+		// unexported type names.
+		names, fields := strings.Split(fe.Namespace(), "."), strings.Split(fe.StructNamespace(), ".")
+
+		var path []string
+		for i := 1; i < len(names) && i < len(fields); i++ {
+			if r, _ := utf8.DecodeRuneInString(fields[i]); !unicode.IsLower(r) {
+				path = append(path, names[i])
+			}
+		}
+
+		name := strings.Join(path, ".")
+
+		reason := fmt.Sprintf("failed the %s rule", fe.Tag())
+		if fe.Param() != "" {
+			reason = fmt.Sprintf("failed the %s=%s rule", fe.Tag(), fe.Param())
+		}
+
+		params = append(params, InvalidParam{Name: name, Reason: reason})
+	}
+
+	return params
+}
+
+// IsMalformed reports whether err comes from a request that cannot be parsed,
+// like a body that is not JSON or a query parameter of the wrong type.
+func IsMalformed(err error) bool {
+	var (
+		syntaxErr   *json.SyntaxError
+		typeErr     *json.UnmarshalTypeError
+		numErr      *strconv.NumError
+		timeErr     *time.ParseError
+		maxBytesErr *http.MaxBytesError
+	)
+
+	return errors.As(err, &syntaxErr) ||
+		errors.As(err, &typeErr) ||
+		errors.As(err, &numErr) ||
+		errors.As(err, &timeErr) ||
+		errors.As(err, &maxBytesErr) ||
+		isAny(err, io.EOF, io.ErrUnexpectedEOF, jsonpatch.ErrBadJSONDoc, jsonpatch.ErrBadJSONPatch)
+}
+
+// IsConflict reports whether err is a unique violation, or a foreign key
+// violation like deleting a tenant that still owns triggers.
+func IsConflict(err error) bool {
+	return errors.Is(err, gorm.ErrDuplicatedKey) || sqlState(err) == "23505" || isForeignKeyViolation(err)
+}
+
+func isForeignKeyViolation(err error) bool {
+	return errors.Is(err, gorm.ErrForeignKeyViolated) || sqlState(err) == "23503"
+}
+
+// IsUnavailable reports whether err comes from a dependency that cannot be
+// reached, or that refuses connections for now.
+func IsUnavailable(err error) bool {
+	var netErr net.Error
+
+	if errors.As(err, &netErr) || isAny(err, driver.ErrBadConn, sql.ErrConnDone, context.DeadlineExceeded) {
+		return true
+	}
+
+	// Connection exceptions, insufficient resources and operator intervention,
+	// like a database shutting down.
+	state := sqlState(err)
+
+	return strings.HasPrefix(state, "08") || strings.HasPrefix(state, "53") || strings.HasPrefix(state, "57P")
+}
+
+// ClusterStatus returns the status of the errors of the Kubernetes API with
+// the given reason. The cluster rejecting a request, other than for a conflict
+// or an invalid object, is a fault of the gateway rather than of the client.
+func ClusterStatus(reason metav1.StatusReason) int {
+	switch reason {
+	case metav1.StatusReasonNotFound, metav1.StatusReasonGone:
+		return http.StatusNotFound
+	case metav1.StatusReasonAlreadyExists, metav1.StatusReasonConflict:
+		return http.StatusConflict
+	case metav1.StatusReasonInvalid:
+		return http.StatusUnprocessableEntity
+	case metav1.StatusReasonTimeout, metav1.StatusReasonServerTimeout, metav1.StatusReasonTooManyRequests, metav1.StatusReasonServiceUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// sqlState returns the SQLSTATE code of a database error, as told by the pgx
+// errors, or an empty string.
+func sqlState(err error) string {
+	var e interface{ SQLState() string }
+
+	if errors.As(err, &e) {
+		return e.SQLState()
+	}
+
+	return ""
+}
+
+func isAny(err error, targets ...error) bool {
+	return slices.ContainsFunc(targets, func(target error) bool { return errors.Is(err, target) })
+}
@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/oidc"
+	"github.com/skhaz/scheduler/workflow"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// PgError stands for the errors of the database driver, which tell their
+// SQLSTATE code.
+type PgError struct {
+	Code string
+}
+
+func (e *PgError) Error() string { return "ERROR (SQLSTATE " + e.Code + ")" }
+
+func (e *PgError) SQLState() string { return e.Code }
+
+func handleError(err error) *httptest.ResponseRecorder {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/", nil)
+
+	HandleError(ctx, err)
+
+	return r
+}
+
+func TestHandleError(t *testing.T) {
+	resource := schema.GroupResource{Group: "argoproj.io", Resource: "cronworkflows"}
+	dial := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	for _, test := range []struct {
+		err    error
+		status int
+		kind   string
+	}{
+		{fmt.Errorf("get: %w", &PgError{Code: "23505"}), http.StatusConflict, "errors:database/conflict"},
+		{&PgError{Code: "23503"}, http.StatusConflict, "errors:database/conflict"},
+		{&PgError{Code: "08006"}, http.StatusServiceUnavailable, "errors:http/service-unavailable"},
+		{driver.ErrBadConn, http.StatusServiceUnavailable, "errors:http/service-unavailable"},
+		{fmt.Errorf("%w: %w", oidc.ErrKeySetUnavailable, dial), http.StatusServiceUnavailable, "errors:auth/key-set-unavailable"},
+		{&json.SyntaxError{}, http.StatusBadRequest, "errors:http/bad-request"},
+		{ErrImmutableName, http.StatusUnprocessableEntity, "errors:http/unprocessable-entity"},
+		{model.ErrNeverRuns, http.StatusUnprocessableEntity, "errors:http/unprocessable-entity"},
+		{apierrors.NewNotFound(resource, "trigger"), http.StatusNotFound, "errors:workflow/cluster-error"},
+		{apierrors.NewConflict(resource, "trigger", errors.New("modified")), http.StatusConflict, "errors:workflow/cluster-error"},
+		{apierrors.NewServiceUnavailable("etcd is down"), http.StatusServiceUnavailable, "errors:workflow/cluster-error"},
+		{apierrors.NewForbidden(resource, "trigger", errors.New("rbac")), http.StatusBadGateway, "errors:workflow/cluster-error"},
+		{&workflow.Error{Op: workflow.Deploy, Kind: "CronWorkflow", Err: apierrors.NewInvalid(schema.GroupKind{Kind: "CronWorkflow"}, "trigger", nil)}, http.StatusUnprocessableEntity, "errors:workflow/cluster-error"},
+		{&workflow.Error{Op: workflow.Deploy, Kind: "CronWorkflow", Err: dial}, http.StatusServiceUnavailable, "errors:workflow/cluster-unavailable"},
+		{&workflow.Error{Op: workflow.Deploy, Kind: "CronWorkflow", Err: errors.New("no kind is registered")}, http.StatusBadGateway, "errors:workflow/apply-failed"},
+	} {
+		r := handleError(test.err)
+
+		assert.Equal(t, test.status, r.Code, test.err)
+		assert.Equal(t, "application/problem+json", r.Header().Get("Content-Type"))
+		assert.Contains(t, r.Body.String(), fmt.Sprintf(`"type":%q`, test.kind), test.err)
+	}
+}
+
+func TestHandleErrorValidation(t *testing.T) {
+	err := validate.Struct(model.Trigger{Name: "trigger", Schedule: "* * * * *", Timezone: "UTC", Timeout: 60, Retry: 3, SuccessCriteria: &model.SuccessCriteria{Statuses: []string{"6xx"}}})
+
+	r := handleError(err)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, r.Code)
+	assert.Contains(t, r.Body.String(), `"invalid-params":[{"name":"success_criteria.statuses[0]","reason":"failed the statusrange rule"}]`)
+}
+
+func TestHandleErrorUnknown(t *testing.T) {
+	r := handleError(errors.New("pq: password authentication failed"))
+
+	assert.Equal(t, http.StatusInternalServerError, r.Code)
+	assert.Contains(t, r.Body.String(), `"correlation_id":"`)
+	assert.NotContains(t, r.Body.String(), "password")
+}
@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+	"gorm.io/gorm"
+)
+
+type executionParams struct {
+	params
+	Execution string `uri:"execution" validate:"required,uuid"`
+}
+
+func GetExecutionRepository(ctx *gin.Context) repository.Repository {
+	return ctx.MustGet("RepositoryRegistry").(*repository.RepositoryRegistry).MustRepository("ExecutionRepository")
+}
+
+// GetExecutions lists the executions of a trigger from the executions table,
+// where the recorder copies them from the cluster.
+func GetExecutions(ctx *gin.Context) {
+	p := params{}
+
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	var q = query{}
+
+	if err := ctx.ShouldBindQuery(&q); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	e, err := GetTriggerRepository(ctx).Get(p.ID)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	trigger := e.(*model.Trigger)
+
+	c, err := GetExecutionRepository(ctx).List(q.After, q.Limit, repository.WhereTrigger(trigger.ID))
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteHAL(ctx, http.StatusOK, c.(model.ExecutionCollection).ToHAL(ctx.Request.URL.Path, ctx.Request.URL.Query()))
+}
+
+func GetExecution(ctx *gin.Context) {
+	p := executionParams{}
+
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	e, err := GetTriggerRepository(ctx).Get(p.ID)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	trigger := e.(*model.Trigger)
+
+	e, err = GetExecutionRepository(ctx).Get(p.Execution)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	execution := e.(*model.Execution)
+	if execution.TriggerID != trigger.ID {
+		HandleError(ctx, gorm.ErrRecordNotFound)
+
+		return
+	}
+
+	WriteHAL(ctx, http.StatusOK, execution.ToHAL(ctx.Request.URL.Path))
+}
+
+// RunTrigger submits a one-off execution of the trigger, the response links to
+// the execution so its progress can be polled.
+func RunTrigger(ctx *gin.Context) {
+	p := params{}
+
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	e, err := GetTriggerRepository(ctx).Get(p.ID)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	trigger := e.(*model.Trigger)
+
+	execution, err := GetWorkflow(ctx).Submit(trigger.ID.String(), trigger.Name)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	execution.TriggerID = trigger.ID
+
+	err = Transactional(ctx, func(registry *repository.RepositoryRegistry) (func() error, error) {
+		if _, err := registry.MustRepository("ExecutionRepository").Create(execution); err != nil {
+			return nil, err
+		}
+
+		return nil, Audit(ctx, registry, model.ActionRun, trigger, trigger)
+	})
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	selfHref, _ := url.JoinPath(ctx.Request.URL.Path, "..", "executions", execution.ID.String())
+	ctx.Header("Location", selfHref)
+	WriteHAL(ctx, http.StatusCreated, execution.ToHAL(selfHref))
+}
@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/thanhpk/randstr"
+	"gorm.io/gorm"
+)
+
+type ExecutionRepository struct {
+	err        error
+	execution  *model.Execution
+	executions model.ExecutionCollection
+	created    model.ExecutionCollection
+}
+
+func (r *ExecutionRepository) Configure(db *gorm.DB) {
+}
+
+func (r *ExecutionRepository) List(after time.Time, limit int, scopes ...repository.Scope) (any, error) {
+	return r.executions, r.err
+}
+
+func (r *ExecutionRepository) Get(id any) (any, error) {
+	return r.execution, r.err
+}
+
+func (r *ExecutionRepository) Create(entity any) (any, error) {
+	r.created = append(r.created, entity.(*model.Execution))
+	return entity, r.err
+}
+
+func (r *ExecutionRepository) Update(id any, entity any) (bool, error) {
+	return true, r.err
+}
+
+func (r *ExecutionRepository) Delete(id any) (bool, error) {
+	return true, r.err
+}
+
+func TestGetExecutions(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	trigger := model.Trigger{ID: id, Name: randstr.String(16)}
+	execution := model.Execution{ID: uuid.New(), Name: randstr.String(16), Phase: model.Succeeded, StatusCode: 200, StartedAt: time.Now()}
+	executions := &ExecutionRepository{executions: model.ExecutionCollection{&execution}}
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/triggers/"+id.String()+"/executions", nil)
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger}, executions))
+	ctx.Set("Workflow", &Workflow{executions: model.ExecutionCollection{&execution}})
+
+	GetExecutions(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "application/hal+json", r.Header().Get("Content-Type"))
+	assert.Contains(t, r.Body.String(), `"status_code":200`)
+	assert.Contains(t, r.Body.String(), fmt.Sprintf(`"href":"/triggers/%v/executions/%v"`, id, execution.ID))
+	assert.Empty(t, executions.created)
+}
+
+func TestGetExecutionsClusterError(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	trigger := model.Trigger{ID: id, Name: randstr.String(16)}
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/triggers/"+id.String()+"/executions", nil)
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger}, &ExecutionRepository{}))
+	ctx.Set("Workflow", &Workflow{err: errors.New("the server is currently unable to handle the request")})
+
+	GetExecutions(ctx)
+
+	// The history is read from the table, so the cluster being down does not
+	// get in the way.
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "application/hal+json", r.Header().Get("Content-Type"))
+}
+
+func TestGetExecution(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	trigger := model.Trigger{ID: id, Name: randstr.String(16)}
+	execution := model.Execution{ID: uuid.New(), TriggerID: id, Name: randstr.String(16), Phase: model.Running, StartedAt: time.Now()}
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/triggers/"+id.String()+"/executions/"+execution.ID.String(), nil)
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}, {Key: "execution", Value: execution.ID.String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger}, &ExecutionRepository{execution: &execution}))
+	ctx.Set("Workflow", &Workflow{})
+
+	GetExecution(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "application/hal+json", r.Header().Get("Content-Type"))
+	assert.Contains(t, r.Body.String(), `"phase":"Running"`)
+}
+
+func TestGetExecutionOfAnotherTrigger(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	trigger := model.Trigger{ID: id, Name: randstr.String(16)}
+	execution := model.Execution{ID: uuid.New(), TriggerID: uuid.New(), Name: randstr.String(16), Phase: model.Running}
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/", nil)
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}, {Key: "execution", Value: execution.ID.String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger}, &ExecutionRepository{execution: &execution}))
+	ctx.Set("Workflow", &Workflow{})
+
+	GetExecution(ctx)
+
+	assert.Equal(t, http.StatusNotFound, r.Code)
+	assert.Equal(t, "application/problem+json", r.Header().Get("Content-Type"))
+}
+
+func TestRunTrigger(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	trigger := model.Trigger{ID: id, Name: randstr.String(16)}
+	execution := model.Execution{ID: uuid.New(), Name: randstr.String(16), Phase: model.Pending}
+	executions, audit := &ExecutionRepository{}, &AuditEntryRepository{}
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/triggers/"+id.String()+"/run", nil)
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger}, executions, audit))
+	ctx.Set("Workflow", &Workflow{executions: model.ExecutionCollection{&execution}})
+
+	RunTrigger(ctx)
+
+	location := fmt.Sprintf("/triggers/%v/executions/%v", id, execution.ID)
+
+	assert.Equal(t, http.StatusCreated, r.Code)
+	assert.Equal(t, "application/hal+json", r.Header().Get("Content-Type"))
+	assert.Equal(t, location, r.Header().Get("Location"))
+	assert.Contains(t, r.Body.String(), fmt.Sprintf(`"href":"%v"`, location))
+	assert.Len(t, executions.created, 1)
+	assert.Equal(t, id, executions.created[0].TriggerID)
+	assert.Len(t, audit.created, 1)
+	assert.Equal(t, model.ActionRun, audit.created[0].Action)
+}
+
+func TestRunTriggerClusterError(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	trigger := model.Trigger{ID: id, Name: randstr.String(16)}
+	executions, audit := &ExecutionRepository{}, &AuditEntryRepository{}
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/triggers/"+id.String()+"/run", nil)
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger}, executions, audit))
+	ctx.Set("Workflow", &Workflow{err: errors.New("cronworkflows.argoproj.io not found")})
+
+	RunTrigger(ctx)
+
+	assert.Equal(t, "application/problem+json", r.Header().Get("Content-Type"))
+	assert.Empty(t, executions.created)
+}
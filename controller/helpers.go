@@ -1,14 +1,32 @@
 package controller
 
 import (
+	"mime"
 	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	"github.com/pmoule/go2hal/hal"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+	"github.com/skhaz/scheduler/workflow"
+	"go.uber.org/zap"
+	"golang.org/x/net/http/httpguts"
 )
 
-var validate = validator.New()
+var validate = NewValidator()
+
+func init() {
+	// The query parameters are validated by gin, so its validator reports
+	// them by their names too.
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(FieldName)
+	}
+}
 
 var encoder = hal.NewEncoder()
 
@@ -23,3 +41,102 @@ func WriteHAL(ctx *gin.Context, statusCode int, resource hal.Resource) {
 func WriteNoContent(ctx *gin.Context) {
 	ctx.JSON(http.StatusNoContent, nil)
 }
+
+func GetRepositoryRegistry(ctx *gin.Context) *repository.RepositoryRegistry {
+	return ctx.MustGet("RepositoryRegistry").(*repository.RepositoryRegistry)
+}
+
+func GetLogger(ctx *gin.Context) *zap.Logger {
+	if logger, ok := ctx.Get("Logger"); ok {
+		return logger.(*zap.Logger)
+	}
+
+	return zap.NewNop()
+}
+
+// Apply performs op on the objects of manifest and logs what happened to each
+// one of them.
+func Apply(ctx *gin.Context, wf workflow.Interface, manifest []byte, op workflow.Operation) error {
+	results, err := wf.Apply(manifest, op)
+
+	logger := GetLogger(ctx)
+	for _, result := range results {
+		logger.Info("applied manifest", zap.String("op", string(op)), zap.Stringer("result", result))
+	}
+
+	return err
+}
+
+// Transactional runs fn inside a database transaction. fn changes the cluster
+// as its last step and returns how to undo that change, which is called when
+// the transaction fails to commit after the cluster was already changed.
+func Transactional(ctx *gin.Context, fn func(*repository.RepositoryRegistry) (func() error, error)) error {
+	var compensate func() error
+
+	err := GetRepositoryRegistry(ctx).Transaction(func(registry *repository.RepositoryRegistry) (err error) {
+		compensate, err = fn(registry)
+		return err
+	})
+
+	if err != nil && compensate != nil {
+		if err := compensate(); err != nil {
+			GetLogger(ctx).Error("failed to compensate the cluster after a rollback", zap.Error(err))
+		}
+	}
+
+	return err
+}
+
+// FieldName returns the name of a field in requests, from its json or form
+// tag, so validation errors can be reported by it.
+func FieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(key), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+// NewValidator returns a validator that also knows how to check the HTTP
+// request and the success criteria parts of a trigger, and the subjects of
+// role bindings.
+func NewValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(FieldName)
+
+	_ = v.RegisterValidation("httpheader", func(fl validator.FieldLevel) bool {
+		return httpguts.ValidHeaderFieldName(fl.Field().String())
+	})
+
+	_ = v.RegisterValidation("httpheadervalue", func(fl validator.FieldLevel) bool {
+		return httpguts.ValidHeaderFieldValue(fl.Field().String())
+	})
+
+	_ = v.RegisterValidation("mediatype", func(fl validator.FieldLevel) bool {
+		_, _, err := mime.ParseMediaType(fl.Field().String())
+		return err == nil
+	})
+
+	_ = v.RegisterValidation("statusrange", func(fl validator.FieldLevel) bool {
+		_, err := model.ParseStatusRange(fl.Field().String())
+		return err == nil
+	})
+
+	_ = v.RegisterValidation("posixregexp", func(fl validator.FieldLevel) bool {
+		_, err := regexp.CompilePOSIX(fl.Field().String())
+		return err == nil
+	})
+
+	_ = v.RegisterValidation("jsonpath", func(fl validator.FieldLevel) bool {
+		_, err := model.ParseJSONPath(fl.Field().String())
+		return err == nil
+	})
+
+	_ = v.RegisterValidation("subject", func(fl validator.FieldLevel) bool {
+		return model.ValidSubject(fl.Field().String())
+	})
+
+	return v
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/skhaz/scheduler/repository"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func mockRegistry(t *testing.T, v ...repository.Repository) (*repository.RepositoryRegistry, sqlmock.Sqlmock) {
+	conn, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{})
+	assert.NoError(t, err)
+
+	return repository.NewRepositoryRegistry(db, v...), mock
+}
+
+func TestTransactionalCommit(t *testing.T) {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	registry, mock := mockRegistry(t, &TriggerRepository{})
+	ctx.Set("RepositoryRegistry", registry)
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	compensated := false
+	err := Transactional(ctx, func(tx *repository.RepositoryRegistry) (func() error, error) {
+		assert.NotSame(t, registry, tx)
+		return func() error { compensated = true; return nil }, nil
+	})
+
+	assert.NoError(t, err)
+	assert.False(t, compensated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransactionalRollback(t *testing.T) {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	registry, mock := mockRegistry(t, &TriggerRepository{})
+	ctx.Set("RepositoryRegistry", registry)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	expected := errors.New("the cluster is unreachable")
+	err := Transactional(ctx, func(tx *repository.RepositoryRegistry) (func() error, error) {
+		return nil, expected
+	})
+
+	assert.ErrorIs(t, err, expected)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransactionalCompensate(t *testing.T) {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	registry, mock := mockRegistry(t, &TriggerRepository{})
+	ctx.Set("RepositoryRegistry", registry)
+
+	expected := errors.New("connection reset by peer")
+
+	mock.ExpectBegin()
+	mock.ExpectCommit().WillReturnError(expected)
+
+	compensated := false
+	err := Transactional(ctx, func(tx *repository.RepositoryRegistry) (func() error, error) {
+		return func() error { compensated = true; return nil }, nil
+	})
+
+	assert.ErrorIs(t, err, expected)
+	assert.True(t, compensated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,21 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skhaz/scheduler/leader"
+)
+
+func GetLeader(ctx *gin.Context) {
+	elector := ctx.MustGet("Elector").(leader.Interface)
+
+	status, err := elector.Status(ctx)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteHAL(ctx, http.StatusOK, status.ToHAL(ctx.Request.URL.Path))
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skhaz/scheduler/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/thanhpk/randstr"
+)
+
+type Elector struct {
+	err    error
+	status *model.Leader
+}
+
+func (e *Elector) Status(ctx context.Context) (*model.Leader, error) {
+	return e.status, e.err
+}
+
+func TestGetLeader(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/leader", nil)
+
+	identity := randstr.String(16)
+	ctx.Set("Elector", &Elector{status: &model.Leader{Identity: identity, Leader: identity, IsLeader: true}})
+
+	GetLeader(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "application/hal+json", r.Header().Get("Content-Type"))
+	assert.Contains(t, r.Body.String(), `"is_leader":true`)
+}
+
+func TestGetLeaderError(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/leader", nil)
+
+	ctx.Set("Elector", &Elector{err: errors.New("connection refused")})
+
+	GetLeader(ctx)
+
+	assert.Equal(t, http.StatusInternalServerError, r.Code)
+	assert.Contains(t, r.Body.String(), `"correlation_id"`)
+	assert.NotContains(t, r.Body.String(), "connection refused")
+}
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+)
+
+func GetNotificationRepository(ctx *gin.Context) repository.Repository {
+	return ctx.MustGet("RepositoryRegistry").(*repository.RepositoryRegistry).MustRepository("NotificationRepository")
+}
+
+// GetNotifications lists the notifications sent about the executions of the
+// trigger, along with whether they were delivered.
+func GetNotifications(ctx *gin.Context) {
+	p := params{}
+
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	var q = query{}
+
+	if err := ctx.ShouldBindQuery(&q); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	e, err := GetTriggerRepository(ctx).Get(p.ID)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	trigger := e.(*model.Trigger)
+
+	c, err := GetNotificationRepository(ctx).List(q.After, q.Limit, repository.WhereTrigger(trigger.ID))
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteHAL(ctx, http.StatusOK, c.(model.NotificationCollection).ToHAL(ctx.Request.URL.Path, ctx.Request.URL.Query()))
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/thanhpk/randstr"
+	"gorm.io/gorm"
+)
+
+type NotificationRepository struct {
+	err           error
+	notifications model.NotificationCollection
+}
+
+func (r *NotificationRepository) Configure(db *gorm.DB) {
+}
+
+func (r *NotificationRepository) List(after time.Time, limit int, scopes ...repository.Scope) (any, error) {
+	return r.notifications, r.err
+}
+
+func (r *NotificationRepository) Get(id any) (any, error) {
+	return nil, r.err
+}
+
+func (r *NotificationRepository) Create(entity any) (any, error) {
+	return entity, r.err
+}
+
+func (r *NotificationRepository) Update(id any, entity any) (bool, error) {
+	return true, r.err
+}
+
+func (r *NotificationRepository) Delete(id any) (bool, error) {
+	return true, r.err
+}
+
+func TestGetNotifications(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	trigger := model.Trigger{ID: id, Name: randstr.String(16)}
+	notification := model.Notification{ID: uuid.New(), TriggerID: id, ExecutionID: uuid.New(), Kind: model.Webhook, Event: model.OnFailure, State: model.Delivered, Attempts: 1, CreatedAt: time.Now()}
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/triggers/"+id.String()+"/notifications", nil)
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger}, &NotificationRepository{notifications: model.NotificationCollection{&notification}}))
+
+	GetNotifications(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "application/hal+json", r.Header().Get("Content-Type"))
+	assert.Contains(t, r.Body.String(), `"state":"delivered"`)
+	assert.Contains(t, r.Body.String(), fmt.Sprintf(`"href":"/triggers/%v/executions/%v"`, id, notification.ExecutionID))
+}
+
+func TestGetNotificationsError(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	trigger := model.Trigger{ID: id, Name: randstr.String(16)}
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/triggers/"+id.String()+"/notifications", nil)
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger}, &NotificationRepository{err: errors.New("connection refused")}))
+
+	GetNotifications(ctx)
+
+	assert.Equal(t, "application/problem+json", r.Header().Get("Content-Type"))
+}
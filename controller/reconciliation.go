@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skhaz/scheduler/reconciler"
+	"gorm.io/gorm"
+	"schneider.vip/problem"
+)
+
+func GetReconciliation(ctx *gin.Context) {
+	rec := ctx.MustGet("Reconciler").(reconciler.Interface)
+
+	last, err := rec.Last()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		p := problem.New(
+			problem.Title("Not Found"),
+			problem.Type("errors:reconciler/not-reconciled"),
+			problem.Detail("no reconciliation has finished yet"),
+			problem.Status(http.StatusNotFound),
+		)
+
+		if _, err := p.WriteTo(ctx.Writer); err != nil {
+			panic(err)
+		}
+
+		return
+	}
+
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteHAL(ctx, http.StatusOK, last.ToHAL(ctx.Request.URL.Path))
+}
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type Reconciler struct {
+	last *model.Reconciliation
+	err  error
+}
+
+func (r *Reconciler) Last() (*model.Reconciliation, error) {
+	if r.last == nil && r.err == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return r.last, r.err
+}
+
+func TestGetReconciliation(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/reconciliation", nil)
+
+	id := uuid.NewString()
+	ctx.Set("Reconciler", &Reconciler{last: &model.Reconciliation{
+		StartedAt:  time.Now(),
+		FinishedAt: time.Now(),
+		Checked:    1,
+		Created:    []string{id},
+	}})
+
+	GetReconciliation(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "application/hal+json", r.Header().Get("Content-Type"))
+	assert.Contains(t, r.Body.String(), id)
+}
+
+func TestGetReconciliationNotReconciled(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/reconciliation", nil)
+
+	ctx.Set("Reconciler", &Reconciler{})
+
+	GetReconciliation(ctx)
+
+	assert.Equal(t, http.StatusNotFound, r.Code)
+	assert.Contains(t, r.Body.String(), "errors:reconciler/not-reconciled")
+}
+
+func TestGetReconciliationError(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/reconciliation", nil)
+
+	ctx.Set("Reconciler", &Reconciler{err: errors.New("connection refused")})
+
+	GetReconciliation(ctx)
+
+	assert.Equal(t, http.StatusInternalServerError, r.Code)
+}
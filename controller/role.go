@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+)
+
+type roleBindingQuery struct {
+	query
+	Subject string `form:"subject"`
+}
+
+func GetRoleBindingRepository(ctx *gin.Context) repository.Repository {
+	return ctx.MustGet("RepositoryRegistry").(*repository.RepositoryRegistry).MustRepository("RoleBindingRepository")
+}
+
+func GetRoleBindings(ctx *gin.Context) {
+	var q = roleBindingQuery{}
+
+	if err := ctx.ShouldBindQuery(&q); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	var scopes []repository.Scope
+	if q.Subject != "" {
+		scopes = append(scopes, repository.WhereSubject(q.Subject))
+	}
+
+	e, err := GetRoleBindingRepository(ctx).List(q.After, q.Limit, scopes...)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteHAL(ctx, http.StatusOK, e.(model.RoleBindingCollection).ToHAL(ctx.Request.URL.Path, ctx.Request.URL.Query()))
+}
+
+// CreateRoleBinding grants a role to a subject, either key:<id> for an API key
+// or oidc:<issuer>|<sub> for the tokens of a user. It takes effect on the next
+// request of the subject.
+func CreateRoleBinding(ctx *gin.Context) {
+	body := model.RoleBinding{}
+
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	var binding *model.RoleBinding
+
+	err := Transactional(ctx, func(registry *repository.RepositoryRegistry) (func() error, error) {
+		e, err := registry.MustRepository("RoleBindingRepository").Create(&body)
+		if err != nil {
+			return nil, err
+		}
+
+		binding = e.(*model.RoleBinding)
+
+		return nil, Audit(ctx, registry, model.ActionCreate, nil, binding)
+	})
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	selfHref, _ := url.JoinPath(ctx.Request.URL.Path, binding.ID.String())
+	WriteHAL(ctx, http.StatusCreated, binding.ToHAL(selfHref))
+}
+
+func GetRoleBinding(ctx *gin.Context) {
+	p := params{}
+
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	e, err := GetRoleBindingRepository(ctx).Get(p.ID)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteHAL(ctx, http.StatusOK, e.(*model.RoleBinding).ToHAL(ctx.Request.URL.Path))
+}
+
+func DeleteRoleBinding(ctx *gin.Context) {
+	p := params{}
+
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	e, err := GetRoleBindingRepository(ctx).Get(p.ID)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	binding := e.(*model.RoleBinding)
+
+	err = Transactional(ctx, func(registry *repository.RepositoryRegistry) (func() error, error) {
+		if _, err := registry.MustRepository("RoleBindingRepository").Delete(binding.ID); err != nil {
+			return nil, err
+		}
+
+		return nil, Audit(ctx, registry, model.ActionDelete, binding, nil)
+	})
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteNoContent(ctx)
+}
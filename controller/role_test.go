@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type RoleBindingRepository struct {
+	err      error
+	bindings model.RoleBindingCollection
+	created  *model.RoleBinding
+}
+
+func (r *RoleBindingRepository) Configure(db *gorm.DB) {
+}
+
+// List ignores its scopes, as the bindings of the tests are all of the same
+// subject.
+func (r *RoleBindingRepository) List(after time.Time, limit int, scopes ...repository.Scope) (any, error) {
+	return r.bindings, r.err
+}
+
+func (r *RoleBindingRepository) Get(id any) (any, error) {
+	binding, _ := model.Last(r.bindings)
+	return binding, r.err
+}
+
+func (r *RoleBindingRepository) Create(entity any) (any, error) {
+	r.created = entity.(*model.RoleBinding)
+	r.created.ID = uuid.New()
+	return r.created, r.err
+}
+
+func (r *RoleBindingRepository) Update(id any, entity any) (bool, error) {
+	return true, r.err
+}
+
+func (r *RoleBindingRepository) Delete(id any) (bool, error) {
+	return true, r.err
+}
+
+func TestAuthenticateOperator(t *testing.T) {
+	token, _ := model.GenerateAPIKey()
+
+	key := &model.APIKey{ID: uuid.New(), Scopes: []string{model.TriggersRead}}
+	key.SetKey(token)
+
+	router := newAuthenticatedRouter(Authenticate("", nil), model.RoleBindingCollection{{Subject: model.KeySubject(key.ID), Role: model.Operator}}, key)
+
+	assert.Equal(t, http.StatusOK, serve(router, http.MethodGet, token).Code)
+	assert.Equal(t, http.StatusOK, serve(router, http.MethodPut, token).Code)
+
+	r := serve(router, http.MethodDelete, token)
+	assert.Equal(t, http.StatusForbidden, r.Code)
+	assert.Equal(t, "application/problem+json", r.Header().Get("Content-Type"))
+	assert.Contains(t, r.Body.String(), `"type":"errors:auth/forbidden"`)
+}
+
+func TestAuthenticateEditor(t *testing.T) {
+	token, _ := model.GenerateAPIKey()
+
+	key := &model.APIKey{ID: uuid.New(), Scopes: []string{model.TriggersRead}}
+	key.SetKey(token)
+
+	router := newAuthenticatedRouter(Authenticate("", nil), model.RoleBindingCollection{{Subject: model.KeySubject(key.ID), Role: model.Editor}}, key)
+
+	assert.Equal(t, http.StatusOK, serve(router, http.MethodPut, token).Code)
+	assert.Equal(t, http.StatusOK, serve(router, http.MethodDelete, token).Code)
+}
+
+func TestGetRoleBindings(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	binding := model.RoleBinding{ID: uuid.New(), Subject: uuid.NewString(), Role: model.Viewer, CreatedAt: time.Now()}
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/admin/roles?subject="+binding.Subject, nil)
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &RoleBindingRepository{bindings: model.RoleBindingCollection{&binding}}))
+
+	GetRoleBindings(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Contains(t, r.Body.String(), `"role":"viewer"`)
+	assert.Contains(t, r.Body.String(), `"href":"/admin/roles/`+binding.ID.String()+`"`)
+}
+
+func TestCreateRoleBinding(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	bindings, audit := &RoleBindingRepository{}, &AuditEntryRepository{}
+
+	b, _ := json.Marshal(model.RoleBinding{Subject: model.KeySubject(uuid.New()), Role: model.Operator})
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/admin/roles", bytes.NewBuffer(b))
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, bindings, audit))
+
+	CreateRoleBinding(ctx)
+
+	assert.Equal(t, http.StatusCreated, r.Code)
+	assert.Equal(t, "application/hal+json", r.Header().Get("Content-Type"))
+	assert.Equal(t, model.Operator, bindings.created.Role)
+	assert.Len(t, audit.created, 1)
+	assert.Equal(t, model.ResourceRoleBinding, audit.created[0].Resource)
+}
+
+func TestCreateRoleBindingUnknownRole(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	bindings := &RoleBindingRepository{}
+
+	b, _ := json.Marshal(model.RoleBinding{Subject: model.KeySubject(uuid.New()), Role: "owner"})
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/admin/roles", bytes.NewBuffer(b))
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, bindings))
+
+	CreateRoleBinding(ctx)
+
+	assert.Equal(t, "application/problem+json", r.Header().Get("Content-Type"))
+	assert.Nil(t, bindings.created)
+}
+
+func TestCreateRoleBindingInvalidSubject(t *testing.T) {
+	for _, subject := range []string{uuid.NewString(), "alice", "key:alice", "oidc:alice", "oidc:|alice"} {
+		r := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(r)
+		bindings := &RoleBindingRepository{}
+
+		b, _ := json.Marshal(model.RoleBinding{Subject: subject, Role: model.Viewer})
+		ctx.Request, _ = http.NewRequest(http.MethodPost, "/admin/roles", bytes.NewBuffer(b))
+
+		ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, bindings))
+
+		CreateRoleBinding(ctx)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, r.Code, subject)
+		assert.Nil(t, bindings.created, subject)
+	}
+}
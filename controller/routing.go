@@ -3,6 +3,10 @@ package controller
 import (
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
+	"github.com/skhaz/scheduler/leader"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/oidc"
+	"github.com/skhaz/scheduler/reconciler"
 	"github.com/skhaz/scheduler/repository"
 	"github.com/skhaz/scheduler/workflow"
 	"go.uber.org/zap"
@@ -48,16 +52,99 @@ func (s *Server) SetWorkflow(wf workflow.Interface) {
 	})
 }
 
+func (s *Server) SetReconciler(rec reconciler.Interface) {
+	s.router.Use(func(c *gin.Context) {
+		c.Set("Reconciler", rec)
+		c.Next()
+	})
+}
+
+func (s *Server) SetElector(elector leader.Interface) {
+	s.router.Use(func(c *gin.Context) {
+		c.Set("Elector", elector)
+		c.Next()
+	})
+}
+
+// SetAuthentication resolves the API key or the JWT of every request to its
+// principal, see Authenticate, and scopes the request to the tenant of the
+// principal. The keys are looked up in the registry, so it must be set before.
+// verifier is nil when JWTs are not accepted.
+func (s *Server) SetAuthentication(adminKey string, verifier *oidc.Verifier) {
+	s.router.Use(Authenticate(adminKey, verifier), ScopeTenant)
+}
+
 func (s *Server) registerRoutes() {
 	var router = s.router
 
 	router.NoRoute(NoRoute)
 
+	router.GET("/reconciliation", RequireScope(model.Admin), GetReconciliation)
+	router.GET("/leader", RequireScope(model.Admin), GetLeader)
+
 	triggers := router.Group("/triggers")
 	{
-		triggers.GET("", GetTriggers)
-		triggers.POST("", CreateTrigger)
-		triggers.GET("/:uuid", GetTrigger)
-		triggers.DELETE("/:uuid", DeleteTrigger)
+		read, write := RequireScope(model.TriggersRead), RequireScope(model.TriggersWrite)
+		run, operate := RequireScope(model.TriggersRun), RequireScope(model.TriggersOperate)
+
+		triggers.GET("", read, GetTriggers)
+		triggers.POST("", write, CreateTrigger)
+		triggers.GET("/:uuid", read, GetTrigger)
+		triggers.PUT("/:uuid", write, UpdateTrigger)
+		triggers.PATCH("/:uuid", write, PatchTrigger)
+		triggers.DELETE("/:uuid", write, DeleteTrigger)
+		triggers.POST("/:uuid/pause", operate, PauseTrigger)
+		triggers.POST("/:uuid/resume", operate, ResumeTrigger)
+		triggers.POST("/:uuid/secret", write, RotateSecret)
+		triggers.POST("/:uuid/restore", write, RestoreTrigger)
+		triggers.POST("/:uuid/run", run, RunTrigger)
+		triggers.GET("/:uuid/executions", read, GetExecutions)
+		triggers.GET("/:uuid/executions/:execution", read, GetExecution)
+		triggers.GET("/:uuid/notifications", read, GetNotifications)
+	}
+
+	webhooks := router.Group("/webhooks")
+	{
+		read, write := RequireScope(model.WebhooksRead), RequireScope(model.WebhooksWrite)
+
+		webhooks.GET("", read, GetWebhooks)
+		webhooks.POST("", write, CreateWebhook)
+		webhooks.GET("/:uuid", read, GetWebhook)
+		webhooks.PUT("/:uuid", write, UpdateWebhook)
+		webhooks.DELETE("/:uuid", write, DeleteWebhook)
+		webhooks.GET("/:uuid/deliveries", read, GetDeliveries)
+		webhooks.GET("/:uuid/deliveries/:delivery", read, GetDelivery)
+		webhooks.POST("/:uuid/deliveries/:delivery/redeliver", write, Redeliver)
+	}
+
+	audit := router.Group("/audit", RequireScope(model.AuditRead))
+	{
+		audit.GET("", GetAuditEntries)
+		audit.GET("/:uuid", GetAuditEntry)
+	}
+
+	tenants := router.Group("/admin/tenants", RequireScope(model.Admin))
+	{
+		tenants.GET("", GetTenants)
+		tenants.POST("", CreateTenant)
+		tenants.GET("/:uuid", GetTenant)
+		tenants.PUT("/:uuid", UpdateTenant)
+		tenants.DELETE("/:uuid", DeleteTenant)
+	}
+
+	roles := router.Group("/admin/roles", RequireScope(model.Admin))
+	{
+		roles.GET("", GetRoleBindings)
+		roles.POST("", CreateRoleBinding)
+		roles.GET("/:uuid", GetRoleBinding)
+		roles.DELETE("/:uuid", DeleteRoleBinding)
+	}
+
+	keys := router.Group("/admin/keys", RequireScope(model.Admin))
+	{
+		keys.GET("", GetAPIKeys)
+		keys.POST("", CreateAPIKey)
+		keys.GET("/:uuid", GetAPIKey)
+		keys.DELETE("/:uuid", RevokeAPIKey)
 	}
 }
@@ -0,0 +1,252 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+)
+
+var ErrNoCounter = errors.New("triggers cannot be counted")
+
+func GetTenantRepository(ctx *gin.Context) repository.Repository {
+	return ctx.MustGet("RepositoryRegistry").(*repository.RepositoryRegistry).MustRepository("TenantRepository")
+}
+
+// CheckQuotas returns a model.QuotaError when trigger would exceed a quota of
+// its tenant, which is the tenant of the request or, for admins, the one the
+// trigger is assigned to. creating tells whether trigger is a new trigger, in
+// which case the tenant is locked until the end of the transaction of
+// registry, so concurrent requests cannot create more triggers than allowed.
+func CheckQuotas(ctx *gin.Context, registry *repository.RepositoryRegistry, trigger *model.Trigger, creating bool) error {
+	tenantID := trigger.TenantID
+	if principal, ok := GetPrincipal(ctx); ok && principal.Tenant != nil {
+		tenantID = principal.Tenant
+	}
+
+	if tenantID == nil {
+		return nil
+	}
+
+	tenantRepository := registry.MustRepository("TenantRepository")
+
+	get := tenantRepository.Get
+	if creating {
+		locker, ok := tenantRepository.(repository.Locker)
+		if !ok {
+			return repository.ErrNoLocker
+		}
+
+		get = locker.Lock
+	}
+
+	e, err := get(*tenantID)
+	if err != nil {
+		return err
+	}
+
+	tenant := e.(*model.Tenant)
+
+	if err := tenant.CheckSchedule(trigger.Schedule, trigger.Timezone); err != nil {
+		return err
+	}
+
+	if !creating {
+		return nil
+	}
+
+	counter, ok := registry.MustRepository("TriggerRepository").(repository.Counter)
+	if !ok {
+		return ErrNoCounter
+	}
+
+	count, err := counter.Count(repository.WhereTenant(*tenantID))
+	if err != nil {
+		return err
+	}
+
+	return tenant.CheckTriggers(int(count) + 1)
+}
+
+func GetTenants(ctx *gin.Context) {
+	var q = query{}
+
+	if err := ctx.ShouldBindQuery(&q); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	e, err := GetTenantRepository(ctx).List(q.After, q.Limit)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteHAL(ctx, http.StatusOK, e.(model.TenantCollection).ToHAL(ctx.Request.URL.Path, ctx.Request.URL.Query()))
+}
+
+func CreateTenant(ctx *gin.Context) {
+	body := model.Tenant{}
+
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	var tenant *model.Tenant
+
+	err := Transactional(ctx, func(registry *repository.RepositoryRegistry) (func() error, error) {
+		e, err := registry.MustRepository("TenantRepository").Create(&body)
+		if err != nil {
+			return nil, err
+		}
+
+		tenant = e.(*model.Tenant)
+
+		return nil, Audit(ctx, registry, model.ActionCreate, nil, tenant)
+	})
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	selfHref, _ := url.JoinPath(ctx.Request.URL.Path, tenant.ID.String())
+	WriteHAL(ctx, http.StatusCreated, tenant.ToHAL(selfHref))
+}
+
+func GetTenant(ctx *gin.Context) {
+	p := params{}
+
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	e, err := GetTenantRepository(ctx).Get(p.ID)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteHAL(ctx, http.StatusOK, e.(*model.Tenant).ToHAL(ctx.Request.URL.Path))
+}
+
+// UpdateTenant replaces the name and quotas of a tenant. Lowering a quota does
+// not affect the triggers the tenant already has, only the next changes.
+func UpdateTenant(ctx *gin.Context) {
+	p := params{}
+
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	e, err := GetTenantRepository(ctx).Get(p.ID)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	tenant := e.(*model.Tenant)
+
+	body := model.Tenant{}
+
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	body.ID = tenant.ID
+	body.CreatedAt = tenant.CreatedAt
+
+	if err := validate.Struct(body); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	err = Transactional(ctx, func(registry *repository.RepositoryRegistry) (func() error, error) {
+		if _, err := registry.MustRepository("TenantRepository").Update(tenant.ID, &body); err != nil {
+			return nil, err
+		}
+
+		return nil, Audit(ctx, registry, model.ActionUpdate, tenant, &body)
+	})
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteHAL(ctx, http.StatusOK, body.ToHAL(ctx.Request.URL.Path))
+}
+
+func DeleteTenant(ctx *gin.Context) {
+	p := params{}
+
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	e, err := GetTenantRepository(ctx).Get(p.ID)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	tenant := e.(*model.Tenant)
+
+	err = Transactional(ctx, func(registry *repository.RepositoryRegistry) (func() error, error) {
+		if _, err := registry.MustRepository("TenantRepository").Delete(tenant.ID); err != nil {
+			return nil, err
+		}
+
+		return nil, Audit(ctx, registry, model.ActionDelete, tenant, nil)
+	})
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteNoContent(ctx)
+}
@@ -0,0 +1,178 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/thanhpk/randstr"
+	"gorm.io/gorm"
+)
+
+type TenantRepository struct {
+	err     error
+	tenant  *model.Tenant
+	tenants model.TenantCollection
+	updated *model.Tenant
+	locked  bool
+}
+
+func (r *TenantRepository) Configure(db *gorm.DB) {
+}
+
+func (r *TenantRepository) List(after time.Time, limit int, scopes ...repository.Scope) (any, error) {
+	return r.tenants, r.err
+}
+
+func (r *TenantRepository) Get(id any) (any, error) {
+	return r.tenant, r.err
+}
+
+func (r *TenantRepository) Lock(id any) (any, error) {
+	r.locked = true
+	return r.tenant, r.err
+}
+
+func (r *TenantRepository) Create(entity any) (any, error) {
+	tenant := entity.(*model.Tenant)
+	tenant.ID = uuid.New()
+	return tenant, r.err
+}
+
+func (r *TenantRepository) Update(id any, entity any) (bool, error) {
+	r.updated = entity.(*model.Tenant)
+	return true, r.err
+}
+
+func (r *TenantRepository) Delete(id any) (bool, error) {
+	return true, r.err
+}
+
+func createTenantTrigger(tenants *TenantRepository, triggers *TriggerRepository, schedule string) *httptest.ResponseRecorder {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+
+	trigger := model.Trigger{Name: randstr.String(16), Schedule: schedule, Timezone: "UTC", Url: "https://httpbin.org/status/200", Timeout: 60, Retry: 3}
+	triggers.trigger = &trigger
+
+	b, _ := json.Marshal(trigger)
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/triggers", bytes.NewBuffer(b))
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, triggers, tenants, &DeliveryRepository{}, &AuditEntryRepository{}))
+	ctx.Set("Workflow", &Workflow{})
+	ctx.Set("Principal", &model.Principal{Subject: uuid.NewString(), Scopes: []string{model.TriggersWrite}, Tenant: &tenants.tenant.ID})
+
+	CreateTrigger(ctx)
+
+	return r
+}
+
+func TestCreateTriggerWithinQuotas(t *testing.T) {
+	tenant := &model.Tenant{ID: uuid.New(), Name: randstr.String(16), MaxTriggers: 2, MinInterval: 60}
+
+	tenants := &TenantRepository{tenant: tenant}
+
+	r := createTenantTrigger(tenants, &TriggerRepository{count: 1}, "* * * * *")
+
+	assert.Equal(t, http.StatusCreated, r.Code)
+	assert.True(t, tenants.locked)
+}
+
+func TestCreateTriggerMaxTriggers(t *testing.T) {
+	tenant := &model.Tenant{ID: uuid.New(), Name: randstr.String(16), MaxTriggers: 2, MinInterval: 60}
+
+	r := createTenantTrigger(&TenantRepository{tenant: tenant}, &TriggerRepository{count: 2}, "* * * * *")
+
+	assert.Equal(t, http.StatusForbidden, r.Code)
+	assert.Equal(t, "application/problem+json", r.Header().Get("Content-Type"))
+	assert.Contains(t, r.Body.String(), `"quota":"max_triggers"`)
+	assert.Contains(t, r.Body.String(), `"limit":2`)
+}
+
+func TestCreateTriggerMinInterval(t *testing.T) {
+	tenant := &model.Tenant{ID: uuid.New(), Name: randstr.String(16), MaxTriggers: 100, MinInterval: 300}
+
+	r := createTenantTrigger(&TenantRepository{tenant: tenant}, &TriggerRepository{}, "*/2 * * * *")
+
+	assert.Equal(t, http.StatusForbidden, r.Code)
+	assert.Contains(t, r.Body.String(), `"quota":"min_interval"`)
+	assert.Contains(t, r.Body.String(), `"limit":300`)
+}
+
+func TestScopeTenant(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	registry := repository.NewRepositoryRegistry(nil)
+	tenant := uuid.New()
+
+	ctx.Set("RepositoryRegistry", registry)
+	ctx.Set("Principal", &model.Principal{Scopes: []string{model.TriggersRead}})
+	ScopeTenant(ctx)
+	assert.Same(t, registry, GetRepositoryRegistry(ctx))
+
+	ctx.Set("Principal", &model.Principal{Scopes: []string{model.TriggersRead}, Tenant: &tenant})
+	ScopeTenant(ctx)
+	assert.NotSame(t, registry, GetRepositoryRegistry(ctx))
+}
+
+func TestCreateTenant(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+
+	b, _ := json.Marshal(model.Tenant{Name: randstr.String(16), MaxTriggers: 10, MinInterval: 60})
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/admin/tenants", bytes.NewBuffer(b))
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TenantRepository{}, &AuditEntryRepository{}))
+
+	CreateTenant(ctx)
+
+	assert.Equal(t, http.StatusCreated, r.Code)
+	assert.Equal(t, "application/hal+json", r.Header().Get("Content-Type"))
+	assert.Contains(t, r.Body.String(), `"max_triggers":10`)
+}
+
+func TestCreateTenantInvalid(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+
+	b, _ := json.Marshal(model.Tenant{Name: randstr.String(16), MaxTriggers: 10, MinInterval: 1})
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/admin/tenants", bytes.NewBuffer(b))
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TenantRepository{}))
+
+	CreateTenant(ctx)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, r.Code)
+	assert.Equal(t, "application/problem+json", r.Header().Get("Content-Type"))
+}
+
+func TestUpdateTenant(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	tenant := model.Tenant{ID: uuid.New(), Name: randstr.String(16), MaxTriggers: 10, MinInterval: 60, CreatedAt: time.Now()}
+	tenants, audit := &TenantRepository{tenant: &tenant}, &AuditEntryRepository{}
+
+	b, _ := json.Marshal(model.Tenant{ID: uuid.New(), Name: tenant.Name, MaxTriggers: 20, MinInterval: 120})
+	ctx.Request, _ = http.NewRequest(http.MethodPut, "/admin/tenants/"+tenant.ID.String(), bytes.NewBuffer(b))
+	ctx.Params = []gin.Param{{Key: "uuid", Value: tenant.ID.String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, tenants, audit))
+
+	UpdateTenant(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Contains(t, r.Body.String(), fmt.Sprintf(`"id":"%v"`, tenant.ID))
+	assert.Equal(t, tenant.ID, tenants.updated.ID)
+	assert.Equal(t, 20, tenants.updated.MaxTriggers)
+	assert.Len(t, audit.created, 1)
+	assert.Contains(t, audit.created[0].Diff, model.Change{Field: "max_triggers", Before: float64(10), After: float64(20)})
+}
@@ -1,28 +1,36 @@
 package controller
 
 import (
-	_ "embed"
-
-	"bytes"
-	"html/template"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
+	"path"
 	"time"
 
+	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/gin-gonic/gin"
 	"github.com/skhaz/scheduler/model"
 	"github.com/skhaz/scheduler/repository"
 	"github.com/skhaz/scheduler/workflow"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
-//go:embed manifest.yaml
-var manifest string
+var ErrImmutableName = errors.New("the name of a trigger cannot be changed")
 
 type query struct {
 	After time.Time `form:"after"`
 	Limit int       `form:"limit,default=10" binding:"gte=1,lte=100"`
 }
 
+type triggerQuery struct {
+	query
+	Enabled *bool `form:"enabled"`
+	Deleted bool  `form:"deleted"`
+}
+
 type params struct {
 	ID string `uri:"uuid" validate:"required,uuid4"`
 }
@@ -35,55 +43,179 @@ func GetWorkflow(ctx *gin.Context) workflow.Interface {
 	return ctx.MustGet("Workflow").(workflow.Interface)
 }
 
-func GetManifest(trigger *model.Trigger) ([]byte, error) {
-	tmp, err := template.New("manifest").Parse(manifest)
+func GetTriggers(ctx *gin.Context) {
+	var q = triggerQuery{}
+
+	if err := ctx.ShouldBindQuery(&q); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	var scopes []repository.Scope
+	if q.Enabled != nil {
+		scopes = append(scopes, repository.WhereEnabled(*q.Enabled))
+	}
+
+	if q.Deleted {
+		scopes = append(scopes, repository.OnlyDeleted)
+	}
+
+	e, err := GetTriggerRepository(ctx).List(q.After, q.Limit, scopes...)
 	if err != nil {
-		return nil, err
+		HandleError(ctx, err)
+
+		return
 	}
 
-	var buffer bytes.Buffer
-	if err := tmp.Execute(&buffer, trigger); err != nil {
-		return nil, err
+	WriteHAL(ctx, http.StatusOK, e.(model.TriggerCollection).ToHAL(ctx.Request.URL.Path, ctx.Request.URL.Query()))
+}
+
+func CreateTrigger(ctx *gin.Context) {
+	body := model.Trigger{}
+
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		HandleError(ctx, err)
+
+		return
 	}
 
-	return buffer.Bytes(), nil
+	if err := validate.Struct(body); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	secret, err := model.GenerateSecret()
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	body.Secret = secret
+
+	wf := GetWorkflow(ctx)
+
+	var trigger *model.Trigger
+
+	err = Transactional(ctx, func(registry *repository.RepositoryRegistry) (func() error, error) {
+		if err := CheckQuotas(ctx, registry, &body, true); err != nil {
+			return nil, err
+		}
+
+		e, err := registry.MustRepository("TriggerRepository").Create(&body)
+		if err != nil {
+			return nil, err
+		}
+
+		trigger = e.(*model.Trigger)
+
+		if err := Publish(registry, model.TriggerCreated, trigger); err != nil {
+			return nil, err
+		}
+
+		if err := Audit(ctx, registry, model.ActionCreate, nil, trigger); err != nil {
+			return nil, err
+		}
+
+		manifest, err := wf.Render(trigger)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := Apply(ctx, wf, manifest, workflow.Deploy); err != nil {
+			return nil, err
+		}
+
+		return func() error { return Apply(ctx, wf, manifest, workflow.Displace) }, nil
+	})
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	selfHref, _ := url.JoinPath(ctx.Request.URL.Path, trigger.ID.String())
+	resource := trigger.ToHAL(selfHref)
+	resource.AddData(model.TriggerSecret{Secret: trigger.Secret})
+	WriteHAL(ctx, http.StatusCreated, resource)
 }
 
-func GetTriggers(ctx *gin.Context) {
-	var q = query{}
+func GetTrigger(ctx *gin.Context) {
+	p := params{}
 
-	if err := ctx.ShouldBindQuery(&q); err != nil {
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(p); err != nil {
 		HandleError(ctx, err)
 
 		return
 	}
 
-	e, err := GetTriggerRepository(ctx).List(q.After, q.Limit)
+	e, err := GetTriggerRepository(ctx).Get(p.ID)
 	if err != nil {
 		HandleError(ctx, err)
 
 		return
 	}
 
-	WriteHAL(ctx, http.StatusOK, e.(model.TriggerCollection).ToHAL(ctx.Request.URL.Path, ctx.Request.URL.Query()))
+	WriteHAL(ctx, http.StatusOK, e.(*model.Trigger).ToHAL(ctx.Request.URL.Path))
 }
 
-func CreateTrigger(ctx *gin.Context) {
+func UpdateTrigger(ctx *gin.Context) {
+	p := params{}
+
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	e, err := GetTriggerRepository(ctx).Get(p.ID)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
 	body := model.Trigger{}
 
-	if err := ctx.BindJSON(&body); err != nil {
+	if err := ctx.ShouldBindJSON(&body); err != nil {
 		HandleError(ctx, err)
 
 		return
 	}
 
-	if err := validate.Struct(body); err != nil {
+	ReplaceTrigger(ctx, e.(*model.Trigger), &body)
+}
+
+func PatchTrigger(ctx *gin.Context) {
+	p := params{}
+
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(p); err != nil {
 		HandleError(ctx, err)
 
 		return
 	}
 
-	e, err := GetTriggerRepository(ctx).Create(&body)
+	e, err := GetTriggerRepository(ctx).Get(p.ID)
 	if err != nil {
 		HandleError(ctx, err)
 
@@ -92,24 +224,123 @@ func CreateTrigger(ctx *gin.Context) {
 
 	trigger := e.(*model.Trigger)
 
-	manifest, err := GetManifest(trigger)
+	patch, err := ctx.GetRawData()
 	if err != nil {
 		HandleError(ctx, err)
 
 		return
 	}
 
-	if err := GetWorkflow(ctx).Apply(manifest, workflow.Deploy); err != nil {
+	original, err := json.Marshal(trigger)
+	if err != nil {
 		HandleError(ctx, err)
 
 		return
 	}
 
-	selfHref, _ := url.JoinPath(ctx.Request.URL.Path, trigger.ID.String())
-	WriteHAL(ctx, http.StatusCreated, trigger.ToHAL(selfHref))
+	merged, err := jsonpatch.MergePatch(original, patch)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	body := model.Trigger{}
+
+	if err := json.Unmarshal(merged, &body); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	ReplaceTrigger(ctx, trigger, &body)
 }
 
-func GetTrigger(ctx *gin.Context) {
+// ReplaceTrigger validates and persists body as the new state of trigger, then
+// re-renders its manifest so the namespace and CronWorkflow are updated in place.
+func ReplaceTrigger(ctx *gin.Context, trigger *model.Trigger, body *model.Trigger) {
+	body.ID = trigger.ID
+	body.TenantID = trigger.TenantID
+	body.CreatedAt = trigger.CreatedAt
+	body.Secret = trigger.Secret
+
+	if body.Enabled == nil {
+		body.Enabled = trigger.Enabled
+	}
+
+	if body.Name != trigger.Name {
+		HandleError(ctx, ErrImmutableName)
+
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if _, ok := GetTriggerRepository(ctx).(repository.Replacer); !ok {
+		HandleError(ctx, repository.ErrNoReplacer)
+
+		return
+	}
+
+	wf := GetWorkflow(ctx)
+
+	previous, err := wf.Render(trigger)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	manifest, err := wf.Render(body)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	err = Transactional(ctx, func(registry *repository.RepositoryRegistry) (func() error, error) {
+		if err := CheckQuotas(ctx, registry, body, false); err != nil {
+			return nil, err
+		}
+
+		if _, err := registry.MustRepository("TriggerRepository").(repository.Replacer).Replace(trigger.ID, body); err != nil {
+			return nil, err
+		}
+
+		if err := Publish(registry, model.TriggerUpdated, body); err != nil {
+			return nil, err
+		}
+
+		if err := Audit(ctx, registry, model.ActionUpdate, trigger, body); err != nil {
+			return nil, err
+		}
+
+		return Replace(ctx, wf, previous, manifest)
+	})
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteHAL(ctx, http.StatusOK, body.ToHAL(ctx.Request.URL.Path))
+}
+
+func PauseTrigger(ctx *gin.Context) {
+	SuspendTrigger(ctx, true)
+}
+
+func ResumeTrigger(ctx *gin.Context) {
+	SuspendTrigger(ctx, false)
+}
+
+// SuspendTrigger flips the enabled state of a trigger and the suspend flag of
+// its CronWorkflow, so it stops (or starts again) firing without being deleted.
+func SuspendTrigger(ctx *gin.Context, suspend bool) {
 	p := params{}
 
 	if err := ctx.ShouldBindUri(&p); err != nil {
@@ -131,10 +362,52 @@ func GetTrigger(ctx *gin.Context) {
 		return
 	}
 
-	WriteHAL(ctx, http.StatusOK, e.(*model.Trigger).ToHAL(ctx.Request.URL.Path))
+	trigger := e.(*model.Trigger)
+
+	enabled := !suspend
+	wf := GetWorkflow(ctx)
+
+	event, action := model.TriggerResumed, model.ActionResume
+	if suspend {
+		event, action = model.TriggerPaused, model.ActionPause
+	}
+
+	err = Transactional(ctx, func(registry *repository.RepositoryRegistry) (func() error, error) {
+		if _, err := registry.MustRepository("TriggerRepository").Update(trigger.ID, &model.Trigger{Enabled: &enabled}); err != nil {
+			return nil, err
+		}
+
+		snapshot := *trigger
+		snapshot.Enabled = &enabled
+
+		if err := Publish(registry, event, &snapshot); err != nil {
+			return nil, err
+		}
+
+		if err := Audit(ctx, registry, action, trigger, &snapshot); err != nil {
+			return nil, err
+		}
+
+		if err := wf.Suspend(trigger.ID.String(), trigger.Name, suspend); err != nil {
+			return nil, err
+		}
+
+		return func() error { return wf.Suspend(trigger.ID.String(), trigger.Name, !suspend) }, nil
+	})
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	trigger.Enabled = &enabled
+
+	WriteHAL(ctx, http.StatusOK, trigger.ToHAL(path.Dir(ctx.Request.URL.Path)))
 }
 
-func DeleteTrigger(ctx *gin.Context) {
+// RotateSecret replaces the signing secret of a trigger and returns the new
+// one, which is the only time it is shown after the trigger is created.
+func RotateSecret(ctx *gin.Context) {
 	p := params{}
 
 	if err := ctx.ShouldBindUri(&p); err != nil {
@@ -149,9 +422,7 @@ func DeleteTrigger(ctx *gin.Context) {
 		return
 	}
 
-	repository := GetTriggerRepository(ctx)
-
-	e, err := repository.Get(p.ID)
+	e, err := GetTriggerRepository(ctx).Get(p.ID)
 	if err != nil {
 		HandleError(ctx, err)
 
@@ -160,20 +431,101 @@ func DeleteTrigger(ctx *gin.Context) {
 
 	trigger := e.(*model.Trigger)
 
-	manifest, err := GetManifest(trigger)
+	wf := GetWorkflow(ctx)
+
+	previous, err := wf.Render(trigger)
 	if err != nil {
 		HandleError(ctx, err)
 
 		return
 	}
 
-	if err := GetWorkflow(ctx).Apply(manifest, workflow.Displace); err != nil {
+	secret, err := model.GenerateSecret()
+	if err != nil {
 		HandleError(ctx, err)
 
 		return
 	}
 
-	if _, err := repository.Delete(p.ID); err != nil {
+	trigger.Secret = secret
+
+	manifest, err := wf.Render(trigger)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	err = Transactional(ctx, func(registry *repository.RepositoryRegistry) (func() error, error) {
+		if _, err := registry.MustRepository("TriggerRepository").Update(trigger.ID, &model.Trigger{Secret: secret}); err != nil {
+			return nil, err
+		}
+
+		if err := Audit(ctx, registry, model.ActionRotateSecret, trigger, trigger); err != nil {
+			return nil, err
+		}
+
+		return Replace(ctx, wf, previous, manifest)
+	})
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	resource := trigger.ToHAL(path.Dir(ctx.Request.URL.Path))
+	resource.AddData(model.TriggerSecret{Secret: secret})
+	WriteHAL(ctx, http.StatusOK, resource)
+}
+
+// DeleteTrigger soft deletes a trigger and suspends its CronWorkflow, which is
+// kept until the trigger is purged so RestoreTrigger can bring it back.
+func DeleteTrigger(ctx *gin.Context) {
+	p := params{}
+
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	e, err := GetTriggerRepository(ctx).Get(p.ID)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	trigger := e.(*model.Trigger)
+
+	wf := GetWorkflow(ctx)
+
+	err = Transactional(ctx, func(registry *repository.RepositoryRegistry) (func() error, error) {
+		if _, err := registry.MustRepository("TriggerRepository").Delete(p.ID); err != nil {
+			return nil, err
+		}
+
+		if err := Publish(registry, model.TriggerDeleted, trigger); err != nil {
+			return nil, err
+		}
+
+		if err := Audit(ctx, registry, model.ActionDelete, trigger, nil); err != nil {
+			return nil, err
+		}
+
+		if err := wf.Suspend(trigger.ID.String(), trigger.Name, true); err != nil {
+			return nil, err
+		}
+
+		return func() error { return wf.Suspend(trigger.ID.String(), trigger.Name, !trigger.IsEnabled()) }, nil
+	})
+	if err != nil {
 		HandleError(ctx, err)
 
 		return
@@ -181,3 +533,105 @@ func DeleteTrigger(ctx *gin.Context) {
 
 	WriteNoContent(ctx)
 }
+
+// RestoreTrigger undoes the deletion of a trigger that is not purged yet, and
+// deploys it again as it was before.
+func RestoreTrigger(ctx *gin.Context) {
+	p := params{}
+
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	restorer, ok := GetTriggerRepository(ctx).(repository.Restorer)
+	if !ok {
+		HandleError(ctx, repository.ErrNoRestorer)
+
+		return
+	}
+
+	e, err := restorer.Deleted(p.ID)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	deleted := e.(*model.Trigger)
+
+	restored := *deleted
+	restored.DeletedAt = gorm.DeletedAt{}
+
+	wf := GetWorkflow(ctx)
+
+	previous, err := wf.Render(deleted)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	manifest, err := wf.Render(&restored)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	err = Transactional(ctx, func(registry *repository.RepositoryRegistry) (func() error, error) {
+		if err := CheckQuotas(ctx, registry, deleted, true); err != nil {
+			return nil, err
+		}
+
+		ok, err := registry.MustRepository("TriggerRepository").(repository.Restorer).Restore(p.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return nil, gorm.ErrRecordNotFound
+		}
+
+		if err := Publish(registry, model.TriggerRestored, &restored); err != nil {
+			return nil, err
+		}
+
+		if err := Audit(ctx, registry, model.ActionRestore, deleted, &restored); err != nil {
+			return nil, err
+		}
+
+		return Replace(ctx, wf, previous, manifest)
+	})
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteHAL(ctx, http.StatusOK, restored.ToHAL(path.Dir(ctx.Request.URL.Path)))
+}
+
+// Replace applies manifest in place of previous. If the cluster rejects it
+// midway, previous is applied again so no object is left half updated; when
+// that fails too, both errors are returned.
+func Replace(ctx *gin.Context, wf workflow.Interface, previous, manifest []byte) (func() error, error) {
+	if err := Apply(ctx, wf, manifest, workflow.Replace); err != nil {
+		if rollbackErr := Apply(ctx, wf, previous, workflow.Replace); rollbackErr != nil {
+			GetLogger(ctx).Error("failed to roll back manifest", zap.Error(rollbackErr))
+
+			return nil, errors.Join(err, fmt.Errorf("rollback: %w", rollbackErr))
+		}
+
+		return nil, err
+	}
+
+	return func() error { return Apply(ctx, wf, previous, workflow.Replace) }, nil
+}
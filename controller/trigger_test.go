@@ -3,6 +3,7 @@ package controller
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -24,12 +25,17 @@ type TriggerRepository struct {
 	trigger  *model.Trigger
 	triggers model.TriggerCollection
 	success  bool
+	count    int64
+	scopes   int
+	deleted  *model.Trigger
+	replaced *model.Trigger
 }
 
 func (r *TriggerRepository) Configure(db *gorm.DB) {
 }
 
-func (r *TriggerRepository) List(after time.Time, limit int) (any, error) {
+func (r *TriggerRepository) List(after time.Time, limit int, scopes ...repository.Scope) (any, error) {
+	r.scopes = len(scopes)
 	return r.triggers, r.err
 }
 
@@ -49,10 +55,73 @@ func (r *TriggerRepository) Delete(id any) (bool, error) {
 	return r.success, r.err
 }
 
+func (r *TriggerRepository) Count(scopes ...repository.Scope) (int64, error) {
+	return r.count, r.err
+}
+
+func (r *TriggerRepository) Replace(id any, entity any) (bool, error) {
+	r.replaced = entity.(*model.Trigger)
+	return r.success, r.err
+}
+
+func (r *TriggerRepository) Deleted(id any) (any, error) {
+	if r.deleted == nil && r.err == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return r.deleted, r.err
+}
+
+func (r *TriggerRepository) Restore(id any) (bool, error) {
+	return r.success, r.err
+}
+
+func (r *TriggerRepository) Purge(id any) (bool, error) {
+	return r.success, r.err
+}
+
 type Workflow struct {
+	err        error
+	applyErr   error
+	suspendErr error
+	executions model.ExecutionCollection
+	operations []workflow.Operation
+	suspended  []bool
+}
+
+func (wf *Workflow) Render(trigger *model.Trigger) ([]byte, error) {
+	return []byte(trigger.ID.String()), nil
 }
 
-func (wf *Workflow) Apply(manifest []byte, op workflow.Operation) error { return nil }
+func (wf *Workflow) Apply(manifest []byte, op workflow.Operation) ([]workflow.Result, error) {
+	wf.operations = append(wf.operations, op)
+	return nil, wf.applyErr
+}
+
+func (wf *Workflow) Suspend(namespace, name string, suspend bool) error {
+	wf.suspended = append(wf.suspended, suspend)
+	return wf.suspendErr
+}
+
+func (wf *Workflow) Executions(namespace string) (model.ExecutionCollection, error) {
+	return wf.executions, wf.err
+}
+
+func (wf *Workflow) Submit(namespace, name string) (*model.Execution, error) {
+	if wf.err != nil {
+		return nil, wf.err
+	}
+
+	execution, _ := model.Last(wf.executions)
+
+	return execution, nil
+}
+
+func (wf *Workflow) Diff(manifest []byte) ([]byte, []byte, error) { return nil, nil, wf.err }
+
+func (wf *Workflow) Namespaces(before time.Time) ([]string, error) { return nil, wf.err }
+
+func (wf *Workflow) Prune(namespace string) error { return wf.err }
 
 func TestGetTriggers(t *testing.T) {
 	r := httptest.NewRecorder()
@@ -72,6 +141,19 @@ func TestGetTriggers(t *testing.T) {
 	// assert.Equal(t, payload, r.Body.Bytes())
 }
 
+func TestGetTriggersEnabled(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/?enabled=false", nil)
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{}))
+
+	GetTriggers(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "application/hal+json", r.Header().Get("Content-Type"))
+}
+
 func TestCreateTrigger(t *testing.T) {
 	r := httptest.NewRecorder()
 	ctx, _ := gin.CreateTestContext(r)
@@ -102,7 +184,8 @@ func TestCreateTrigger(t *testing.T) {
 	assert.NoError(t, err)
 	ctx.Request, _ = http.NewRequest(http.MethodPost, "/triggers", bytes.NewBuffer(b))
 
-	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger}))
+	outbox, audit := &DeliveryRepository{}, &AuditEntryRepository{}
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger}, outbox, audit))
 	ctx.Set("Workflow", &Workflow{})
 
 	CreateTrigger(ctx)
@@ -110,6 +193,11 @@ func TestCreateTrigger(t *testing.T) {
 	assert.Equal(t, http.StatusCreated, r.Code)
 	assert.Equal(t, "application/hal+json", r.Header().Get("Content-Type"))
 	assert.Contains(t, r.Body.String(), fmt.Sprintf(`"name":"%v"`, trigger.Name))
+	assert.Contains(t, r.Body.String(), `"secret":`)
+	assert.Len(t, outbox.events, 1)
+	assert.Equal(t, model.TriggerCreated, outbox.events[0].Type)
+	assert.Len(t, audit.created, 1)
+	assert.Equal(t, model.ActionCreate, audit.created[0].Action)
 }
 
 func TestGetTrigger(t *testing.T) {
@@ -139,11 +227,472 @@ func TestDeleteTrigger(t *testing.T) {
 	ctx.Request, _ = http.NewRequest(http.MethodDelete, "/", nil)
 	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
 
-	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger}))
-	ctx.Set("Workflow", &Workflow{})
+	outbox, audit := &DeliveryRepository{}, &AuditEntryRepository{}
+	wf := &Workflow{}
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger}, outbox, audit))
+	ctx.Set("Workflow", wf)
 
 	DeleteTrigger(ctx)
 
 	assert.Equal(t, http.StatusNoContent, r.Code)
 	assert.Equal(t, "application/json; charset=utf-8", r.Header().Get("Content-Type"))
+	assert.Len(t, outbox.events, 1)
+	assert.Equal(t, model.TriggerDeleted, outbox.events[0].Type)
+	assert.Len(t, audit.created, 1)
+	assert.Equal(t, model.ActionDelete, audit.created[0].Action)
+	assert.Equal(t, []bool{true}, wf.suspended)
+	assert.Empty(t, wf.operations)
+}
+
+func TestGetDeletedTriggers(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/?deleted=true", nil)
+
+	triggers := &TriggerRepository{}
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, triggers))
+
+	GetTriggers(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, 1, triggers.scopes)
+}
+
+func TestRestoreTrigger(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	deleted := model.Trigger{ID: id, Name: randstr.String(16), DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/triggers/"+id.String()+"/restore", nil)
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
+
+	outbox, audit, wf := &DeliveryRepository{}, &AuditEntryRepository{}, &Workflow{}
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{deleted: &deleted, success: true}, outbox, audit))
+	ctx.Set("Workflow", wf)
+
+	RestoreTrigger(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "application/hal+json", r.Header().Get("Content-Type"))
+	assert.Contains(t, r.Body.String(), fmt.Sprintf(`"href":"/triggers/%s"`, id))
+	assert.Len(t, outbox.events, 1)
+	assert.Equal(t, model.TriggerRestored, outbox.events[0].Type)
+	assert.Len(t, audit.created, 1)
+	assert.Equal(t, model.ActionRestore, audit.created[0].Action)
+	assert.NotNil(t, audit.created[0].After)
+	assert.Equal(t, []workflow.Operation{workflow.Replace}, wf.operations)
+}
+
+func TestRestoreTriggerNotDeleted(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/triggers/"+id.String()+"/restore", nil)
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
+
+	wf := &Workflow{}
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{}, &DeliveryRepository{}, &AuditEntryRepository{}))
+	ctx.Set("Workflow", wf)
+
+	RestoreTrigger(ctx)
+
+	assert.Equal(t, http.StatusNotFound, r.Code)
+	assert.Empty(t, wf.operations)
+}
+
+func TestUpdateTrigger(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	trigger := model.Trigger{ID: id, Name: randstr.String(16)}
+	body := model.Trigger{
+		Name:     trigger.Name,
+		Schedule: "*/5 * * * *",
+		Timezone: "UTC",
+		Url:      "https://httpbin.org/status/200",
+		Timeout:  60,
+		Retry:    3,
+	}
+
+	b, err := json.Marshal(body)
+	assert.NoError(t, err)
+	ctx.Request, _ = http.NewRequest(http.MethodPut, "/triggers/"+id.String(), bytes.NewBuffer(b))
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
+
+	outbox, audit := &DeliveryRepository{}, &AuditEntryRepository{}
+	triggers := &TriggerRepository{trigger: &trigger, success: true}
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, triggers, outbox, audit))
+	ctx.Set("Workflow", &Workflow{})
+
+	UpdateTrigger(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "application/hal+json", r.Header().Get("Content-Type"))
+	assert.Contains(t, r.Body.String(), `"schedule":"*/5 * * * *"`)
+	assert.Equal(t, "*/5 * * * *", triggers.replaced.Schedule)
+	assert.Contains(t, r.Body.String(), fmt.Sprintf(`"id":"%v"`, id))
+	assert.Len(t, outbox.events, 1)
+	assert.Equal(t, model.TriggerUpdated, outbox.events[0].Type)
+	assert.Len(t, audit.created, 1)
+	assert.Equal(t, model.ActionUpdate, audit.created[0].Action)
+}
+
+func TestUpdateTriggerRename(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	trigger := model.Trigger{ID: id, Name: randstr.String(16)}
+	body := model.Trigger{
+		Name:     randstr.String(16),
+		Schedule: "* * * * *",
+		Timezone: "UTC",
+		Timeout:  60,
+		Retry:    3,
+	}
+
+	b, err := json.Marshal(body)
+	assert.NoError(t, err)
+	ctx.Request, _ = http.NewRequest(http.MethodPut, "/triggers/"+id.String(), bytes.NewBuffer(b))
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger, success: true}))
+	ctx.Set("Workflow", &Workflow{})
+
+	UpdateTrigger(ctx)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, r.Code)
+	assert.Equal(t, "application/problem+json", r.Header().Get("Content-Type"))
+}
+
+func TestPatchTrigger(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	trigger := model.Trigger{
+		ID:       id,
+		Name:     randstr.String(16),
+		Schedule: "* * * * *",
+		Timezone: "UTC",
+		Url:      "https://httpbin.org/status/200",
+		Method:   http.MethodGet,
+		Success:  200,
+		Timeout:  60,
+		Retry:    3,
+	}
+
+	ctx.Request, _ = http.NewRequest(http.MethodPatch, "/triggers/"+id.String(), bytes.NewBufferString(`{"timeout":120}`))
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger, success: true}, &DeliveryRepository{}, &AuditEntryRepository{}))
+	ctx.Set("Workflow", &Workflow{})
+
+	PatchTrigger(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "application/hal+json", r.Header().Get("Content-Type"))
+	assert.Contains(t, r.Body.String(), `"timeout":120`)
+	assert.Contains(t, r.Body.String(), `"schedule":"* * * * *"`)
+}
+
+func TestPatchTriggerInvalid(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	trigger := model.Trigger{
+		ID:       id,
+		Name:     randstr.String(16),
+		Schedule: "* * * * *",
+		Timezone: "UTC",
+		Timeout:  60,
+		Retry:    3,
+	}
+
+	ctx.Request, _ = http.NewRequest(http.MethodPatch, "/triggers/"+id.String(), bytes.NewBufferString(`{"retry":0}`))
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger, success: true}))
+	ctx.Set("Workflow", &Workflow{})
+
+	PatchTrigger(ctx)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, r.Code)
+	assert.Equal(t, "application/problem+json", r.Header().Get("Content-Type"))
+}
+
+func TestPauseTrigger(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	trigger := model.Trigger{ID: id, Name: randstr.String(16)}
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/triggers/"+id.String()+"/pause", nil)
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
+
+	outbox, audit := &DeliveryRepository{}, &AuditEntryRepository{}
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger, success: true}, outbox, audit))
+	ctx.Set("Workflow", &Workflow{})
+
+	PauseTrigger(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "application/hal+json", r.Header().Get("Content-Type"))
+	assert.Contains(t, r.Body.String(), `"enabled":false`)
+	assert.Contains(t, r.Body.String(), fmt.Sprintf(`"href":"/triggers/%v"`, id))
+	assert.Len(t, outbox.events, 1)
+	assert.Equal(t, model.TriggerPaused, outbox.events[0].Type)
+	assert.Len(t, audit.created, 1)
+	assert.Equal(t, model.ActionPause, audit.created[0].Action)
+}
+
+func TestResumeTrigger(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	disabled := false
+	trigger := model.Trigger{ID: id, Name: randstr.String(16), Enabled: &disabled}
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/triggers/"+id.String()+"/resume", nil)
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
+
+	outbox, audit := &DeliveryRepository{}, &AuditEntryRepository{}
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger, success: true}, outbox, audit))
+	ctx.Set("Workflow", &Workflow{})
+
+	ResumeTrigger(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "application/hal+json", r.Header().Get("Content-Type"))
+	assert.Contains(t, r.Body.String(), `"enabled":true`)
+	assert.Len(t, outbox.events, 1)
+	assert.Equal(t, model.TriggerResumed, outbox.events[0].Type)
+	assert.Len(t, audit.created, 1)
+	assert.Equal(t, model.ActionResume, audit.created[0].Action)
+}
+
+func TestRotateSecret(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	secret := randstr.Hex(32)
+	trigger := model.Trigger{ID: id, Name: randstr.String(16), Secret: secret}
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/triggers/"+id.String()+"/secret", nil)
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger, success: true}, &AuditEntryRepository{}))
+	ctx.Set("Workflow", &Workflow{})
+
+	RotateSecret(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "application/hal+json", r.Header().Get("Content-Type"))
+	assert.NotEqual(t, secret, trigger.Secret)
+	assert.Contains(t, r.Body.String(), fmt.Sprintf(`"secret":"%v"`, trigger.Secret))
+}
+
+func TestGetTriggerHidesSecret(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	trigger := model.Trigger{Name: randstr.String(16), Secret: randstr.Hex(32)}
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/", nil)
+	ctx.Params = []gin.Param{{Key: "uuid", Value: uuid.New().String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger}))
+
+	GetTrigger(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.NotContains(t, r.Body.String(), trigger.Secret)
+	assert.NotContains(t, r.Body.String(), `"secret"`)
+}
+
+func TestCreateTriggerInvalidHeaders(t *testing.T) {
+	for _, headers := range []map[string]string{
+		{"Invalid Name": "value"},
+		{"X-Injected": "value\r\nX-Other: value"},
+	} {
+		r := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(r)
+		trigger := model.Trigger{
+			Name:     randstr.String(16),
+			Schedule: "* * * * *",
+			Timezone: "UTC",
+			Url:      "https://httpbin.org/status/200",
+			Headers:  headers,
+			Timeout:  60,
+			Retry:    3,
+		}
+
+		b, err := json.Marshal(trigger)
+		assert.NoError(t, err)
+		ctx.Request, _ = http.NewRequest(http.MethodPost, "/triggers", bytes.NewBuffer(b))
+
+		ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger}))
+		ctx.Set("Workflow", &Workflow{})
+
+		CreateTrigger(ctx)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, r.Code)
+		assert.Equal(t, "application/problem+json", r.Header().Get("Content-Type"))
+	}
+}
+
+func TestCreateTriggerInvalidContentType(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	trigger := model.Trigger{
+		Name:        randstr.String(16),
+		Schedule:    "* * * * *",
+		Timezone:    "UTC",
+		Url:         "https://httpbin.org/status/200",
+		Body:        "{}",
+		ContentType: "application/json; charset",
+		Timeout:     60,
+		Retry:       3,
+	}
+
+	b, err := json.Marshal(trigger)
+	assert.NoError(t, err)
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/triggers", bytes.NewBuffer(b))
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger}))
+	ctx.Set("Workflow", &Workflow{})
+
+	CreateTrigger(ctx)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, r.Code)
+}
+
+func TestCreateTriggerInvalidRetryPolicy(t *testing.T) {
+	for _, policy := range []*model.RetryPolicy{
+		{Strategy: "linear"},
+		{BaseDelay: 3601},
+		{Jitter: 1.5},
+		{Statuses: []int{200, 99}},
+	} {
+		r := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(r)
+		trigger := model.Trigger{
+			Name:        randstr.String(16),
+			Schedule:    "* * * * *",
+			Timezone:    "UTC",
+			Url:         "https://httpbin.org/status/200",
+			Timeout:     60,
+			Retry:       3,
+			RetryPolicy: policy,
+		}
+
+		b, err := json.Marshal(trigger)
+		assert.NoError(t, err)
+		ctx.Request, _ = http.NewRequest(http.MethodPost, "/triggers", bytes.NewBuffer(b))
+
+		ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger}))
+		ctx.Set("Workflow", &Workflow{})
+
+		CreateTrigger(ctx)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, r.Code)
+	}
+}
+
+func TestCreateTriggerInvalidSuccessCriteria(t *testing.T) {
+	for _, criteria := range []*model.SuccessCriteria{
+		{Statuses: []string{"2xx", "7xx"}},
+		{Statuses: []string{"299-200"}},
+		{Body: &model.BodyAssertion{Matches: `\d+`}},
+		{Body: &model.BodyAssertion{JSONPath: "$..items", Equals: json.RawMessage(`1`)}},
+		{Body: &model.BodyAssertion{JSONPath: "$.items"}},
+		{Body: &model.BodyAssertion{Equals: json.RawMessage(`1`)}},
+	} {
+		r := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(r)
+		trigger := model.Trigger{
+			Name:            randstr.String(16),
+			Schedule:        "* * * * *",
+			Timezone:        "UTC",
+			Url:             "https://httpbin.org/status/200",
+			Timeout:         60,
+			Retry:           3,
+			SuccessCriteria: criteria,
+		}
+
+		b, err := json.Marshal(trigger)
+		assert.NoError(t, err)
+		ctx.Request, _ = http.NewRequest(http.MethodPost, "/triggers", bytes.NewBuffer(b))
+
+		ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger}))
+		ctx.Set("Workflow", &Workflow{})
+
+		CreateTrigger(ctx)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, r.Code, criteria)
+	}
+}
+
+func TestCreateTriggerApplyError(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	trigger := model.Trigger{
+		Name:     randstr.String(16),
+		Schedule: "* * * * *",
+		Timezone: "UTC",
+		Timeout:  60,
+		Retry:    3,
+	}
+
+	b, err := json.Marshal(trigger)
+	assert.NoError(t, err)
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/triggers", bytes.NewBuffer(b))
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger}, &DeliveryRepository{}, &AuditEntryRepository{}))
+	ctx.Set("Workflow", &Workflow{applyErr: &workflow.Error{Op: workflow.Deploy, Kind: "CronWorkflow", Err: errors.New("forbidden")}})
+
+	CreateTrigger(ctx)
+
+	assert.Equal(t, http.StatusBadGateway, r.Code)
+	assert.Equal(t, "application/problem+json", r.Header().Get("Content-Type"))
+	assert.Contains(t, r.Body.String(), "errors:workflow/apply-failed")
+}
+
+func TestUpdateTriggerApplyError(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	trigger := model.Trigger{ID: id, Name: randstr.String(16)}
+	body := model.Trigger{
+		Name:     trigger.Name,
+		Schedule: "* * * * *",
+		Timezone: "UTC",
+		Timeout:  60,
+		Retry:    3,
+	}
+
+	b, err := json.Marshal(body)
+	assert.NoError(t, err)
+	ctx.Request, _ = http.NewRequest(http.MethodPut, "/triggers/"+id.String(), bytes.NewBuffer(b))
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
+
+	wf := &Workflow{applyErr: &workflow.Error{Op: workflow.Replace, Kind: "CronWorkflow", Err: errors.New("conflict")}}
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger, success: true}, &DeliveryRepository{}, &AuditEntryRepository{}))
+	ctx.Set("Workflow", wf)
+
+	UpdateTrigger(ctx)
+
+	assert.Equal(t, http.StatusBadGateway, r.Code)
+	assert.Equal(t, []workflow.Operation{workflow.Replace, workflow.Replace}, wf.operations)
+	assert.Contains(t, r.Body.String(), "rollback: ")
+}
+
+func TestDeleteTriggerSuspendError(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	trigger := model.Trigger{ID: id}
+	ctx.Request, _ = http.NewRequest(http.MethodDelete, "/", nil)
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &TriggerRepository{trigger: &trigger}, &DeliveryRepository{}, &AuditEntryRepository{}))
+	ctx.Set("Workflow", &Workflow{suspendErr: &workflow.Error{Op: workflow.Replace, Kind: "CronWorkflow", Err: errors.New("timeout")}})
+
+	DeleteTrigger(ctx)
+
+	assert.Equal(t, http.StatusBadGateway, r.Code)
+	assert.Equal(t, "application/problem+json", r.Header().Get("Content-Type"))
 }
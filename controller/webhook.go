@@ -0,0 +1,368 @@
+package controller
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+	"gorm.io/gorm"
+)
+
+type deliveryParams struct {
+	params
+	Delivery string `uri:"delivery" validate:"required,uuid"`
+}
+
+func GetSubscriptionRepository(ctx *gin.Context) repository.Repository {
+	return ctx.MustGet("RepositoryRegistry").(*repository.RepositoryRegistry).MustRepository("SubscriptionRepository")
+}
+
+func GetDeliveryRepository(ctx *gin.Context) repository.Repository {
+	return ctx.MustGet("RepositoryRegistry").(*repository.RepositoryRegistry).MustRepository("DeliveryRepository")
+}
+
+// Publish writes an event about trigger to the outbox of registry, which must
+// be the registry of the transaction that changes the trigger, so the event is
+// only delivered once the change is committed.
+func Publish(registry *repository.RepositoryRegistry, event string, trigger *model.Trigger) error {
+	e, err := registry.Repository("DeliveryRepository")
+	if err != nil {
+		return err
+	}
+
+	outbox, ok := e.(repository.Outbox)
+	if !ok {
+		return repository.ErrNoOutbox
+	}
+
+	return outbox.Publish(model.NewEvent(event, trigger))
+}
+
+func GetWebhooks(ctx *gin.Context) {
+	var q = query{}
+
+	if err := ctx.ShouldBindQuery(&q); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	e, err := GetSubscriptionRepository(ctx).List(q.After, q.Limit)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteHAL(ctx, http.StatusOK, e.(model.SubscriptionCollection).ToHAL(ctx.Request.URL.Path, ctx.Request.URL.Query()))
+}
+
+func CreateWebhook(ctx *gin.Context) {
+	body := model.Subscription{}
+
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	secret, err := model.GenerateSecret()
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	body.Secret = secret
+
+	var subscription *model.Subscription
+
+	err = Transactional(ctx, func(registry *repository.RepositoryRegistry) (func() error, error) {
+		e, err := registry.MustRepository("SubscriptionRepository").Create(&body)
+		if err != nil {
+			return nil, err
+		}
+
+		subscription = e.(*model.Subscription)
+
+		return nil, Audit(ctx, registry, model.ActionCreate, nil, subscription)
+	})
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	selfHref, _ := url.JoinPath(ctx.Request.URL.Path, subscription.ID.String())
+	resource := subscription.ToHAL(selfHref)
+	resource.AddData(model.SubscriptionSecret{Secret: subscription.Secret})
+	WriteHAL(ctx, http.StatusCreated, resource)
+}
+
+func GetWebhook(ctx *gin.Context) {
+	p := params{}
+
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	e, err := GetSubscriptionRepository(ctx).Get(p.ID)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteHAL(ctx, http.StatusOK, e.(*model.Subscription).ToHAL(ctx.Request.URL.Path))
+}
+
+// UpdateWebhook replaces the URL, events and state of a subscription, its
+// secret is kept. Deliveries already in the outbox are not affected.
+func UpdateWebhook(ctx *gin.Context) {
+	p := params{}
+
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	e, err := GetSubscriptionRepository(ctx).Get(p.ID)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	subscription := e.(*model.Subscription)
+
+	body := model.Subscription{}
+
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	body.ID = subscription.ID
+	body.TenantID = subscription.TenantID
+	body.CreatedAt = subscription.CreatedAt
+	body.Secret = subscription.Secret
+
+	if body.Enabled == nil {
+		body.Enabled = subscription.Enabled
+	}
+
+	if err := validate.Struct(body); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	err = Transactional(ctx, func(registry *repository.RepositoryRegistry) (func() error, error) {
+		if _, err := registry.MustRepository("SubscriptionRepository").Update(subscription.ID, &body); err != nil {
+			return nil, err
+		}
+
+		return nil, Audit(ctx, registry, model.ActionUpdate, subscription, &body)
+	})
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteHAL(ctx, http.StatusOK, body.ToHAL(ctx.Request.URL.Path))
+}
+
+func DeleteWebhook(ctx *gin.Context) {
+	p := params{}
+
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	e, err := GetSubscriptionRepository(ctx).Get(p.ID)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	subscription := e.(*model.Subscription)
+
+	err = Transactional(ctx, func(registry *repository.RepositoryRegistry) (func() error, error) {
+		if _, err := registry.MustRepository("SubscriptionRepository").Delete(subscription.ID); err != nil {
+			return nil, err
+		}
+
+		return nil, Audit(ctx, registry, model.ActionDelete, subscription, nil)
+	})
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteNoContent(ctx)
+}
+
+// GetDeliveries lists the deliveries to a subscription, which is the log of
+// what it was told and how it responded.
+func GetDeliveries(ctx *gin.Context) {
+	p := params{}
+
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	if err := validate.Struct(p); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	var q = query{}
+
+	if err := ctx.ShouldBindQuery(&q); err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	e, err := GetSubscriptionRepository(ctx).Get(p.ID)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	subscription := e.(*model.Subscription)
+
+	c, err := GetDeliveryRepository(ctx).List(q.After, q.Limit, repository.WhereSubscription(subscription.ID))
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteHAL(ctx, http.StatusOK, c.(model.DeliveryCollection).ToHAL(ctx.Request.URL.Path, ctx.Request.URL.Query()))
+}
+
+// getDelivery returns the delivery of the request, as long as it belongs to
+// the subscription of the request, along with the subscription.
+func getDelivery(ctx *gin.Context) (*model.Delivery, *model.Subscription, error) {
+	p := deliveryParams{}
+
+	if err := ctx.ShouldBindUri(&p); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validate.Struct(p); err != nil {
+		return nil, nil, err
+	}
+
+	e, err := GetSubscriptionRepository(ctx).Get(p.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	subscription := e.(*model.Subscription)
+
+	e, err = GetDeliveryRepository(ctx).Get(p.Delivery)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	delivery := e.(*model.Delivery)
+	if delivery.SubscriptionID != subscription.ID {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
+
+	return delivery, subscription, nil
+}
+
+func GetDelivery(ctx *gin.Context) {
+	delivery, _, err := getDelivery(ctx)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	WriteHAL(ctx, http.StatusOK, delivery.ToHAL(ctx.Request.URL.Path))
+}
+
+// Redeliver queues the event of a delivery to be delivered again right away,
+// as a new delivery, the response links to it so its outcome can be polled.
+func Redeliver(ctx *gin.Context) {
+	delivery, subscription, err := getDelivery(ctx)
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	var redelivery *model.Delivery
+
+	err = Transactional(ctx, func(registry *repository.RepositoryRegistry) (func() error, error) {
+		e, err := registry.MustRepository("DeliveryRepository").Create(&model.Delivery{
+			SubscriptionID: delivery.SubscriptionID,
+			EventID:        delivery.EventID,
+			State:          model.Delivering,
+			NextAttemptAt:  time.Now(),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		redelivery = e.(*model.Delivery)
+
+		return nil, Audit(ctx, registry, model.ActionRedeliver, subscription, subscription)
+	})
+	if err != nil {
+		HandleError(ctx, err)
+
+		return
+	}
+
+	redelivery.Event = delivery.Event
+
+	selfHref, _ := url.JoinPath(path.Dir(path.Dir(ctx.Request.URL.Path)), redelivery.ID.String())
+	ctx.Header("Location", selfHref)
+	WriteHAL(ctx, http.StatusCreated, redelivery.ToHAL(selfHref))
+}
@@ -0,0 +1,248 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type SubscriptionRepository struct {
+	err           error
+	subscription  *model.Subscription
+	subscriptions model.SubscriptionCollection
+	updated       *model.Subscription
+}
+
+func (r *SubscriptionRepository) Configure(db *gorm.DB) {
+}
+
+func (r *SubscriptionRepository) List(after time.Time, limit int, scopes ...repository.Scope) (any, error) {
+	return r.subscriptions, r.err
+}
+
+func (r *SubscriptionRepository) Get(id any) (any, error) {
+	return r.subscription, r.err
+}
+
+func (r *SubscriptionRepository) Create(entity any) (any, error) {
+	s := entity.(*model.Subscription)
+	s.ID = uuid.New()
+	return s, r.err
+}
+
+func (r *SubscriptionRepository) Update(id any, entity any) (bool, error) {
+	r.updated = entity.(*model.Subscription)
+	return true, r.err
+}
+
+func (r *SubscriptionRepository) Delete(id any) (bool, error) {
+	return true, r.err
+}
+
+type DeliveryRepository struct {
+	err        error
+	delivery   *model.Delivery
+	deliveries model.DeliveryCollection
+	created    model.DeliveryCollection
+	events     []*model.Event
+}
+
+func (r *DeliveryRepository) Configure(db *gorm.DB) {
+}
+
+func (r *DeliveryRepository) Publish(event *model.Event) error {
+	r.events = append(r.events, event)
+	return r.err
+}
+
+func (r *DeliveryRepository) Due(now time.Time, limit int) (model.DeliveryCollection, error) {
+	return nil, r.err
+}
+
+func (r *DeliveryRepository) List(after time.Time, limit int, scopes ...repository.Scope) (any, error) {
+	return r.deliveries, r.err
+}
+
+func (r *DeliveryRepository) Get(id any) (any, error) {
+	return r.delivery, r.err
+}
+
+func (r *DeliveryRepository) Create(entity any) (any, error) {
+	d := entity.(*model.Delivery)
+	d.ID = uuid.New()
+	r.created = append(r.created, d)
+	return d, r.err
+}
+
+func (r *DeliveryRepository) Update(id any, entity any) (bool, error) {
+	return true, r.err
+}
+
+func (r *DeliveryRepository) Delete(id any) (bool, error) {
+	return true, r.err
+}
+
+func TestGetWebhooks(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	subscription := model.Subscription{ID: uuid.New(), URL: "https://example.com", Events: []string{model.TriggerCreated}}
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/webhooks", nil)
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &SubscriptionRepository{subscriptions: model.SubscriptionCollection{&subscription}}))
+
+	GetWebhooks(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Equal(t, "application/hal+json", r.Header().Get("Content-Type"))
+	assert.Contains(t, r.Body.String(), fmt.Sprintf(`"href":"/webhooks/%v"`, subscription.ID))
+	assert.Contains(t, r.Body.String(), `"events":["trigger.created"]`)
+}
+
+func TestCreateWebhook(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	body := model.Subscription{URL: "https://example.com", Events: []string{model.TriggerCreated, model.TriggerDeleted}}
+
+	b, err := json.Marshal(body)
+	assert.NoError(t, err)
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/webhooks", bytes.NewBuffer(b))
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &SubscriptionRepository{}, &AuditEntryRepository{}))
+
+	CreateWebhook(ctx)
+
+	assert.Equal(t, http.StatusCreated, r.Code)
+	assert.Equal(t, "application/hal+json", r.Header().Get("Content-Type"))
+	assert.Contains(t, r.Body.String(), `"secret":`)
+	assert.Contains(t, r.Body.String(), `"events":["trigger.created","trigger.deleted"]`)
+}
+
+func TestCreateWebhookInvalid(t *testing.T) {
+	for _, body := range []string{
+		`{"url":"https://example.com","events":["trigger.renamed"]}`,
+		`{"url":"https://example.com","events":[]}`,
+		`{"url":"example","events":["trigger.created"]}`,
+	} {
+		r := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(r)
+		ctx.Request, _ = http.NewRequest(http.MethodPost, "/webhooks", bytes.NewBufferString(body))
+
+		ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &SubscriptionRepository{}))
+
+		CreateWebhook(ctx)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, r.Code, body)
+		assert.Equal(t, "application/problem+json", r.Header().Get("Content-Type"))
+	}
+}
+
+func TestUpdateWebhook(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	enabled := true
+	subscription := model.Subscription{ID: id, URL: "https://example.com", Events: []string{model.TriggerCreated}, Enabled: &enabled, Secret: "secret"}
+	subscriptions, audit := &SubscriptionRepository{subscription: &subscription}, &AuditEntryRepository{}
+	ctx.Request, _ = http.NewRequest(http.MethodPut, "/webhooks/"+id.String(), bytes.NewBufferString(`{"url":"https://example.org","events":["trigger.paused"]}`))
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, subscriptions, audit))
+
+	UpdateWebhook(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Contains(t, r.Body.String(), `"url":"https://example.org"`)
+	assert.NotContains(t, r.Body.String(), "secret")
+	assert.Equal(t, "secret", subscriptions.updated.Secret)
+	assert.True(t, subscriptions.updated.IsEnabled())
+	assert.Len(t, audit.created, 1)
+	assert.Equal(t, model.ResourceWebhook, audit.created[0].Resource)
+	assert.Contains(t, audit.created[0].Diff, model.Change{Field: "url", Redacted: true})
+}
+
+func TestDeleteWebhookNotFound(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	ctx.Request, _ = http.NewRequest(http.MethodDelete, "/webhooks/"+id.String(), nil)
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &SubscriptionRepository{err: gorm.ErrRecordNotFound}))
+
+	DeleteWebhook(ctx)
+
+	assert.Equal(t, http.StatusNotFound, r.Code)
+}
+
+func TestGetDeliveries(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	subscription := model.Subscription{ID: id}
+	delivery := model.Delivery{ID: uuid.New(), SubscriptionID: id, EventID: uuid.New(), State: model.Undelivered, Attempts: 8, StatusCode: 503}
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/webhooks/"+id.String()+"/deliveries", nil)
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &SubscriptionRepository{subscription: &subscription}, &DeliveryRepository{deliveries: model.DeliveryCollection{&delivery}}))
+
+	GetDeliveries(ctx)
+
+	assert.Equal(t, http.StatusOK, r.Code)
+	assert.Contains(t, r.Body.String(), `"status_code":503`)
+	assert.Contains(t, r.Body.String(), fmt.Sprintf(`"href":"/webhooks/%v/deliveries/%v"`, id, delivery.ID))
+}
+
+func TestRedeliver(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	subscription := model.Subscription{ID: id}
+	event := model.NewEvent(model.TriggerDeleted, &model.Trigger{ID: uuid.New()})
+	event.ID = uuid.New()
+	delivery := model.Delivery{ID: uuid.New(), SubscriptionID: id, EventID: event.ID, Event: event, State: model.Undelivered}
+	deliveries := &DeliveryRepository{delivery: &delivery}
+	ctx.Request, _ = http.NewRequest(http.MethodPost, fmt.Sprintf("/webhooks/%v/deliveries/%v/redeliver", id, delivery.ID), nil)
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}, {Key: "delivery", Value: delivery.ID.String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &SubscriptionRepository{subscription: &subscription}, deliveries, &AuditEntryRepository{}))
+
+	Redeliver(ctx)
+
+	assert.Equal(t, http.StatusCreated, r.Code)
+	assert.Len(t, deliveries.created, 1)
+
+	redelivery := deliveries.created[0]
+	assert.Equal(t, event.ID, redelivery.EventID)
+	assert.Equal(t, model.Delivering, redelivery.State)
+	assert.Equal(t, fmt.Sprintf("/webhooks/%v/deliveries/%v", id, redelivery.ID), r.Header().Get("Location"))
+	assert.Contains(t, r.Body.String(), `"type":"trigger.deleted"`)
+}
+
+func TestRedeliverOtherSubscription(t *testing.T) {
+	r := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(r)
+	id := uuid.New()
+	subscription := model.Subscription{ID: id}
+	delivery := model.Delivery{ID: uuid.New(), SubscriptionID: uuid.New()}
+	deliveries := &DeliveryRepository{delivery: &delivery}
+	ctx.Request, _ = http.NewRequest(http.MethodPost, fmt.Sprintf("/webhooks/%v/deliveries/%v/redeliver", id, delivery.ID), nil)
+	ctx.Params = []gin.Param{{Key: "uuid", Value: id.String()}, {Key: "delivery", Value: delivery.ID.String()}}
+
+	ctx.Set("RepositoryRegistry", repository.NewRepositoryRegistry(nil, &SubscriptionRepository{subscription: &subscription}, deliveries))
+
+	Redeliver(ctx)
+
+	assert.Equal(t, http.StatusNotFound, r.Code)
+	assert.Empty(t, deliveries.created)
+}
@@ -8,18 +8,136 @@ import (
 )
 
 func Connect(dsn string) (db *gorm.DB, err error) {
-	db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Info)})
-	if err != nil {
+	return gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Info)})
+}
+
+// Migrate brings the schema up to date. Only one replica at a time should
+// run it, which is why it is not part of Connect.
+func Migrate(db *gorm.DB) (err error) {
+	if err = db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp";`).Error; err != nil {
 		return
 	}
 
-	if err = db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp";`).Error; err != nil {
+	if err = renameAuditTriggers(db); err != nil {
+		return
+	}
+
+	if err = db.AutoMigrate(&model.Tenant{}, &model.Trigger{}, &model.Execution{}, &model.Job{}, &model.Notification{}, &model.Subscription{}, &model.Event{}, &model.Delivery{}, &model.APIKey{}, &model.RoleBinding{}, &model.AuditEntry{}, &model.Reconciliation{}); err != nil {
+		return
+	}
+
+	if err = backfillSecrets(db); err != nil {
+		return
+	}
+
+	if err = redactEvents(db); err != nil {
+		return
+	}
+
+	if err = redactAuditEntries(db); err != nil {
 		return
 	}
 
-	if err = db.AutoMigrate(&model.Trigger{}); err != nil {
+	if err = grantOperate(db); err != nil {
+		return
+	}
+
+	if err = qualifyKeySubjects(db); err != nil {
 		return
 	}
 
 	return
 }
+
+// renameAuditTriggers renames the trigger of the audit entries written before
+// every resource was audited to their resource, the new resource column
+// defaults to triggers.
+func renameAuditTriggers(db *gorm.DB) error {
+	migrator := db.Migrator()
+
+	if !migrator.HasColumn(&model.AuditEntry{}, "trigger_id") || migrator.HasColumn(&model.AuditEntry{}, "resource_id") {
+		return nil
+	}
+
+	if err := migrator.DropIndex(&model.AuditEntry{}, "idx_audit_entries_trigger_id"); err != nil {
+		return err
+	}
+
+	return migrator.RenameColumn(&model.AuditEntry{}, "trigger_id", "resource_id")
+}
+
+// requestFields are the fields of a trigger left out of its snapshot, and
+// redactedFields the ones whose values are left out of the audit log.
+const (
+	requestFields  = `ARRAY['url', 'method', 'headers', 'body', 'content_type', 'success', 'success_criteria', 'timeout', 'retry', 'retry_policy', 'channels']`
+	redactedFields = `ARRAY['url', 'headers', 'body', 'channels']`
+)
+
+// redactEvents reduces the triggers of the events written before events only
+// held a snapshot of them to that snapshot, so the credentials they may hold
+// are not delivered again by redeliveries.
+func redactEvents(db *gorm.DB) error {
+	return db.Exec(`UPDATE events SET trigger = trigger - ` + requestFields + ` WHERE jsonb_exists_any(trigger, ` + redactedFields + `)`).Error
+}
+
+// redactAuditEntries reduces the triggers of the audit entries written before
+// entries only held a snapshot of them to that snapshot, and leaves the values
+// of the redacted fields out of their diffs.
+func redactAuditEntries(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, column := range []string{"before", "after"} {
+			if err := tx.Exec(`UPDATE audit_entries SET ` + column + ` = ` + column + ` - ` + requestFields + ` WHERE resource = 'trigger' AND jsonb_exists_any(` + column + `, ` + redactedFields + `)`).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Exec(`UPDATE audit_entries SET diff = (
+	SELECT jsonb_agg(CASE WHEN change->>'field' = ANY(` + redactedFields + `)
+		THEN jsonb_build_object('field', change->'field', 'before', NULL, 'after', NULL, 'redacted', true)
+		ELSE change END ORDER BY n)
+	FROM jsonb_array_elements(diff) WITH ORDINALITY AS changes(change, n))
+WHERE resource = 'trigger' AND jsonb_typeof(diff) = 'array' AND EXISTS (
+	SELECT 1 FROM jsonb_array_elements(diff) AS change
+	WHERE change->>'field' = ANY(` + redactedFields + `) AND change->'redacted' IS NULL)`).Error
+	})
+}
+
+// grantOperate adds the operate scope to the API keys with the write scope on
+// triggers, which let them pause and resume triggers before the operate scope
+// had to be granted on its own.
+func grantOperate(db *gorm.DB) error {
+	return db.Model(&model.APIKey{}).
+		Where("scopes @> jsonb_build_array(?::text) AND NOT scopes @> jsonb_build_array(?::text)", model.TriggersWrite, model.TriggersOperate).
+		UpdateColumn("scopes", gorm.Expr("scopes || jsonb_build_array(?::text)", model.TriggersOperate)).Error
+}
+
+// qualifyKeySubjects prefixes the subjects of the role bindings of API keys
+// made before subjects told API keys and tokens apart. The other bindings were
+// made for tokens of an unknown issuer, so they are left to be bound again.
+func qualifyKeySubjects(db *gorm.DB) error {
+	return db.Model(&model.RoleBinding{}).
+		Where("subject IN (SELECT id::text FROM api_keys)").
+		UpdateColumn("subject", gorm.Expr("'key:' || subject")).Error
+}
+
+// backfillSecrets generates the signing secret of the triggers created before
+// triggers had one, whose requests would otherwise go out unsigned.
+func backfillSecrets(db *gorm.DB) error {
+	var ids []string
+	if err := db.Unscoped().Model(&model.Trigger{}).Where("secret IS NULL OR secret = ''").Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		secret, err := model.GenerateSecret()
+		if err != nil {
+			return err
+		}
+
+		if err := db.Unscoped().Model(&model.Trigger{}).Where("id = ?", id).UpdateColumn("secret", secret).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,201 @@
+// Package leader elects one replica among the ones sharing a database, so
+// background loops run only once.
+//
+// The leader is whoever holds a session level Postgres advisory lock. The
+// lock lives as long as the connection that took it, so a replica that
+// crashes or loses the database gives up the leadership on its own. The
+// leader also names its connection after its identity, which lets every
+// replica find out who the leader is through pg_locks.
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/skhaz/scheduler/model"
+	"go.uber.org/zap"
+)
+
+// Key identifies the advisory lock of the scheduler. It fits in 32 bits so
+// it can be found in the objid column of pg_locks.
+const Key int64 = 0x5c4ed01e
+
+// MigrationKey identifies the advisory lock held while migrating, which is
+// not the one of the leader so replicas migrate whoever leads.
+const MigrationKey int64 = 0x5c4ed01f
+
+const holderQuery = `SELECT a.application_name FROM pg_locks l JOIN pg_stat_activity a ON a.pid = l.pid
+WHERE l.locktype = 'advisory' AND l.classid = 0 AND l.objid = $1 AND l.objsubid = 1 AND l.granted`
+
+type Interface interface {
+	Status(ctx context.Context) (*model.Leader, error)
+}
+
+type Elector struct {
+	db       *sql.DB
+	identity string
+	interval time.Duration
+	logger   *zap.Logger
+
+	mu    sync.RWMutex
+	since *time.Time
+}
+
+func NewElector(db *sql.DB, identity string, interval time.Duration, logger *zap.Logger) *Elector {
+	return &Elector{
+		db:       db,
+		identity: identity,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run campaigns for the leadership until ctx is done. Every time this replica
+// becomes the leader, run is called with a context that is cancelled when the
+// leadership is lost.
+func (e *Elector) Run(ctx context.Context, run func(context.Context)) error {
+	for {
+		if err := e.campaign(ctx, run); err != nil && !errors.Is(err, context.Canceled) {
+			e.logger.Error("leader election failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(e.interval):
+		}
+	}
+}
+
+// campaign tries to take the lock once. When it succeeds, it holds the lock
+// and checks the connection every interval until either ctx is done or the
+// connection is lost.
+func (e *Elector) campaign(ctx context.Context, run func(context.Context)) error {
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", Key).Scan(&acquired); err != nil {
+		return err
+	}
+
+	if !acquired {
+		return nil
+	}
+
+	if _, err := conn.ExecContext(ctx, "SELECT set_config('application_name', $1, false)", e.identity); err != nil {
+		_ = e.resign(conn)
+		return err
+	}
+
+	e.logger.Info("became the leader", zap.String("identity", e.identity))
+
+	now := time.Now()
+	e.setSince(&now)
+	defer e.setSince(nil)
+
+	leading, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		run(leading)
+	}()
+
+	defer func() {
+		cancel()
+		<-done
+
+		e.logger.Info("lost the leadership", zap.String("identity", e.identity))
+	}()
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return e.resign(conn)
+		case <-ticker.C:
+			if err := conn.PingContext(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// resign releases the lock and the name of a connection that goes back to
+// the pool.
+func (e *Elector) resign(conn *sql.Conn) error {
+	if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", Key); err != nil {
+		return err
+	}
+
+	_, err := conn.ExecContext(context.Background(), "SELECT set_config('application_name', '', false)")
+
+	return err
+}
+
+// Exclusively runs fn while holding the advisory lock identified by key,
+// waiting for whichever replica holds it first. It is meant for the work every
+// replica must see done before going on, such as migrations.
+func Exclusively(ctx context.Context, db *sql.DB, key int64, fn func() error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", key); err != nil {
+		return err
+	}
+
+	err = fn()
+
+	if _, unlockErr := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key); unlockErr != nil && err == nil {
+		err = unlockErr
+	}
+
+	return err
+}
+
+func (e *Elector) setSince(since *time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.since = since
+}
+
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.since != nil
+}
+
+// Status reports this replica and the current leader, which is empty while
+// no replica holds the lock.
+func (e *Elector) Status(ctx context.Context) (*model.Leader, error) {
+	status := &model.Leader{Identity: e.identity}
+
+	e.mu.RLock()
+	if e.since != nil {
+		since := *e.since
+		status.IsLeader = true
+		status.Since = &since
+	}
+	e.mu.RUnlock()
+
+	err := e.db.QueryRowContext(ctx, holderQuery, Key).Scan(&status.Leader)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	return status, nil
+}
@@ -0,0 +1,140 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/thanhpk/randstr"
+	"go.uber.org/zap"
+)
+
+func newElector(t *testing.T) (*Elector, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return NewElector(db, randstr.String(16), time.Hour, zap.NewNop()), mock
+}
+
+func TestRunLeader(t *testing.T) {
+	e, mock := newElector(t)
+
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).WithArgs(Key).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+	mock.ExpectExec(`SELECT set_config\('application_name', \$1, false\)`).WithArgs(e.identity).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).WithArgs(Key).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SELECT set_config\('application_name', '', false\)`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	started, stopped := make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+
+	go func() {
+		done <- e.Run(ctx, func(leading context.Context) {
+			close(started)
+			<-leading.Done()
+			close(stopped)
+		})
+	}()
+
+	<-started
+	assert.True(t, e.IsLeader())
+
+	cancel()
+	assert.NoError(t, <-done)
+
+	<-stopped
+	assert.False(t, e.IsLeader())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunFollower(t *testing.T) {
+	e, mock := newElector(t)
+
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).WithArgs(Key).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(false))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() {
+		done <- e.Run(ctx, func(context.Context) {
+			t.Error("a follower must not run the leader work")
+		})
+	}()
+
+	assert.Eventually(t, func() bool { return mock.ExpectationsWereMet() == nil }, time.Second, time.Millisecond)
+	assert.False(t, e.IsLeader())
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestStatus(t *testing.T) {
+	e, mock := newElector(t)
+
+	mock.ExpectQuery(`SELECT a.application_name FROM pg_locks`).WithArgs(Key).
+		WillReturnRows(sqlmock.NewRows([]string{"application_name"}).AddRow("other"))
+
+	status, err := e.Status(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, e.identity, status.Identity)
+	assert.Equal(t, "other", status.Leader)
+	assert.False(t, status.IsLeader)
+	assert.Nil(t, status.Since)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStatusNoLeader(t *testing.T) {
+	e, mock := newElector(t)
+
+	mock.ExpectQuery(`SELECT a.application_name FROM pg_locks`).WithArgs(Key).
+		WillReturnRows(sqlmock.NewRows([]string{"application_name"}))
+
+	status, err := e.Status(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, status.Leader)
+}
+
+func TestExclusively(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(`SELECT pg_advisory_lock\(\$1\)`).WithArgs(MigrationKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).WithArgs(MigrationKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ran := false
+	assert.NoError(t, Exclusively(context.Background(), db, MigrationKey, func() error {
+		ran = true
+		return nil
+	}))
+
+	assert.True(t, ran)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExclusivelyError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(`SELECT pg_advisory_lock\(\$1\)`).WithArgs(MigrationKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).WithArgs(MigrationKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	failed := errors.New("relation already exists")
+	assert.ErrorIs(t, Exclusively(context.Background(), db, MigrationKey, func() error { return failed }), failed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
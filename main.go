@@ -3,20 +3,47 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/skhaz/scheduler/controller"
 	"github.com/skhaz/scheduler/database"
+	"github.com/skhaz/scheduler/leader"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/notifier"
+	"github.com/skhaz/scheduler/oidc"
+	"github.com/skhaz/scheduler/reconciler"
 	"github.com/skhaz/scheduler/repository"
 	"github.com/skhaz/scheduler/workflow"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
 func main() {
 	viper.AutomaticEnv()
+	viper.SetDefault("RECONCILE_INTERVAL", 5*time.Minute)
+	viper.SetDefault("RECORD_INTERVAL", 30*time.Second)
+	viper.SetDefault("BACKEND", "argo")
+	viper.SetDefault("LEADER_ELECTION_INTERVAL", 5*time.Second)
+	viper.SetDefault("VISIBILITY_TIMEOUT", 15*time.Minute)
+	viper.SetDefault("NOTIFY_INTERVAL", 30*time.Second)
+	viper.SetDefault("DISPATCH_INTERVAL", 5*time.Second)
+	viper.SetDefault("PURGE_INTERVAL", time.Hour)
+	viper.SetDefault("TRIGGER_RETENTION", 30*24*time.Hour)
+	viper.SetDefault("SMTP_PORT", 587)
+	viper.SetDefault("OIDC_TENANT_CLAIM", "tenant")
+	viper.SetDefault("OIDC_ROLES_CLAIM", "roles")
+	viper.SetDefault("OIDC_LEEWAY", time.Minute)
+	viper.SetDefault("OIDC_JWKS_TIMEOUT", 10*time.Second)
+
+	hostname, _ := os.Hostname()
+	viper.SetDefault("REPLICA_ID", hostname)
 
 	logger, _ := zap.NewDevelopment()
 
@@ -33,25 +60,154 @@ func main() {
 		panic(err)
 	}
 
+	sqlDB, err := db.DB()
+	if err != nil {
+		panic(err)
+	}
+
+	// Every replica waits for the schema to be up to date before working or
+	// serving, and only one of them migrates at a time.
+	if err := leader.Exclusively(context.Background(), sqlDB, leader.MigrationKey, func() error { return database.Migrate(db) }); err != nil {
+		panic(err)
+	}
+
 	registry := repository.NewRepositoryRegistry(
 		db,
 		&repository.TriggerRepository{},
+		&repository.ExecutionRepository{},
+		&repository.JobRepository{},
+		&repository.NotificationRepository{},
+		&repository.SubscriptionRepository{},
+		&repository.DeliveryRepository{},
+		&repository.APIKeyRepository{},
+		&repository.TenantRepository{},
+		&repository.RoleBindingRepository{},
+		&repository.AuditEntryRepository{},
+		&repository.ReconciliationRepository{},
 	)
 
-	c := ctrl.GetConfigOrDie()
-	clientset := kubernetes.NewForConfigOrDie(c)
-	api, err := dynamic.NewForConfig(c)
-	if err != nil {
-		panic(err)
+	var ctx = context.Background()
+
+	// singletons run on the leader only, every replica serves the API.
+	var singletons []func(context.Context) error
+
+	var wf workflow.Interface
+
+	switch backend := viper.GetString("BACKEND"); backend {
+	case "argo":
+		api, clientset := kubernetesClients()
+		wf = workflow.NewWorkflow(ctx, api, clientset)
+	case "cronjob":
+		api, clientset := kubernetesClients()
+		wf = workflow.NewCronJob(ctx, api, clientset)
+	case "local":
+		local := workflow.NewLocal(ctx, registry, http.DefaultClient, clock.RealClock{}, logger)
+		singletons = append(singletons, local.Start)
+		wf = local
+
+		// The leader enqueues what is due, every replica runs the queue.
+		go func() {
+			if err := local.Work(ctx, viper.GetString("REPLICA_ID"), viper.GetDuration("VISIBILITY_TIMEOUT")); err != nil {
+				logger.Error("job worker failed", zap.Error(err))
+			}
+		}()
+	default:
+		panic(fmt.Errorf("unknown backend %q", backend))
 	}
 
-	var ctx = context.Background()
+	rec := reconciler.NewReconciler(registry, wf, viper.GetDuration("RECONCILE_INTERVAL"), logger)
+	singletons = append(singletons, rec.Start)
+
+	recorder := reconciler.NewRecorder(registry, wf, viper.GetDuration("RECORD_INTERVAL"), logger)
+	singletons = append(singletons, recorder.Start)
 
-	wf := workflow.NewWorkflow(ctx, api, clientset)
+	purger := reconciler.NewPurger(registry, wf, clock.RealClock{}, viper.GetDuration("PURGE_INTERVAL"), viper.GetDuration("TRIGGER_RETENTION"), logger)
+	singletons = append(singletons, purger.Start)
+
+	senders := map[string]notifier.Sender{
+		model.Webhook: &notifier.Webhook{Client: http.DefaultClient},
+		model.Slack:   &notifier.Slack{Client: http.DefaultClient},
+	}
+
+	if host := viper.GetString("SMTP_HOST"); host != "" {
+		senders[model.Email] = &notifier.SMTP{
+			Host:     host,
+			Port:     viper.GetInt("SMTP_PORT"),
+			Username: viper.GetString("SMTP_USERNAME"),
+			Password: viper.GetString("SMTP_PASSWORD"),
+			From:     viper.GetString("SMTP_FROM"),
+		}
+	}
+
+	n := notifier.NewNotifier(registry, senders, clock.RealClock{}, viper.GetDuration("NOTIFY_INTERVAL"), logger)
+	singletons = append(singletons, n.Start)
+
+	dispatcher := notifier.NewDispatcher(registry, http.DefaultClient, clock.RealClock{}, viper.GetDuration("DISPATCH_INTERVAL"), logger)
+	singletons = append(singletons, dispatcher.Start)
+
+	elector := leader.NewElector(sqlDB, viper.GetString("REPLICA_ID"), viper.GetDuration("LEADER_ELECTION_INTERVAL"), logger)
+	go func() {
+		_ = elector.Run(ctx, func(ctx context.Context) {
+			var wg sync.WaitGroup
+			for _, singleton := range singletons {
+				wg.Add(1)
+				go func(start func(context.Context) error) {
+					defer wg.Done()
+					if err := start(ctx); err != nil {
+						logger.Error("background work failed", zap.Error(err))
+					}
+				}(singleton)
+			}
+			wg.Wait()
+		})
+	}()
 
 	server := controller.InitServer()
 	server.SetLogger(logger)
 	server.SetRepositoryRegistry(registry)
+	server.SetAuthentication(viper.GetString("ADMIN_API_KEY"), tokenVerifier())
 	server.SetWorkflow(wf)
+	server.SetReconciler(rec)
+	server.SetElector(elector)
 	server.Run()
 }
+
+// tokenVerifier returns the verifier of the JWTs of the OIDC provider, or nil
+// when OIDC_ISSUER is not set. OIDC_JWKS is the URL or the path of its keys,
+// fetched within OIDC_JWKS_TIMEOUT. Only the tokens with the OIDC_ADMIN_ROLE
+// role may lack a tenant, and none when it is not set.
+func tokenVerifier() *oidc.Verifier {
+	issuer := viper.GetString("OIDC_ISSUER")
+	if issuer == "" {
+		return nil
+	}
+
+	jwks, audience := viper.GetString("OIDC_JWKS"), viper.GetString("OIDC_AUDIENCE")
+	if jwks == "" || audience == "" {
+		panic("OIDC_JWKS and OIDC_AUDIENCE are required with OIDC_ISSUER")
+	}
+
+	config := oidc.Config{
+		Issuer:      issuer,
+		Audience:    audience,
+		TenantClaim: viper.GetString("OIDC_TENANT_CLAIM"),
+		RolesClaim:  viper.GetString("OIDC_ROLES_CLAIM"),
+		AdminRole:   viper.GetString("OIDC_ADMIN_ROLE"),
+		Leeway:      viper.GetDuration("OIDC_LEEWAY"),
+	}
+
+	client := &http.Client{Timeout: viper.GetDuration("OIDC_JWKS_TIMEOUT")}
+
+	return oidc.NewVerifier(config, oidc.NewKeySet(jwks, client, clock.RealClock{}), clock.RealClock{})
+}
+
+func kubernetesClients() (dynamic.Interface, kubernetes.Interface) {
+	c := ctrl.GetConfigOrDie()
+	clientset := kubernetes.NewForConfigOrDie(c)
+	api, err := dynamic.NewForConfig(c)
+	if err != nil {
+		panic(err)
+	}
+
+	return api, clientset
+}
@@ -0,0 +1,160 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pmoule/go2hal/hal"
+)
+
+const (
+	TriggersRead    = "triggers:read"
+	TriggersWrite   = "triggers:write"
+	TriggersRun     = "triggers:run"
+	TriggersOperate = "triggers:operate"
+	WebhooksRead    = "webhooks:read"
+	WebhooksWrite   = "webhooks:write"
+	AuditRead       = "audit:read"
+
+	// Admin allows everything, including managing API keys.
+	Admin = "admin"
+
+	// KeyPrefix starts every API key, so leaked keys are easy to search for.
+	KeyPrefix = "sk_"
+)
+
+// APIKey authenticates requests made with it as a bearer token. Only the
+// SHA-256 of the key is stored, along with its first characters so it can be
+// told apart from the others. The requests made with the key of a tenant are
+// scoped to the tenant, admin keys belong to no tenant.
+type APIKey struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();not null" json:"id"`
+	TenantID  *uuid.UUID `gorm:"type:uuid;index" json:"tenant_id,omitempty"`
+	Tenant    *Tenant    `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Name      string     `gorm:"type:varchar(64);not null" json:"name" validate:"required,max=64"`
+	Prefix    string     `gorm:"type:varchar(12);not null" json:"prefix"`
+	Hash      string     `gorm:"type:char(64);uniqueIndex;not null" json:"-"`
+	Scopes    []string   `gorm:"type:jsonb;serializer:json;not null" json:"scopes" validate:"min=1,max=8,unique,dive,oneof=triggers:read triggers:write triggers:run triggers:operate webhooks:read webhooks:write audit:read admin"`
+	ExpiresAt *time.Time `gorm:"default:null" json:"expires_at,omitempty"`
+	RevokedAt *time.Time `gorm:"default:null" json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime;not null" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"autoUpdateTime;not null" json:"updated_at"`
+}
+
+type APIKeyCollection []*APIKey
+
+// APIKeySecret reveals an API key, which only happens in the response of the
+// request that creates it.
+type APIKeySecret struct {
+	Key string `json:"key"`
+}
+
+// GenerateAPIKey returns a new random API key.
+func GenerateAPIKey() (string, error) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		return "", err
+	}
+
+	return KeyPrefix + secret, nil
+}
+
+// HashAPIKey returns the hash an API key is stored and looked up by. Keys are
+// random, so a plain SHA-256 is enough to keep them safe.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// SetKey stores the hash and the prefix of key.
+func (k *APIKey) SetKey(key string) {
+	k.Hash = HashAPIKey(key)
+	k.Prefix = key[:min(len(key), 12)]
+}
+
+// IsActive reports whether the key can be used at now.
+func (k *APIKey) IsActive(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+func (k *APIKey) AuditResource() (string, uuid.UUID, *uuid.UUID) {
+	return ResourceAPIKey, k.ID, k.TenantID
+}
+
+// AuditSnapshot is the key as listed, only its hash is not.
+func (k *APIKey) AuditSnapshot() any {
+	return k
+}
+
+func (k *APIKey) AuditRedacted() []string {
+	return nil
+}
+
+func (k *APIKey) ToHAL(selfHref string) (root hal.Resource) {
+	root = hal.NewResourceObject()
+	_ = AddJSON(root, k)
+
+	selfRel := hal.NewSelfLinkRelation()
+	selfLink := &hal.LinkObject{Href: selfHref}
+	selfRel.SetLink(selfLink)
+	root.AddLink(selfRel)
+
+	return
+}
+
+func (collection APIKeyCollection) ToHAL(selfHref string, queryString url.Values) (root hal.Resource) {
+	type NameOnly struct {
+		Name string `json:"name"`
+	}
+
+	type Result struct {
+		Count   int              `json:"count"`
+		Results APIKeyCollection `json:"results"`
+	}
+
+	root = hal.NewResourceObject()
+
+	selfRel := hal.NewSelfLinkRelation()
+	selfRel.SetLink(&hal.LinkObject{Href: selfHref})
+	root.AddLink(selfRel)
+
+	el, hasLast := Last(collection)
+	if hasLast {
+		after, err := el.CreatedAt.MarshalText()
+		if NoError(err) {
+			queryString.Set(After, string(after))
+
+			nextRel, _ := hal.NewLinkRelation(NextRelation)
+			nextLink := &hal.LinkObject{Href: strings.Join([]string{selfHref, queryString.Encode()}, "?")}
+			nextRel.SetLink(nextLink)
+			root.AddLink(nextRel)
+		}
+	}
+
+	var embedded []hal.Resource
+
+	for _, key := range collection {
+		selfLink, _ := hal.NewLinkObject(fmt.Sprintf("%s/%v", selfHref, key.ID))
+
+		selfRel, _ := hal.NewLinkRelation("self")
+		selfRel.SetLink(selfLink)
+
+		resource := hal.NewResourceObject()
+		resource.AddLink(selfRel)
+		resource.AddData(NameOnly{key.Name})
+
+		embedded = append(embedded, resource)
+	}
+
+	keys, _ := hal.NewResourceRelation("keys")
+	keys.SetResources(embedded)
+	root.AddResource(keys)
+	_ = AddJSON(root, Result{len(collection), collection})
+
+	return
+}
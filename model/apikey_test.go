@@ -0,0 +1,62 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKey(t *testing.T) {
+	key, err := GenerateAPIKey()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, KeyPrefix))
+
+	k := APIKey{}
+	k.SetKey(key)
+	assert.Equal(t, HashAPIKey(key), k.Hash)
+	assert.NotEqual(t, HashAPIKey(key+"0"), k.Hash)
+	assert.Equal(t, key[:12], k.Prefix)
+
+	now := time.Now()
+	assert.True(t, k.IsActive(now))
+
+	later := now.Add(time.Hour)
+	k.ExpiresAt = &later
+	assert.True(t, k.IsActive(now))
+	assert.False(t, k.IsActive(later))
+
+	k.RevokedAt = &now
+	assert.False(t, k.IsActive(now))
+}
+
+func TestPrincipalAllows(t *testing.T) {
+	reader := Principal{Scopes: []string{TriggersRead}}
+	assert.True(t, reader.Allows(TriggersRead))
+	assert.False(t, reader.Allows(TriggersWrite))
+
+	admin := Principal{Scopes: []string{Admin}}
+	assert.True(t, admin.Allows(TriggersRun))
+	assert.True(t, admin.Allows(WebhooksWrite))
+}
+
+func TestPrincipalRoles(t *testing.T) {
+	operator := Principal{Roles: []string{Operator}}
+	assert.True(t, operator.Allows(TriggersRun))
+	assert.True(t, operator.Allows(TriggersOperate))
+	assert.False(t, operator.Allows(TriggersWrite))
+
+	writer := Principal{Scopes: []string{TriggersWrite}}
+	assert.False(t, writer.Allows(TriggersOperate))
+
+	editor := Principal{Roles: []string{Editor}}
+	assert.True(t, editor.Allows(TriggersOperate))
+
+	tenant := uuid.New()
+	tenantAdmin := Principal{Scopes: []string{TriggersRead}, Roles: []string{Admin}, Tenant: &tenant}
+	assert.True(t, tenantAdmin.Allows(TriggersRead))
+	assert.False(t, tenantAdmin.Allows(TriggersWrite))
+	assert.False(t, tenantAdmin.Allows(Admin))
+}
@@ -0,0 +1,261 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pmoule/go2hal/hal"
+)
+
+const (
+	ActionCreate       = "create"
+	ActionUpdate       = "update"
+	ActionRun          = "run"
+	ActionPause        = "pause"
+	ActionResume       = "resume"
+	ActionRotateSecret = "rotate_secret"
+	ActionDelete       = "delete"
+	ActionRestore      = "restore"
+	ActionRedeliver    = "redeliver"
+	ActionRevoke       = "revoke"
+
+	ResourceTrigger     = "trigger"
+	ResourceWebhook     = "webhook"
+	ResourceTenant      = "tenant"
+	ResourceRoleBinding = "role_binding"
+	ResourceAPIKey      = "api_key"
+)
+
+// resourcePaths are where the resources of the audit log are served.
+var resourcePaths = map[string]string{
+	ResourceTrigger:     "/triggers",
+	ResourceWebhook:     "/webhooks",
+	ResourceTenant:      "/admin/tenants",
+	ResourceRoleBinding: "/admin/roles",
+	ResourceAPIKey:      "/admin/keys",
+}
+
+// Audited is implemented by the entities whose changes are audited.
+// AuditResource tells the type, ID and tenant of the entity, AuditSnapshot the
+// part of it kept in the audit log, and AuditRedacted the fields, by their
+// JSON names, whose changes are audited without their values, as they may hold
+// credentials and the audit log is kept for good.
+type Audited interface {
+	AuditResource() (resource string, id uuid.UUID, tenant *uuid.UUID)
+	AuditSnapshot() any
+	AuditRedacted() []string
+}
+
+// AuditEntry records a change made to a resource through the API, written in
+// the same transaction as the change itself. Before and After are snapshots of
+// the resource around the change, Before is nil for created resources and
+// After for deleted ones. Entries are never updated nor deleted.
+type AuditEntry struct {
+	ID         uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();not null" json:"id"`
+	TenantID   *uuid.UUID `gorm:"type:uuid;index" json:"tenant_id,omitempty"`
+	Resource   string     `gorm:"type:varchar(16);index;default:trigger;not null" json:"resource"`
+	ResourceID uuid.UUID  `gorm:"type:uuid;index;not null" json:"resource_id"`
+	Action     string     `gorm:"type:varchar(16);index;not null" json:"action"`
+	Actor      string     `gorm:"type:varchar(255);index;not null" json:"actor"`
+	IP         string     `gorm:"type:varchar(45);not null" json:"ip"`
+	Before     any        `gorm:"type:jsonb;serializer:json;default:null" json:"before,omitempty"`
+	After      any        `gorm:"type:jsonb;serializer:json;default:null" json:"after,omitempty"`
+	Diff       []Change   `gorm:"type:jsonb;serializer:json;default:null" json:"diff,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime;index;not null" json:"created_at"`
+}
+
+type AuditEntryCollection []*AuditEntry
+
+// Change is a field of a resource that a change set from Before to After, by
+// its JSON name. The values of the redacted fields are left out.
+type Change struct {
+	Field    string `json:"field"`
+	Before   any    `json:"before"`
+	After    any    `json:"after"`
+	Redacted bool   `json:"redacted,omitempty"`
+}
+
+// NewAuditEntry returns the entry of action, done by actor from ip, that
+// changed a resource from before to after. Either may be nil, not both.
+func NewAuditEntry(action, actor, ip string, before, after Audited) (*AuditEntry, error) {
+	audited := after
+	if audited == nil {
+		audited = before
+	}
+
+	diff, err := Diff(before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	resource, id, tenant := audited.AuditResource()
+
+	entry := &AuditEntry{
+		TenantID:   tenant,
+		Resource:   resource,
+		ResourceID: id,
+		Action:     action,
+		Actor:      actor,
+		IP:         ip,
+		Diff:       diff,
+	}
+
+	if before != nil {
+		entry.Before = before.AuditSnapshot()
+	}
+
+	if after != nil {
+		entry.After = after.AuditSnapshot()
+	}
+
+	return entry, nil
+}
+
+// Diff returns the fields that differ between two states of a resource,
+// sorted by name. The timestamps are left out, as every change updates them,
+// and so are the values of the redacted fields.
+func Diff(before, after Audited) ([]Change, error) {
+	b, err := auditedFields(before)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := auditedFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var redacted []string
+	for _, audited := range []Audited{before, after} {
+		if audited != nil {
+			redacted = append(redacted, audited.AuditRedacted()...)
+		}
+	}
+
+	var names []string
+	for name := range b {
+		names = append(names, name)
+	}
+
+	for name := range a {
+		if _, ok := b[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+
+	var diff []Change
+
+	for _, name := range names {
+		if name == "created_at" || name == "updated_at" {
+			continue
+		}
+
+		if reflect.DeepEqual(b[name], a[name]) {
+			continue
+		}
+
+		if slices.Contains(redacted, name) {
+			diff = append(diff, Change{Field: name, Redacted: true})
+			continue
+		}
+
+		diff = append(diff, Change{Field: name, Before: b[name], After: a[name]})
+	}
+
+	return diff, nil
+}
+
+func auditedFields(audited Audited) (map[string]any, error) {
+	fields := map[string]any{}
+
+	if audited == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(audited)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &fields)
+
+	return fields, err
+}
+
+func (entry *AuditEntry) ToHAL(selfHref string) (root hal.Resource) {
+	root = hal.NewResourceObject()
+	_ = AddJSON(root, entry)
+
+	selfRel := hal.NewSelfLinkRelation()
+	selfLink := &hal.LinkObject{Href: selfHref}
+	selfRel.SetLink(selfLink)
+	root.AddLink(selfRel)
+
+	if resourcePath, ok := resourcePaths[entry.Resource]; ok {
+		resourceRel, _ := hal.NewLinkRelation(entry.Resource)
+		resourceRel.SetLink(&hal.LinkObject{Href: fmt.Sprintf("%s/%v", resourcePath, entry.ResourceID)})
+		root.AddLink(resourceRel)
+	}
+
+	return
+}
+
+func (collection AuditEntryCollection) ToHAL(selfHref string, queryString url.Values) (root hal.Resource) {
+	type ActionOnly struct {
+		Action string `json:"action"`
+	}
+
+	type Result struct {
+		Count   int                  `json:"count"`
+		Results AuditEntryCollection `json:"results"`
+	}
+
+	root = hal.NewResourceObject()
+
+	selfRel := hal.NewSelfLinkRelation()
+	selfRel.SetLink(&hal.LinkObject{Href: selfHref})
+	root.AddLink(selfRel)
+
+	el, hasLast := Last(collection)
+	if hasLast {
+		after, err := el.CreatedAt.MarshalText()
+		if NoError(err) {
+			queryString.Set(After, string(after))
+
+			nextRel, _ := hal.NewLinkRelation(NextRelation)
+			nextLink := &hal.LinkObject{Href: strings.Join([]string{selfHref, queryString.Encode()}, "?")}
+			nextRel.SetLink(nextLink)
+			root.AddLink(nextRel)
+		}
+	}
+
+	var embedded []hal.Resource
+
+	for _, entry := range collection {
+		selfLink, _ := hal.NewLinkObject(fmt.Sprintf("%s/%v", selfHref, entry.ID))
+
+		selfRel, _ := hal.NewLinkRelation("self")
+		selfRel.SetLink(selfLink)
+
+		resource := hal.NewResourceObject()
+		resource.AddLink(selfRel)
+		resource.AddData(ActionOnly{entry.Action})
+
+		embedded = append(embedded, resource)
+	}
+
+	entries, _ := hal.NewResourceRelation("entries")
+	entries.SetResources(embedded)
+	root.AddResource(entries)
+	_ = AddJSON(root, Result{len(collection), collection})
+
+	return
+}
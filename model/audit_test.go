@@ -0,0 +1,75 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	enabled, disabled := true, false
+	before := &Trigger{ID: uuid.New(), Name: "nightly", Schedule: "0 0 * * *", Enabled: &enabled, Secret: "before"}
+	after := *before
+	after.Schedule = "0 1 * * *"
+	after.Enabled = &disabled
+	after.Headers = map[string]string{"Authorization": "Bearer token"}
+	after.Url = "https://example.com/?token=token"
+	after.Secret = "after"
+
+	diff, err := Diff(before, &after)
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Field: "enabled", Before: true, After: false},
+		{Field: "headers", Redacted: true},
+		{Field: "schedule", Before: "0 0 * * *", After: "0 1 * * *"},
+		{Field: "url", Redacted: true},
+	}, diff)
+
+	diff, err = Diff(before, before)
+	assert.NoError(t, err)
+	assert.Empty(t, diff)
+}
+
+func TestNewAuditEntryDeleted(t *testing.T) {
+	tenant := uuid.New()
+	trigger := &Trigger{ID: uuid.New(), TenantID: &tenant, Name: "nightly", Channels: []Channel{{Kind: Slack, URL: "https://hooks.slack.com/services/T0/B0/token"}}}
+
+	entry, err := NewAuditEntry(ActionDelete, "alice", "203.0.113.7", trigger, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, ResourceTrigger, entry.Resource)
+	assert.Equal(t, trigger.ID, entry.ResourceID)
+	assert.Equal(t, &tenant, entry.TenantID)
+	assert.Equal(t, trigger.Snapshot(), entry.Before)
+	assert.Nil(t, entry.After)
+	assert.Contains(t, entry.Diff, Change{Field: "name", Before: "nightly", After: nil})
+	assert.Contains(t, entry.Diff, Change{Field: "channels", Redacted: true})
+
+	data, err := json.Marshal(entry)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "token")
+}
+
+func TestNewAuditEntryWebhook(t *testing.T) {
+	enabled, disabled := true, false
+	before := &Subscription{ID: uuid.New(), URL: "https://hooks.example.com/T0/secret-token", Events: []string{TriggerCreated}, Enabled: &enabled, Secret: "secret"}
+	after := *before
+	after.URL = "https://hooks.example.com/T0/other-token"
+	after.Enabled = &disabled
+
+	entry, err := NewAuditEntry(ActionUpdate, "alice", "203.0.113.7", before, &after)
+	assert.NoError(t, err)
+	assert.Equal(t, ResourceWebhook, entry.Resource)
+	assert.Equal(t, before.ID, entry.ResourceID)
+	assert.Equal(t, []Change{
+		{Field: "enabled", Before: true, After: false},
+		{Field: "url", Redacted: true},
+	}, entry.Diff)
+
+	data, err := json.Marshal(entry)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"url":"https://hooks.example.com"`)
+	assert.NotContains(t, string(data), "token")
+	assert.NotContains(t, string(data), "secret")
+}
@@ -0,0 +1,45 @@
+package model
+
+import "slices"
+
+const (
+	Webhook = "webhook"
+	Email   = "email"
+	Slack   = "slack"
+)
+
+const (
+	// OnFailure fires for every failed execution.
+	OnFailure = "failure"
+	// OnRecovery fires for a successful execution that follows a failed one.
+	OnRecovery = "recovery"
+	// OnConsecutive fires once a run of failures reaches Consecutive.
+	OnConsecutive = "consecutive"
+)
+
+// Channel is where the scheduler notifies about the executions of a trigger.
+// Webhook and Slack channels post to URL, email channels write to To through
+// the SMTP server of the scheduler.
+type Channel struct {
+	Kind        string   `json:"kind" validate:"oneof=webhook email slack"`
+	URL         string   `json:"url,omitempty" validate:"required_unless=Kind email,omitempty,url,max=2048"`
+	To          []string `json:"to,omitempty" validate:"required_if=Kind email,max=16,dive,email"`
+	On          []string `json:"on" validate:"min=1,max=3,dive,oneof=failure recovery consecutive"`
+	Consecutive int      `json:"consecutive,omitempty" validate:"gte=0,lte=100"`
+}
+
+// Event returns what the channel is notified about an execution that ended
+// with failed, after streak failures in a row, including itself, or after a
+// failure when recovered. It is empty when the channel is not interested.
+func (c Channel) Event(failed, recovered bool, streak int) string {
+	switch {
+	case failed && slices.Contains(c.On, OnConsecutive) && streak == max(c.Consecutive, 1):
+		return OnConsecutive
+	case failed && slices.Contains(c.On, OnFailure):
+		return OnFailure
+	case recovered && slices.Contains(c.On, OnRecovery):
+		return OnRecovery
+	}
+
+	return ""
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pmoule/go2hal/hal"
+)
+
+// Event is a change to a trigger, written to the outbox in the same
+// transaction as the change itself, along with one delivery per subscription
+// interested in it. Trigger is the state of the trigger after the change, or
+// before it when the trigger was deleted, only the part of it that can be sent
+// to every subscription, see TriggerSnapshot.
+type Event struct {
+	ID        uuid.UUID        `gorm:"type:uuid;default:uuid_generate_v4();not null" json:"id"`
+	Type      string           `gorm:"type:varchar(32);not null" json:"type"`
+	TriggerID uuid.UUID        `gorm:"type:uuid;index;not null" json:"trigger_id"`
+	TenantID  *uuid.UUID       `gorm:"type:uuid" json:"tenant_id,omitempty"`
+	Trigger   *TriggerSnapshot `gorm:"type:jsonb;serializer:json;not null" json:"trigger"`
+	CreatedAt time.Time        `gorm:"autoCreateTime;not null" json:"created_at"`
+}
+
+// NewEvent returns an event of type about a snapshot of trigger.
+func NewEvent(event string, trigger *Trigger) *Event {
+	return &Event{Type: event, TriggerID: trigger.ID, TenantID: trigger.TenantID, Trigger: trigger.Snapshot()}
+}
+
+// Delivery is an attempt to tell a subscription about an event. Pending
+// deliveries are retried with backoff until NextAttemptAt, a redelivery is a
+// new delivery of the same event, so the log of the previous one is kept.
+type Delivery struct {
+	ID             uuid.UUID     `gorm:"type:uuid;default:uuid_generate_v4();not null" json:"id"`
+	SubscriptionID uuid.UUID     `gorm:"type:uuid;index;not null" json:"subscription_id"`
+	Subscription   *Subscription `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	EventID        uuid.UUID     `gorm:"type:uuid;not null" json:"event_id"`
+	Event          *Event        `gorm:"constraint:OnDelete:CASCADE" json:"event,omitempty"`
+	State          string        `gorm:"type:varchar(16);index:idx_deliveries_state_next_attempt_at;default:delivering;not null" json:"state"`
+	Attempts       int           `gorm:"type:smallint;default:0;not null" json:"attempts"`
+	NextAttemptAt  time.Time     `gorm:"index:idx_deliveries_state_next_attempt_at;not null" json:"next_attempt_at"`
+	StatusCode     int           `gorm:"type:smallint;default:null" json:"status_code,omitempty"`
+	LastError      string        `gorm:"type:text;default:null" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time    `gorm:"default:null" json:"delivered_at,omitempty"`
+	CreatedAt      time.Time     `gorm:"autoCreateTime;not null" json:"created_at"`
+	UpdatedAt      time.Time     `gorm:"autoUpdateTime;not null" json:"updated_at"`
+}
+
+type DeliveryCollection []*Delivery
+
+func (d *Delivery) ToHAL(selfHref string) (root hal.Resource) {
+	root = hal.NewResourceObject()
+	_ = AddJSON(root, d)
+
+	selfRel := hal.NewSelfLinkRelation()
+	selfLink := &hal.LinkObject{Href: selfHref}
+	selfRel.SetLink(selfLink)
+	root.AddLink(selfRel)
+
+	redeliverHref, _ := url.JoinPath(selfHref, "redeliver")
+	redeliverRel, _ := hal.NewLinkRelation("redeliver")
+	redeliverRel.SetLink(&hal.LinkObject{Href: redeliverHref})
+	root.AddLink(redeliverRel)
+
+	return
+}
+
+func (collection DeliveryCollection) ToHAL(selfHref string, queryString url.Values) (root hal.Resource) {
+	type StateOnly struct {
+		State string `json:"state"`
+	}
+
+	type Result struct {
+		Count   int                `json:"count"`
+		Results DeliveryCollection `json:"results"`
+	}
+
+	root = hal.NewResourceObject()
+
+	selfRel := hal.NewSelfLinkRelation()
+	selfRel.SetLink(&hal.LinkObject{Href: selfHref})
+	root.AddLink(selfRel)
+
+	el, hasLast := Last(collection)
+	if hasLast {
+		after, err := el.CreatedAt.MarshalText()
+		if NoError(err) {
+			queryString.Set(After, string(after))
+
+			nextRel, _ := hal.NewLinkRelation(NextRelation)
+			nextLink := &hal.LinkObject{Href: strings.Join([]string{selfHref, queryString.Encode()}, "?")}
+			nextRel.SetLink(nextLink)
+			root.AddLink(nextRel)
+		}
+	}
+
+	var embedded []hal.Resource
+
+	for _, delivery := range collection {
+		selfLink, _ := hal.NewLinkObject(fmt.Sprintf("%s/%v", selfHref, delivery.ID))
+
+		selfRel, _ := hal.NewLinkRelation("self")
+		selfRel.SetLink(selfLink)
+
+		resource := hal.NewResourceObject()
+		resource.AddLink(selfRel)
+		resource.AddData(StateOnly{delivery.State})
+
+		embedded = append(embedded, resource)
+	}
+
+	deliveries, _ := hal.NewResourceRelation("deliveries")
+	deliveries.SetResources(embedded)
+	root.AddResource(deliveries)
+	_ = AddJSON(root, Result{len(collection), collection})
+
+	return
+}
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pmoule/go2hal/hal"
+)
+
+const (
+	Pending   = "Pending"
+	Running   = "Running"
+	Succeeded = "Succeeded"
+	Failed    = "Failed"
+	Error     = "Error"
+)
+
+// Execution is a single run of a trigger, its ID is the UID of the workflow
+// object that performed it, so it can be kept after the cluster forgets it.
+type Execution struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	TriggerID  uuid.UUID  `gorm:"type:uuid;index;not null" json:"trigger_id"`
+	Name       string     `gorm:"type:varchar(253);not null" json:"name"`
+	Phase      string     `gorm:"type:varchar(16);not null" json:"phase"`
+	StatusCode int        `gorm:"type:smallint;default:null" json:"status_code,omitempty"`
+	Message    string     `gorm:"type:text;default:null" json:"message,omitempty"`
+	StartedAt  time.Time  `gorm:"index;not null" json:"started_at"`
+	FinishedAt *time.Time `gorm:"default:null" json:"finished_at,omitempty"`
+	Duration   int64      `gorm:"type:bigint;default:0;not null" json:"duration"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime;not null" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime;not null" json:"updated_at"`
+}
+
+type ExecutionCollection []*Execution
+
+func (e *Execution) ToHAL(selfHref string) (root hal.Resource) {
+	root = hal.NewResourceObject()
+	root.AddData(e)
+
+	selfRel := hal.NewSelfLinkRelation()
+	selfLink := &hal.LinkObject{Href: selfHref}
+	selfRel.SetLink(selfLink)
+	root.AddLink(selfRel)
+
+	return
+}
+
+func (collection ExecutionCollection) ToHAL(selfHref string, queryString url.Values) (root hal.Resource) {
+	type PhaseOnly struct {
+		Phase string `json:"phase"`
+	}
+
+	type Result struct {
+		Count   int                 `json:"count"`
+		Results ExecutionCollection `json:"results"`
+	}
+
+	root = hal.NewResourceObject()
+
+	selfRel := hal.NewSelfLinkRelation()
+	selfRel.SetLink(&hal.LinkObject{Href: selfHref})
+	root.AddLink(selfRel)
+
+	el, hasLast := Last(collection)
+	if hasLast {
+		after, err := el.StartedAt.MarshalText()
+		if NoError(err) {
+			queryString.Set(After, string(after))
+
+			nextRel, _ := hal.NewLinkRelation(NextRelation)
+			nextLink := &hal.LinkObject{Href: strings.Join([]string{selfHref, queryString.Encode()}, "?")}
+			nextRel.SetLink(nextLink)
+			root.AddLink(nextRel)
+		}
+	}
+
+	var embedded []hal.Resource
+
+	for _, execution := range collection {
+		selfLink, _ := hal.NewLinkObject(fmt.Sprintf("%s/%v", selfHref, execution.ID))
+
+		selfRel, _ := hal.NewLinkRelation("self")
+		selfRel.SetLink(selfLink)
+
+		resource := hal.NewResourceObject()
+		resource.AddLink(selfRel)
+		resource.AddData(PhaseOnly{execution.Phase})
+
+		embedded = append(embedded, resource)
+	}
+
+	executions, _ := hal.NewResourceRelation("executions")
+	executions.SetResources(embedded)
+	root.AddResource(executions)
+	root.AddData(Result{len(collection), collection})
+
+	return
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/thanhpk/randstr"
+)
+
+func TestSingleExecutionHAL(t *testing.T) {
+	now := time.Now()
+	finished := now.Add(time.Second)
+	id := uuid.New()
+	triggerID := uuid.New()
+	name := randstr.String(16)
+	url := "/" + randstr.String(16)
+
+	execution := Execution{
+		ID:         id,
+		TriggerID:  triggerID,
+		Name:       name,
+		Phase:      Succeeded,
+		StatusCode: 200,
+		StartedAt:  now,
+		FinishedAt: &finished,
+		Duration:   1000,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	type Self struct {
+		Href string `json:"href"`
+	}
+
+	type Links struct {
+		Self Self `json:"self"`
+	}
+
+	type HAL struct {
+		Links      Links     `json:"_links"`
+		ID         uuid.UUID `json:"id"`
+		TriggerID  uuid.UUID `json:"trigger_id"`
+		Name       string    `json:"name"`
+		Phase      string    `json:"phase"`
+		StatusCode int       `json:"status_code"`
+		StartedAt  time.Time `json:"started_at"`
+		FinishedAt time.Time `json:"finished_at"`
+		Duration   int64     `json:"duration"`
+		CreatedAt  time.Time `json:"created_at"`
+		UpdatedAt  time.Time `json:"updated_at"`
+	}
+
+	expected, _ := json.Marshal(HAL{
+		Links:      Links{Self: Self{Href: url}},
+		ID:         id,
+		TriggerID:  triggerID,
+		Name:       name,
+		Phase:      Succeeded,
+		StatusCode: 200,
+		StartedAt:  now,
+		FinishedAt: finished,
+		Duration:   1000,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	})
+
+	resource := execution.ToHAL(url)
+	namedMap := resource.ToMap()
+	actual, _ := json.Marshal(namedMap.Content)
+
+	expected, _ = JSONRemarshal(expected)
+	actual, _ = JSONRemarshal(actual)
+	assert.Equal(t, string(expected), string(actual))
+}
+
+func TestMultipleExecutionsHAL(t *testing.T) {
+	now := time.Now()
+	id := uuid.New()
+	path := "/" + randstr.String(16)
+
+	e := Execution{
+		ID:        id,
+		Name:      randstr.String(16),
+		Phase:     Running,
+		StartedAt: now,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	ec := ExecutionCollection{&e}
+
+	resource := ec.ToHAL(path, url.Values{})
+	namedMap := resource.ToMap()
+	actual, _ := json.Marshal(namedMap.Content)
+
+	after, _ := e.StartedAt.MarshalText()
+	query := url.Values{}
+	query.Add(After, string(after))
+
+	assert.Contains(t, string(actual), `"count":1`)
+	assert.Contains(t, string(actual), `"phase":"Running"`)
+	assert.Contains(t, string(actual), `"href":"`+path+"/"+id.String()+`"`)
+	assert.Contains(t, string(actual), `"href":"`+strings.Join([]string{path, query.Encode()}, "?")+`"`)
+}
@@ -1,7 +1,12 @@
 package model
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+
+	"github.com/pmoule/go2hal/hal"
 )
 
 func Last[E any](arr []E) (E, bool) {
@@ -24,3 +29,36 @@ func JSONRemarshal(bytes []byte) ([]byte, error) {
 	}
 	return json.Marshal(ifce)
 }
+
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// AddJSON adds the fields of v to resource as encoding/json marshals them.
+// AddData only maps slices of strings and of structs, so values that hold
+// other slices, such as the retry policy of a trigger, go through AddJSON.
+func AddJSON(resource hal.Resource, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+
+	var fields map[string]any
+	if err := decoder.Decode(&fields); err != nil {
+		return err
+	}
+
+	data := resource.Data()
+	for key, value := range fields {
+		data[key] = value
+	}
+
+	return nil
+}
@@ -30,3 +30,13 @@ func TestJSONRemarshalError(t *testing.T) {
 	_, err := JSONRemarshal([]byte("{"))
 	assert.Error(t, err)
 }
+
+func TestGenerateSecret(t *testing.T) {
+	secret1, err := GenerateSecret()
+	assert.NoError(t, err)
+	assert.Len(t, secret1, 64)
+
+	secret2, err := GenerateSecret()
+	assert.NoError(t, err)
+	assert.NotEqual(t, secret1, secret2)
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	Queued  = "queued"
+	Claimed = "claimed"
+	Done    = "done"
+	Dead    = "dead"
+)
+
+// Job is a firing of a trigger waiting in the queue to be run by a worker.
+// A claimed job whose lock expires, because its worker crashed, is claimed
+// again by another one. Its ID becomes the ID of the execution it produces.
+// Manual jobs are the runs submitted by hand, which run even when the trigger
+// is paused.
+type Job struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();not null" json:"id"`
+	TriggerID   uuid.UUID  `gorm:"type:uuid;index;not null" json:"trigger_id"`
+	State       string     `gorm:"type:varchar(16);index:idx_jobs_state_run_at;default:queued;not null" json:"state"`
+	RunAt       time.Time  `gorm:"index:idx_jobs_state_run_at;not null" json:"run_at"`
+	Attempts    int        `gorm:"type:smallint;default:0;not null" json:"attempts"`
+	MaxAttempts int        `gorm:"type:smallint;default:3;not null" json:"max_attempts"`
+	Manual      bool       `gorm:"default:false;not null" json:"manual"`
+	ClaimedBy   string     `gorm:"type:varchar(255);default:null" json:"claimed_by,omitempty"`
+	LockedUntil *time.Time `gorm:"default:null" json:"locked_until,omitempty"`
+	LastError   string     `gorm:"type:text;default:null" json:"last_error,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime;not null" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime;not null" json:"updated_at"`
+}
+
+type JobCollection []*Job
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/pmoule/go2hal/hal"
+)
+
+// Leader tells which replica holds the leadership, as seen by the replica
+// identified by Identity.
+type Leader struct {
+	Identity string     `json:"identity"`
+	Leader   string     `json:"leader"`
+	IsLeader bool       `json:"is_leader"`
+	Since    *time.Time `json:"since,omitempty"`
+}
+
+func (l *Leader) ToHAL(selfHref string) (root hal.Resource) {
+	root = hal.NewResourceObject()
+	root.AddData(l)
+
+	selfRel := hal.NewSelfLinkRelation()
+	selfLink := &hal.LinkObject{Href: selfHref}
+	selfRel.SetLink(selfLink)
+	root.AddLink(selfRel)
+
+	return
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thanhpk/randstr"
+)
+
+func TestLeaderHAL(t *testing.T) {
+	now := time.Now()
+	url := "/" + randstr.String(16)
+	identity := randstr.String(16)
+
+	leader := Leader{
+		Identity: identity,
+		Leader:   identity,
+		IsLeader: true,
+		Since:    &now,
+	}
+
+	type Self struct {
+		Href string `json:"href"`
+	}
+
+	type Links struct {
+		Self Self `json:"self"`
+	}
+
+	type HAL struct {
+		Links    Links     `json:"_links"`
+		Identity string    `json:"identity"`
+		Leader   string    `json:"leader"`
+		IsLeader bool      `json:"is_leader"`
+		Since    time.Time `json:"since"`
+	}
+
+	expected, _ := json.Marshal(HAL{
+		Links:    Links{Self: Self{Href: url}},
+		Identity: identity,
+		Leader:   identity,
+		IsLeader: true,
+		Since:    now,
+	})
+
+	resource := leader.ToHAL(url)
+	actual, _ := json.Marshal(resource.ToMap().Content)
+
+	expected, _ = JSONRemarshal(expected)
+	actual, _ = JSONRemarshal(actual)
+	assert.Equal(t, string(expected), string(actual))
+}
+
+func TestLeaderHALFollower(t *testing.T) {
+	leader := Leader{Identity: randstr.String(16)}
+
+	actual, _ := json.Marshal(leader.ToHAL("/leader").ToMap().Content)
+
+	assert.Contains(t, string(actual), `"is_leader":false`)
+	assert.NotContains(t, string(actual), `"since"`)
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pmoule/go2hal/hal"
+)
+
+const (
+	Delivering  = "delivering"
+	Delivered   = "delivered"
+	Undelivered = "undelivered"
+)
+
+// Notification is the delivery of an event about an execution to one of the
+// channels of its trigger, Channel is the position of that channel. A channel
+// is notified at most once about each event of an execution.
+type Notification struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();not null" json:"id"`
+	TriggerID   uuid.UUID  `gorm:"type:uuid;index;not null" json:"trigger_id"`
+	ExecutionID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_notifications_event" json:"execution_id"`
+	Channel     int        `gorm:"type:smallint;not null;uniqueIndex:idx_notifications_event" json:"channel"`
+	Kind        string     `gorm:"type:varchar(16);not null" json:"kind"`
+	Event       string     `gorm:"type:varchar(16);not null;uniqueIndex:idx_notifications_event" json:"event"`
+	State       string     `gorm:"type:varchar(16);default:delivering;not null" json:"state"`
+	Attempts    int        `gorm:"type:smallint;default:0;not null" json:"attempts"`
+	LastError   string     `gorm:"type:text;default:null" json:"last_error,omitempty"`
+	DeliveredAt *time.Time `gorm:"default:null" json:"delivered_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime;not null" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime;not null" json:"updated_at"`
+}
+
+type NotificationCollection []*Notification
+
+func (collection NotificationCollection) ToHAL(selfHref string, queryString url.Values) (root hal.Resource) {
+	type StateOnly struct {
+		Event string `json:"event"`
+		State string `json:"state"`
+	}
+
+	type Result struct {
+		Count   int                    `json:"count"`
+		Results NotificationCollection `json:"results"`
+	}
+
+	root = hal.NewResourceObject()
+
+	selfRel := hal.NewSelfLinkRelation()
+	selfRel.SetLink(&hal.LinkObject{Href: selfHref})
+	root.AddLink(selfRel)
+
+	el, hasLast := Last(collection)
+	if hasLast {
+		after, err := el.CreatedAt.MarshalText()
+		if NoError(err) {
+			queryString.Set(After, string(after))
+
+			nextRel, _ := hal.NewLinkRelation(NextRelation)
+			nextLink := &hal.LinkObject{Href: strings.Join([]string{selfHref, queryString.Encode()}, "?")}
+			nextRel.SetLink(nextLink)
+			root.AddLink(nextRel)
+		}
+	}
+
+	var embedded []hal.Resource
+
+	for _, notification := range collection {
+		executionHref, _ := url.JoinPath(selfHref, "..", "executions", notification.ExecutionID.String())
+		executionLink, _ := hal.NewLinkObject(executionHref)
+
+		executionRel, _ := hal.NewLinkRelation("execution")
+		executionRel.SetLink(executionLink)
+
+		resource := hal.NewResourceObject()
+		resource.AddLink(executionRel)
+		resource.AddData(StateOnly{notification.Event, notification.State})
+
+		embedded = append(embedded, resource)
+	}
+
+	notifications, _ := hal.NewResourceRelation("notifications")
+	notifications.SetResources(embedded)
+	root.AddResource(notifications)
+	root.AddData(Result{len(collection), collection})
+
+	return
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultipleNotificationsHAL(t *testing.T) {
+	now := time.Now()
+	triggerID := uuid.New()
+	executionID := uuid.New()
+	path := "/triggers/" + triggerID.String() + "/notifications"
+
+	n := Notification{
+		ID:          uuid.New(),
+		TriggerID:   triggerID,
+		ExecutionID: executionID,
+		Kind:        Webhook,
+		Event:       OnFailure,
+		State:       Delivered,
+		Attempts:    1,
+		DeliveredAt: &now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	resource := NotificationCollection{&n}.ToHAL(path, url.Values{})
+	namedMap := resource.ToMap()
+	actual, _ := json.Marshal(namedMap.Content)
+
+	after, _ := n.CreatedAt.MarshalText()
+	query := url.Values{}
+	query.Add(After, string(after))
+
+	assert.Contains(t, string(actual), `"count":1`)
+	assert.Contains(t, string(actual), `"event":"failure","state":"delivered"`)
+	assert.Contains(t, string(actual), `"href":"/triggers/`+triggerID.String()+"/executions/"+executionID.String()+`"`)
+	assert.Contains(t, string(actual), `"href":"`+strings.Join([]string{path, query.Encode()}, "?")+`"`)
+}
+
+func TestChannelEvent(t *testing.T) {
+	failures := Channel{On: []string{OnFailure}}
+	assert.Equal(t, OnFailure, failures.Event(true, false, 1))
+	assert.Equal(t, OnFailure, failures.Event(true, false, 5))
+	assert.Empty(t, failures.Event(false, true, 0))
+
+	recoveries := Channel{On: []string{OnRecovery}}
+	assert.Empty(t, recoveries.Event(true, false, 1))
+	assert.Equal(t, OnRecovery, recoveries.Event(false, true, 0))
+	assert.Empty(t, recoveries.Event(false, false, 0))
+
+	streaks := Channel{On: []string{OnFailure, OnConsecutive}, Consecutive: 3}
+	assert.Equal(t, OnFailure, streaks.Event(true, false, 2))
+	assert.Equal(t, OnConsecutive, streaks.Event(true, false, 3))
+	assert.Equal(t, OnFailure, streaks.Event(true, false, 4))
+}
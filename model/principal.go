@@ -0,0 +1,67 @@
+package model
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	keySubject   = "key:"
+	tokenSubject = "oidc:"
+)
+
+// Principal is who a request is made on behalf of, and what it is allowed to
+// do. Without a tenant, it is not restricted to the entities of any tenant.
+// Subject tells API keys and the users of the OIDC provider apart, see
+// KeySubject and TokenSubject, so roles bound to one never go to the other.
+type Principal struct {
+	Subject string
+	Scopes  []string
+	Roles   []string
+	Tenant  *uuid.UUID
+}
+
+// Allows reports whether the principal has scope, either directly or through
+// one of its roles. Admins have every scope, but the principals of tenants are
+// never admins, whatever their roles.
+func (p *Principal) Allows(scope string) bool {
+	scopes := slices.Clone(p.Scopes)
+	for _, role := range p.Roles {
+		scopes = append(scopes, Roles[role]...)
+	}
+
+	if slices.Contains(scopes, scope) && scope != Admin {
+		return true
+	}
+
+	return slices.Contains(scopes, Admin) && p.Tenant == nil
+}
+
+// KeySubject returns the subject of the principal of the API key id.
+func KeySubject(id uuid.UUID) string {
+	return keySubject + id.String()
+}
+
+// TokenSubject returns the subject of the principal of a JWT, whose sub claim
+// is only unique to its issuer.
+func TokenSubject(issuer, subject string) string {
+	return tokenSubject + issuer + "|" + subject
+}
+
+// ValidSubject reports whether subject is either a KeySubject or a
+// TokenSubject.
+func ValidSubject(subject string) bool {
+	if id, ok := strings.CutPrefix(subject, keySubject); ok {
+		_, err := uuid.Parse(id)
+		return err == nil
+	}
+
+	if token, ok := strings.CutPrefix(subject, tokenSubject); ok {
+		issuer, sub, ok := strings.Cut(token, "|")
+		return ok && issuer != "" && sub != ""
+	}
+
+	return false
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/pmoule/go2hal/hal"
+)
+
+// LastReconciliation is the ID of the only reconciliation kept, the last one
+// to finish.
+const LastReconciliation = 1
+
+// Reconciliation is the outcome of comparing the triggers table with the
+// cluster, identifying triggers and namespaces by their IDs. It is kept in
+// the database, so every replica can tell what the leader last did.
+type Reconciliation struct {
+	ID         int       `gorm:"primaryKey;autoIncrement:false" json:"-"`
+	StartedAt  time.Time `gorm:"not null" json:"started_at"`
+	FinishedAt time.Time `gorm:"not null" json:"finished_at"`
+	Checked    int       `gorm:"type:int;not null" json:"checked"`
+	Created    []string  `gorm:"type:jsonb;serializer:json" json:"created"`
+	Updated    []string  `gorm:"type:jsonb;serializer:json" json:"updated"`
+	Removed    []string  `gorm:"type:jsonb;serializer:json" json:"removed"`
+	Errors     []string  `gorm:"type:jsonb;serializer:json" json:"errors"`
+}
+
+func (r *Reconciliation) ToHAL(selfHref string) (root hal.Resource) {
+	root = hal.NewResourceObject()
+	root.AddData(r)
+
+	selfRel := hal.NewSelfLinkRelation()
+	selfLink := &hal.LinkObject{Href: selfHref}
+	selfRel.SetLink(selfLink)
+	root.AddLink(selfRel)
+
+	return
+}
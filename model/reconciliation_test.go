@@ -0,0 +1,61 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/thanhpk/randstr"
+)
+
+func TestReconciliationHAL(t *testing.T) {
+	now := time.Now()
+	url := "/" + randstr.String(16)
+	created := uuid.NewString()
+
+	reconciliation := Reconciliation{
+		StartedAt:  now,
+		FinishedAt: now,
+		Checked:    1,
+		Created:    []string{created},
+	}
+
+	type Self struct {
+		Href string `json:"href"`
+	}
+
+	type Links struct {
+		Self Self `json:"self"`
+	}
+
+	type HAL struct {
+		Links      Links     `json:"_links"`
+		StartedAt  time.Time `json:"started_at"`
+		FinishedAt time.Time `json:"finished_at"`
+		Checked    int       `json:"checked"`
+		Created    []string  `json:"created"`
+		Updated    []string  `json:"updated"`
+		Removed    []string  `json:"removed"`
+		Errors     []string  `json:"errors"`
+	}
+
+	expected, _ := json.Marshal(HAL{
+		Links:      Links{Self: Self{Href: url}},
+		StartedAt:  now,
+		FinishedAt: now,
+		Checked:    1,
+		Created:    []string{created},
+		Updated:    []string{},
+		Removed:    []string{},
+		Errors:     []string{},
+	})
+
+	resource := reconciliation.ToHAL(url)
+	actual, _ := json.Marshal(resource.ToMap().Content)
+
+	expected, _ = JSONRemarshal(expected)
+	actual, _ = JSONRemarshal(actual)
+	assert.Equal(t, string(expected), string(actual))
+}
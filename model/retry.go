@@ -0,0 +1,99 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	Fixed       = "fixed"
+	Exponential = "exponential"
+)
+
+// DefaultRetryPolicy is used by triggers without a policy. It retries the
+// responses that curl --retry considers transient, waiting one second and
+// doubling the wait on every attempt.
+var DefaultRetryPolicy = RetryPolicy{
+	Strategy:  Exponential,
+	BaseDelay: 1,
+	MaxDelay:  60,
+	Statuses:  []int{408, 429, 500, 502, 503, 504},
+}
+
+// RetryPolicy says how long to wait between the Retry attempts of a trigger
+// and which responses deserve another attempt. Delays are in seconds and the
+// zero value of a field means its default. Jitter is the fraction of each
+// delay that is randomly taken off it, which the Argo backend cannot do.
+type RetryPolicy struct {
+	Strategy  string  `json:"strategy,omitempty" validate:"omitempty,oneof=fixed exponential"`
+	BaseDelay int     `json:"base_delay,omitempty" validate:"gte=0,lte=3600"`
+	MaxDelay  int     `json:"max_delay,omitempty" validate:"gte=0,lte=3600"`
+	Jitter    float64 `json:"jitter,omitempty" validate:"gte=0,lte=1"`
+	Statuses  []int   `json:"statuses,omitempty" validate:"max=32,dive,gte=100,lte=599"`
+}
+
+// Policy returns the retry policy of the trigger with its defaults applied.
+func (t *Trigger) Policy() RetryPolicy {
+	policy := DefaultRetryPolicy
+	if t.RetryPolicy == nil {
+		return policy
+	}
+
+	if t.RetryPolicy.Strategy != "" {
+		policy.Strategy = t.RetryPolicy.Strategy
+	}
+
+	if t.RetryPolicy.BaseDelay != 0 {
+		policy.BaseDelay = t.RetryPolicy.BaseDelay
+	}
+
+	if t.RetryPolicy.MaxDelay != 0 {
+		policy.MaxDelay = t.RetryPolicy.MaxDelay
+	}
+
+	if policy.MaxDelay < policy.BaseDelay {
+		policy.MaxDelay = policy.BaseDelay
+	}
+
+	if len(t.RetryPolicy.Statuses) != 0 {
+		policy.Statuses = t.RetryPolicy.Statuses
+	}
+
+	policy.Jitter = t.RetryPolicy.Jitter
+
+	return policy
+}
+
+// Factor is how much the delay grows after every attempt.
+func (p RetryPolicy) Factor() int {
+	if p.Strategy == Fixed {
+		return 1
+	}
+
+	return 2
+}
+
+// Delay returns how long to wait after the given attempt, counting from zero,
+// without jitter. It never exceeds MaxDelay.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := time.Duration(p.BaseDelay) * time.Second
+	limit := time.Duration(p.MaxDelay) * time.Second
+
+	for i := 0; i < attempt && delay < limit; i++ {
+		delay *= time.Duration(p.Factor())
+	}
+
+	return min(delay, limit)
+}
+
+// Jittered takes up to Jitter of delay off it, r is a random number in
+// [0, 1).
+func (p RetryPolicy) Jittered(delay time.Duration, r float64) time.Duration {
+	return delay - time.Duration(float64(delay)*p.Jitter*r)
+}
+
+// Retryable reports whether a response with status deserves another attempt,
+// zero means no response was received, which always does.
+func (p RetryPolicy) Retryable(status int) bool {
+	return status == 0 || slices.Contains(p.Statuses, status)
+}
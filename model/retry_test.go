@@ -0,0 +1,51 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyDefault(t *testing.T) {
+	trigger := Trigger{}
+
+	assert.Equal(t, DefaultRetryPolicy, trigger.Policy())
+}
+
+func TestPolicyOverrides(t *testing.T) {
+	trigger := Trigger{RetryPolicy: &RetryPolicy{Strategy: Fixed, BaseDelay: 90, Jitter: 0.5}}
+
+	policy := trigger.Policy()
+	assert.Equal(t, Fixed, policy.Strategy)
+	assert.Equal(t, 90, policy.BaseDelay)
+	assert.Equal(t, 90, policy.MaxDelay)
+	assert.Equal(t, 0.5, policy.Jitter)
+	assert.Equal(t, DefaultRetryPolicy.Statuses, policy.Statuses)
+}
+
+func TestPolicyDelay(t *testing.T) {
+	exponential := RetryPolicy{Strategy: Exponential, BaseDelay: 2, MaxDelay: 10}
+	fixed := RetryPolicy{Strategy: Fixed, BaseDelay: 2, MaxDelay: 10}
+
+	for attempt, expected := range []time.Duration{2, 4, 8, 10, 10} {
+		assert.Equal(t, expected*time.Second, exponential.Delay(attempt))
+		assert.Equal(t, 2*time.Second, fixed.Delay(attempt))
+	}
+}
+
+func TestPolicyJittered(t *testing.T) {
+	policy := RetryPolicy{Jitter: 0.5}
+
+	assert.Equal(t, 10*time.Second, policy.Jittered(10*time.Second, 0))
+	assert.Equal(t, 7500*time.Millisecond, policy.Jittered(10*time.Second, 0.5))
+	assert.Equal(t, 10*time.Second, RetryPolicy{}.Jittered(10*time.Second, 0.9))
+}
+
+func TestPolicyRetryable(t *testing.T) {
+	policy := RetryPolicy{Statuses: []int{429}}
+
+	assert.True(t, policy.Retryable(0))
+	assert.True(t, policy.Retryable(429))
+	assert.False(t, policy.Retryable(500))
+}
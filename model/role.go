@@ -0,0 +1,117 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pmoule/go2hal/hal"
+)
+
+const (
+	Viewer   = "viewer"
+	Operator = "operator"
+	Editor   = "editor"
+)
+
+// Roles are the scopes granted by each role. Operators run, pause and resume
+// triggers, but cannot change or delete them. Editors also read the audit log.
+// The admin role is named after the admin scope.
+var Roles = map[string][]string{
+	Viewer:   {TriggersRead, WebhooksRead},
+	Operator: {TriggersRead, WebhooksRead, TriggersRun, TriggersOperate},
+	Editor:   {TriggersRead, WebhooksRead, TriggersRun, TriggersOperate, TriggersWrite, WebhooksWrite, AuditRead},
+	Admin:    {Admin},
+}
+
+// RoleBinding assigns a role to a principal, by its subject, on top of the
+// scopes of its API key or token. The subject is either a KeySubject or a
+// TokenSubject.
+type RoleBinding struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();not null" json:"id"`
+	Subject   string    `gorm:"type:varchar(255);uniqueIndex:idx_role_bindings_subject_role;not null" json:"subject" validate:"required,max=255,subject"`
+	Role      string    `gorm:"type:varchar(16);uniqueIndex:idx_role_bindings_subject_role;not null" json:"role" validate:"oneof=viewer operator editor admin"`
+	CreatedAt time.Time `gorm:"autoCreateTime;not null" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;not null" json:"updated_at"`
+}
+
+type RoleBindingCollection []*RoleBinding
+
+func (binding *RoleBinding) AuditResource() (string, uuid.UUID, *uuid.UUID) {
+	return ResourceRoleBinding, binding.ID, nil
+}
+
+func (binding *RoleBinding) AuditSnapshot() any {
+	return binding
+}
+
+func (binding *RoleBinding) AuditRedacted() []string {
+	return nil
+}
+
+func (binding *RoleBinding) ToHAL(selfHref string) (root hal.Resource) {
+	root = hal.NewResourceObject()
+	root.AddData(binding)
+
+	selfRel := hal.NewSelfLinkRelation()
+	selfLink := &hal.LinkObject{Href: selfHref}
+	selfRel.SetLink(selfLink)
+	root.AddLink(selfRel)
+
+	return
+}
+
+func (collection RoleBindingCollection) ToHAL(selfHref string, queryString url.Values) (root hal.Resource) {
+	type SubjectRole struct {
+		Subject string `json:"subject"`
+		Role    string `json:"role"`
+	}
+
+	type Result struct {
+		Count   int                   `json:"count"`
+		Results RoleBindingCollection `json:"results"`
+	}
+
+	root = hal.NewResourceObject()
+
+	selfRel := hal.NewSelfLinkRelation()
+	selfRel.SetLink(&hal.LinkObject{Href: selfHref})
+	root.AddLink(selfRel)
+
+	el, hasLast := Last(collection)
+	if hasLast {
+		after, err := el.CreatedAt.MarshalText()
+		if NoError(err) {
+			queryString.Set(After, string(after))
+
+			nextRel, _ := hal.NewLinkRelation(NextRelation)
+			nextLink := &hal.LinkObject{Href: strings.Join([]string{selfHref, queryString.Encode()}, "?")}
+			nextRel.SetLink(nextLink)
+			root.AddLink(nextRel)
+		}
+	}
+
+	var embedded []hal.Resource
+
+	for _, binding := range collection {
+		selfLink, _ := hal.NewLinkObject(fmt.Sprintf("%s/%v", selfHref, binding.ID))
+
+		selfRel, _ := hal.NewLinkRelation("self")
+		selfRel.SetLink(selfLink)
+
+		resource := hal.NewResourceObject()
+		resource.AddLink(selfRel)
+		resource.AddData(SubjectRole{binding.Subject, binding.Role})
+
+		embedded = append(embedded, resource)
+	}
+
+	roles, _ := hal.NewResourceRelation("roles")
+	roles.SetResources(embedded)
+	root.AddResource(roles)
+	_ = AddJSON(root, Result{len(collection), collection})
+
+	return
+}
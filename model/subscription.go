@@ -0,0 +1,146 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pmoule/go2hal/hal"
+)
+
+const (
+	TriggerCreated  = "trigger.created"
+	TriggerUpdated  = "trigger.updated"
+	TriggerPaused   = "trigger.paused"
+	TriggerResumed  = "trigger.resumed"
+	TriggerDeleted  = "trigger.deleted"
+	TriggerRestored = "trigger.restored"
+)
+
+// Subscription is a webhook told about the lifecycle events of triggers. Its
+// deliveries are signed with Secret the same way as the requests of triggers.
+// A subscription of a tenant is only told about the triggers of the tenant.
+type Subscription struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();not null" json:"id"`
+	TenantID  *uuid.UUID `gorm:"type:uuid;index" json:"tenant_id,omitempty"`
+	Tenant    *Tenant    `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	URL       string     `gorm:"type:varchar(2048);not null" json:"url" validate:"required,url,max=2048"`
+	Events    []string   `gorm:"type:jsonb;serializer:json;not null" json:"events" validate:"min=1,max=6,unique,dive,oneof=trigger.created trigger.updated trigger.paused trigger.resumed trigger.deleted trigger.restored"`
+	Enabled   *bool      `gorm:"type:bool;default:true;not null" json:"enabled"`
+	Secret    string     `gorm:"type:text;not null" json:"-"`
+	CreatedAt time.Time  `gorm:"autoCreateTime;not null" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"autoUpdateTime;not null" json:"updated_at"`
+}
+
+type SubscriptionCollection []*Subscription
+
+// SubscriptionSecret reveals the signing secret of a subscription, which only
+// happens in the response of the request that creates it.
+type SubscriptionSecret struct {
+	Secret string `json:"secret"`
+}
+
+// Subscribes reports whether the subscription is told about events of type.
+func (s *Subscription) Subscribes(event string) bool {
+	return slices.Contains(s.Events, event)
+}
+
+// IsEnabled reports whether the subscription should be told about events, an
+// unset Enabled field means the database default, which is enabled.
+func (s *Subscription) IsEnabled() bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+func (s *Subscription) AuditResource() (string, uuid.UUID, *uuid.UUID) {
+	return ResourceWebhook, s.ID, s.TenantID
+}
+
+// AuditSnapshot keeps only the origin of the URL, whose path and query may
+// hold a token.
+func (s *Subscription) AuditSnapshot() any {
+	snapshot := *s
+
+	if u, err := url.Parse(s.URL); err == nil {
+		snapshot.URL = (&url.URL{Scheme: u.Scheme, Host: u.Host}).String()
+	} else {
+		snapshot.URL = ""
+	}
+
+	return &snapshot
+}
+
+func (s *Subscription) AuditRedacted() []string {
+	return []string{"url"}
+}
+
+func (s *Subscription) ToHAL(selfHref string) (root hal.Resource) {
+	root = hal.NewResourceObject()
+	_ = AddJSON(root, s)
+
+	selfRel := hal.NewSelfLinkRelation()
+	selfLink := &hal.LinkObject{Href: selfHref}
+	selfRel.SetLink(selfLink)
+	root.AddLink(selfRel)
+
+	deliveriesHref, _ := url.JoinPath(selfHref, "deliveries")
+	deliveriesRel, _ := hal.NewLinkRelation("deliveries")
+	deliveriesRel.SetLink(&hal.LinkObject{Href: deliveriesHref})
+	root.AddLink(deliveriesRel)
+
+	return
+}
+
+func (collection SubscriptionCollection) ToHAL(selfHref string, queryString url.Values) (root hal.Resource) {
+	type URLOnly struct {
+		URL string `json:"url"`
+	}
+
+	type Result struct {
+		Count   int                    `json:"count"`
+		Results SubscriptionCollection `json:"results"`
+	}
+
+	root = hal.NewResourceObject()
+
+	selfRel := hal.NewSelfLinkRelation()
+	selfRel.SetLink(&hal.LinkObject{Href: selfHref})
+	root.AddLink(selfRel)
+
+	el, hasLast := Last(collection)
+	if hasLast {
+		after, err := el.CreatedAt.MarshalText()
+		if NoError(err) {
+			queryString.Set(After, string(after))
+
+			nextRel, _ := hal.NewLinkRelation(NextRelation)
+			nextLink := &hal.LinkObject{Href: strings.Join([]string{selfHref, queryString.Encode()}, "?")}
+			nextRel.SetLink(nextLink)
+			root.AddLink(nextRel)
+		}
+	}
+
+	var embedded []hal.Resource
+
+	for _, subscription := range collection {
+		selfLink, _ := hal.NewLinkObject(fmt.Sprintf("%s/%v", selfHref, subscription.ID))
+
+		selfRel, _ := hal.NewLinkRelation("self")
+		selfRel.SetLink(selfLink)
+
+		resource := hal.NewResourceObject()
+		resource.AddLink(selfRel)
+		resource.AddData(URLOnly{subscription.URL})
+
+		embedded = append(embedded, resource)
+	}
+
+	webhooks, _ := hal.NewResourceRelation("webhooks")
+	webhooks.SetResources(embedded)
+	root.AddResource(webhooks)
+	_ = AddJSON(root, Result{len(collection), collection})
+
+	return
+}
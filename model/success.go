@@ -0,0 +1,267 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SuccessCriteria decides whether the response to a trigger is a success.
+// Statuses are codes such as "204", classes such as "2xx" or inclusive ranges
+// such as "200-299". Without statuses, only the Success code of the trigger
+// is a success.
+type SuccessCriteria struct {
+	Statuses []string       `json:"statuses,omitempty" validate:"max=32,dive,statusrange"`
+	Body     *BodyAssertion `json:"body,omitempty"`
+}
+
+// BodyAssertion is checked against the response body once its status is a
+// success, every assertion that is set must hold. Matches is a POSIX extended
+// regular expression and JSONPath selects the value that must be equal to the
+// JSON in Equals, for instance $.items[0].state.
+type BodyAssertion struct {
+	Contains string          `json:"contains,omitempty" validate:"max=1024"`
+	Matches  string          `json:"matches,omitempty" validate:"max=1024,omitempty,posixregexp"`
+	JSONPath string          `json:"json_path,omitempty" validate:"required_with=Equals,max=256,omitempty,jsonpath"`
+	Equals   json.RawMessage `json:"equals,omitempty" validate:"required_with=JSONPath,max=1024"`
+}
+
+// StatusRange is an inclusive range of HTTP status codes.
+type StatusRange struct {
+	From, To int
+}
+
+func (r StatusRange) String() string {
+	return fmt.Sprintf("%d-%d", r.From, r.To)
+}
+
+// ParseStatusRange parses a status code, class or range of SuccessCriteria.
+func ParseStatusRange(s string) (StatusRange, error) {
+	invalid := fmt.Errorf("invalid status range %q", s)
+
+	if len(s) == 3 && strings.HasSuffix(strings.ToLower(s), "xx") {
+		class, err := strconv.Atoi(s[:1])
+		if err != nil || class < 1 || class > 5 {
+			return StatusRange{}, invalid
+		}
+
+		return StatusRange{class * 100, class*100 + 99}, nil
+	}
+
+	from, to, found := strings.Cut(s, "-")
+	if !found {
+		to = from
+	}
+
+	r := StatusRange{}
+
+	var err error
+	if r.From, err = strconv.Atoi(from); err != nil {
+		return StatusRange{}, invalid
+	}
+
+	if r.To, err = strconv.Atoi(to); err != nil {
+		return StatusRange{}, invalid
+	}
+
+	if r.From < 100 || r.To > 599 || r.From > r.To {
+		return StatusRange{}, invalid
+	}
+
+	return r, nil
+}
+
+// SuccessRanges returns the status codes that are a success for the trigger.
+func (t *Trigger) SuccessRanges() []StatusRange {
+	if t.SuccessCriteria == nil || len(t.SuccessCriteria.Statuses) == 0 {
+		return []StatusRange{{t.Success, t.Success}}
+	}
+
+	ranges := make([]StatusRange, 0, len(t.SuccessCriteria.Statuses))
+	for _, s := range t.SuccessCriteria.Statuses {
+		if r, err := ParseStatusRange(s); err == nil {
+			ranges = append(ranges, r)
+		}
+	}
+
+	return ranges
+}
+
+// Accepts reports whether status is a success for the trigger.
+func (t *Trigger) Accepts(status int) bool {
+	for _, r := range t.SuccessRanges() {
+		if status >= r.From && status <= r.To {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Assert checks the body assertion of the trigger against body and returns
+// why it does not hold.
+func (t *Trigger) Assert(body []byte) error {
+	if t.SuccessCriteria == nil || t.SuccessCriteria.Body == nil {
+		return nil
+	}
+
+	assertion := t.SuccessCriteria.Body
+
+	if assertion.Contains != "" && !bytes.Contains(body, []byte(assertion.Contains)) {
+		return fmt.Errorf("the body does not contain %q", assertion.Contains)
+	}
+
+	if assertion.Matches != "" {
+		re, err := regexp.CompilePOSIX(assertion.Matches)
+		if err != nil {
+			return err
+		}
+
+		if !re.Match(body) {
+			return fmt.Errorf("the body does not match %q", assertion.Matches)
+		}
+	}
+
+	if assertion.JSONPath != "" {
+		path, err := ParseJSONPath(assertion.JSONPath)
+		if err != nil {
+			return err
+		}
+
+		var document, expected any
+		if err := json.Unmarshal(body, &document); err != nil {
+			return fmt.Errorf("the body is not JSON: %w", err)
+		}
+
+		if err := json.Unmarshal(assertion.Equals, &expected); err != nil {
+			return err
+		}
+
+		if actual, ok := path.Select(document); !ok || !reflect.DeepEqual(actual, expected) {
+			return fmt.Errorf("%s is not equal to %s", assertion.JSONPath, assertion.Equals)
+		}
+	}
+
+	return nil
+}
+
+// JSONPath is the subset of JSONPath made of member names and array indexes,
+// as in $.items[0]["display name"]. Each element is a string or an int.
+type JSONPath []any
+
+// ParseJSONPath parses a path such as $.items[0].name.
+func ParseJSONPath(s string) (JSONPath, error) {
+	invalid := fmt.Errorf("invalid JSONPath %q", s)
+
+	if !strings.HasPrefix(s, "$") {
+		return nil, invalid
+	}
+
+	path := JSONPath{}
+
+	for rest := s[1:]; rest != ""; {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[") + 1
+			if end == 0 {
+				end = len(rest)
+			}
+
+			name := rest[1:end]
+			if name == "" {
+				return nil, invalid
+			}
+
+			path = append(path, name)
+			rest = rest[end:]
+
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, invalid
+			}
+
+			segment := rest[1:end]
+			if index, err := strconv.Atoi(segment); err == nil && index >= 0 {
+				path = append(path, index)
+			} else if name, err := unquote(segment); err == nil {
+				path = append(path, name)
+			} else {
+				return nil, invalid
+			}
+
+			rest = rest[end+1:]
+
+		default:
+			return nil, invalid
+		}
+	}
+
+	return path, nil
+}
+
+func unquote(s string) (string, error) {
+	if len(s) < 2 || (s[0] != '\'' && s[0] != '"') || s[len(s)-1] != s[0] {
+		return "", errors.New("not a quoted name")
+	}
+
+	name := s[1 : len(s)-1]
+	if strings.ContainsAny(name, `'"\`) {
+		return "", errors.New("quotes and escapes are not supported")
+	}
+
+	return name, nil
+}
+
+// Select returns the value of the path in a decoded JSON document.
+func (path JSONPath) Select(document any) (any, bool) {
+	value := document
+
+	for _, segment := range path {
+		switch segment := segment.(type) {
+		case string:
+			object, ok := value.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+
+			if value, ok = object[segment]; !ok {
+				return nil, false
+			}
+
+		case int:
+			array, ok := value.([]any)
+			if !ok || segment >= len(array) {
+				return nil, false
+			}
+
+			value = array[segment]
+		}
+	}
+
+	return value, true
+}
+
+// Filter returns the path as a jq filter, which is how the cluster backends
+// evaluate it.
+func (path JSONPath) Filter() string {
+	var b strings.Builder
+
+	b.WriteString(".")
+	for _, segment := range path {
+		switch segment := segment.(type) {
+		case string:
+			name, _ := json.Marshal(segment)
+			b.WriteString("[" + string(name) + "]")
+		case int:
+			b.WriteString("[" + strconv.Itoa(segment) + "]")
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStatusRange(t *testing.T) {
+	for s, expected := range map[string]StatusRange{
+		"204":     {204, 204},
+		"2xx":     {200, 299},
+		"5XX":     {500, 599},
+		"200-299": {200, 299},
+	} {
+		r, err := ParseStatusRange(s)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, r)
+	}
+
+	for _, s := range []string{"", "6xx", "0xx", "x", "99", "600", "299-200", "200-", "2x"} {
+		_, err := ParseStatusRange(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestAccepts(t *testing.T) {
+	trigger := Trigger{Success: 200}
+	assert.True(t, trigger.Accepts(200))
+	assert.False(t, trigger.Accepts(204))
+
+	trigger.SuccessCriteria = &SuccessCriteria{Statuses: []string{"2xx", "304"}}
+	assert.True(t, trigger.Accepts(202))
+	assert.True(t, trigger.Accepts(204))
+	assert.True(t, trigger.Accepts(304))
+	assert.False(t, trigger.Accepts(301))
+	assert.False(t, trigger.Accepts(0))
+}
+
+func TestAssert(t *testing.T) {
+	body := []byte(`{"items": [{"state": "done", "count": 2}], "display name": "ok"}`)
+
+	for _, assertion := range []BodyAssertion{
+		{},
+		{Contains: `"done"`},
+		{Matches: `"count": [0-9]+`},
+		{JSONPath: "$.items[0].state", Equals: json.RawMessage(`"done"`)},
+		{JSONPath: "$.items[0].count", Equals: json.RawMessage(`2`)},
+		{JSONPath: `$["display name"]`, Equals: json.RawMessage(`"ok"`)},
+		{JSONPath: "$.items[0]", Equals: json.RawMessage(`{"count": 2, "state": "done"}`)},
+	} {
+		trigger := Trigger{SuccessCriteria: &SuccessCriteria{Body: &assertion}}
+		assert.NoError(t, trigger.Assert(body), assertion)
+	}
+
+	for _, assertion := range []BodyAssertion{
+		{Contains: "pending"},
+		{Matches: `^"items"`},
+		{JSONPath: "$.items[0].state", Equals: json.RawMessage(`"pending"`)},
+		{JSONPath: "$.items[1].state", Equals: json.RawMessage(`"done"`)},
+		{JSONPath: "$.items.state", Equals: json.RawMessage(`"done"`)},
+		{JSONPath: "$.items[0].count", Equals: json.RawMessage(`"2"`)},
+	} {
+		trigger := Trigger{SuccessCriteria: &SuccessCriteria{Body: &assertion}}
+		assert.Error(t, trigger.Assert(body), assertion)
+	}
+
+	trigger := Trigger{SuccessCriteria: &SuccessCriteria{Body: &BodyAssertion{JSONPath: "$", Equals: json.RawMessage(`null`)}}}
+	assert.Error(t, trigger.Assert([]byte("<html>")))
+}
+
+func TestParseJSONPath(t *testing.T) {
+	for s, expected := range map[string]JSONPath{
+		"$":                   {},
+		"$.items":             {"items"},
+		"$.items[0].state":    {"items", 0, "state"},
+		`$['a.b']["c d"][10]`: {"a.b", "c d", 10},
+	} {
+		path, err := ParseJSONPath(s)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, path)
+	}
+
+	for _, s := range []string{"", "items", "$.", "$..items", "$[", "$[-1]", "$[*]", `$['a\'b']`, "$items"} {
+		_, err := ParseJSONPath(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestJSONPathFilter(t *testing.T) {
+	path, err := ParseJSONPath(`$.items[0]["a\"b"]`)
+	assert.Error(t, err)
+	assert.Nil(t, path)
+
+	path, err = ParseJSONPath(`$.items[0]['display name']`)
+	assert.NoError(t, err)
+	assert.Equal(t, `.["items"][0]["display name"]`, path.Filter())
+}
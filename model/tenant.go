@@ -0,0 +1,210 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pmoule/go2hal/hal"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	MaxTriggers = "max_triggers"
+	MinInterval = "min_interval"
+
+	// runs is how many runs of a schedule are looked at to find how often it
+	// runs at most.
+	runs = 1000
+)
+
+var ErrNeverRuns = errors.New("the schedule never runs")
+
+// Tenant is a project that owns triggers, webhooks and API keys. The requests
+// made with the API keys of a tenant only see what the tenant owns, and are
+// held to its quotas. MinInterval is in seconds.
+type Tenant struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();not null" json:"id"`
+	Name        string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"name" validate:"required,max=64"`
+	MaxTriggers int       `gorm:"type:int;default:100;not null" json:"max_triggers" validate:"gte=1,lte=100000"`
+	MinInterval int       `gorm:"type:int;default:60;not null" json:"min_interval" validate:"gte=60,lte=86400"`
+	CreatedAt   time.Time `gorm:"autoCreateTime;not null" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime;not null" json:"updated_at"`
+}
+
+type TenantCollection []*Tenant
+
+// QuotaError tells which quota of a tenant a request would exceed.
+type QuotaError struct {
+	Quota string
+	Limit int
+	Value int
+}
+
+func (e *QuotaError) Error() string {
+	if e.Quota == MinInterval {
+		return fmt.Sprintf("the schedule runs every %ds, the tenant allows at most one run every %ds", e.Value, e.Limit)
+	}
+
+	return fmt.Sprintf("the tenant allows at most %d triggers", e.Limit)
+}
+
+// CheckTriggers returns a QuotaError when the tenant cannot own count
+// triggers.
+func (tenant *Tenant) CheckTriggers(count int) error {
+	if count > tenant.MaxTriggers {
+		return &QuotaError{Quota: MaxTriggers, Limit: tenant.MaxTriggers, Value: count}
+	}
+
+	return nil
+}
+
+// CheckSchedule returns a QuotaError when schedule, in timezone, runs more
+// often than the tenant allows.
+func (tenant *Tenant) CheckSchedule(schedule, timezone string) error {
+	interval, err := ShortestInterval(schedule, timezone)
+	if err != nil {
+		return err
+	}
+
+	if seconds := int(interval / time.Second); seconds < tenant.MinInterval {
+		return &QuotaError{Quota: MinInterval, Limit: tenant.MinInterval, Value: seconds}
+	}
+
+	return nil
+}
+
+// ShortestInterval returns the shortest time between two runs of a cron
+// schedule in timezone, over its next thousand runs from the start of 2000 and
+// from the day before each change of the UTC offset of timezone that year, so
+// the runs skipped or repeated by daylight saving time are accounted for.
+func ShortestInterval(schedule, timezone string) (time.Duration, error) {
+	s, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return 0, err
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return 0, err
+	}
+
+	var shortest time.Duration
+	for _, start := range transitions(time.Date(2000, 1, 1, 0, 0, 0, 0, location)) {
+		previous := s.Next(start)
+		if previous.IsZero() {
+			return 0, fmt.Errorf("%w: %q", ErrNeverRuns, schedule)
+		}
+
+		for i := 0; i < runs; i++ {
+			next := s.Next(previous)
+			if next.IsZero() {
+				break
+			}
+
+			if interval := next.Sub(previous); shortest == 0 || interval < shortest {
+				shortest = interval
+			}
+
+			previous = next
+		}
+	}
+
+	return shortest, nil
+}
+
+// transitions returns start followed by the start of the day before each
+// change of the UTC offset in the year from start.
+func transitions(start time.Time) []time.Time {
+	starts := []time.Time{start}
+
+	_, offset := start.Zone()
+	for day := start.AddDate(0, 0, 1); day.Before(start.AddDate(1, 0, 0)); day = day.AddDate(0, 0, 1) {
+		if _, o := day.Zone(); o != offset {
+			starts = append(starts, day.AddDate(0, 0, -2))
+			offset = o
+		}
+	}
+
+	return starts
+}
+
+// AuditResource tells no tenant, as tenants are managed by admins.
+func (tenant *Tenant) AuditResource() (string, uuid.UUID, *uuid.UUID) {
+	return ResourceTenant, tenant.ID, nil
+}
+
+func (tenant *Tenant) AuditSnapshot() any {
+	return tenant
+}
+
+func (tenant *Tenant) AuditRedacted() []string {
+	return nil
+}
+
+func (tenant *Tenant) ToHAL(selfHref string) (root hal.Resource) {
+	root = hal.NewResourceObject()
+	root.AddData(tenant)
+
+	selfRel := hal.NewSelfLinkRelation()
+	selfLink := &hal.LinkObject{Href: selfHref}
+	selfRel.SetLink(selfLink)
+	root.AddLink(selfRel)
+
+	return
+}
+
+func (collection TenantCollection) ToHAL(selfHref string, queryString url.Values) (root hal.Resource) {
+	type NameOnly struct {
+		Name string `json:"name"`
+	}
+
+	type Result struct {
+		Count   int              `json:"count"`
+		Results TenantCollection `json:"results"`
+	}
+
+	root = hal.NewResourceObject()
+
+	selfRel := hal.NewSelfLinkRelation()
+	selfRel.SetLink(&hal.LinkObject{Href: selfHref})
+	root.AddLink(selfRel)
+
+	el, hasLast := Last(collection)
+	if hasLast {
+		after, err := el.CreatedAt.MarshalText()
+		if NoError(err) {
+			queryString.Set(After, string(after))
+
+			nextRel, _ := hal.NewLinkRelation(NextRelation)
+			nextLink := &hal.LinkObject{Href: strings.Join([]string{selfHref, queryString.Encode()}, "?")}
+			nextRel.SetLink(nextLink)
+			root.AddLink(nextRel)
+		}
+	}
+
+	var embedded []hal.Resource
+
+	for _, tenant := range collection {
+		selfLink, _ := hal.NewLinkObject(fmt.Sprintf("%s/%v", selfHref, tenant.ID))
+
+		selfRel, _ := hal.NewLinkRelation("self")
+		selfRel.SetLink(selfLink)
+
+		resource := hal.NewResourceObject()
+		resource.AddLink(selfRel)
+		resource.AddData(NameOnly{tenant.Name})
+
+		embedded = append(embedded, resource)
+	}
+
+	tenants, _ := hal.NewResourceRelation("tenants")
+	tenants.SetResources(embedded)
+	root.AddResource(tenants)
+	_ = AddJSON(root, Result{len(collection), collection})
+
+	return
+}
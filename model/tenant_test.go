@@ -0,0 +1,65 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShortestInterval(t *testing.T) {
+	for schedule, expected := range map[string]time.Duration{
+		"* * * * *":    time.Minute,
+		"*/5 * * * *":  5 * time.Minute,
+		"0,1 * * * *":  time.Minute,
+		"0 */6 * * *":  6 * time.Hour,
+		"30 9 * * 1-5": 24 * time.Hour,
+	} {
+		interval, err := ShortestInterval(schedule, "UTC")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, interval, schedule)
+	}
+
+	_, err := ShortestInterval("not a schedule", "UTC")
+	assert.Error(t, err)
+
+	_, err = ShortestInterval("* * * * *", "Mars/Olympus_Mons")
+	assert.Error(t, err)
+}
+
+func TestShortestIntervalDaylightSaving(t *testing.T) {
+	// Every other hour, but the clocks of New York skip from 2 to 3 in the
+	// spring, so the run at 1 and the one at 3 are an hour apart.
+	interval, err := ShortestInterval("0 1-23/2 * * *", "America/New_York")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, interval)
+
+	interval, err = ShortestInterval("0 1-23/2 * * *", "UTC")
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Hour, interval)
+}
+
+func TestCheckSchedule(t *testing.T) {
+	tenant := Tenant{MaxTriggers: 1, MinInterval: 300}
+
+	assert.NoError(t, tenant.CheckSchedule("*/5 * * * *", "UTC"))
+
+	var quota *QuotaError
+	err := tenant.CheckSchedule("*/2 * * * *", "UTC")
+	assert.True(t, errors.As(err, &quota))
+	assert.Equal(t, MinInterval, quota.Quota)
+	assert.Equal(t, 120, quota.Value)
+}
+
+func TestCheckTriggers(t *testing.T) {
+	tenant := Tenant{MaxTriggers: 1, MinInterval: 60}
+
+	assert.NoError(t, tenant.CheckTriggers(1))
+
+	var quota *QuotaError
+	err := tenant.CheckTriggers(2)
+	assert.True(t, errors.As(err, &quota))
+	assert.Equal(t, MaxTriggers, quota.Quota)
+	assert.Equal(t, 1, quota.Limit)
+}
@@ -18,27 +18,93 @@ const (
 )
 
 type Trigger struct {
-	ID       uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();not null" json:"id"`
-	Name     string    `gorm:"type:varchar(32);not null" json:"name"`
-	Schedule string    `gorm:"type:varchar(32);not null" json:"schedule" validate:"cron"`
-	Timezone string    `gorm:"type:varchar(64);default:UTC;not null" json:"timezone" validate:"timezone"`
-	Url      string    `gorm:"type:varchar(2048);not null" json:"url"`
-	Method   string    `gorm:"type:varchar(8);not null" json:"method"`
-	Success  int       `gorm:"type:smallint;default:200;not null" json:"success"`
-	Timeout  int       `gorm:"type:smallint;default:60;not null" json:"timeout" validate:"gte=1,lte=300"`
-	Retry    int       `gorm:"type:smallint;default:3;not null" json:"retry" validate:"gte=1,lte=10"`
-	// Enabled  bool      `gorm:"type:bool;default:false" json:"enabled"`
-	// Secret    string         `gorm:"type:text;default:null" json:"secret,omitempty"`
-	CreatedAt time.Time      `gorm:"autoCreateTime;not null" json:"created_at"`
-	UpdatedAt time.Time      `gorm:"autoUpdateTime;not null" json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index,->" json:"-"`
+	ID              uuid.UUID         `gorm:"type:uuid;default:uuid_generate_v4();not null" json:"id"`
+	TenantID        *uuid.UUID        `gorm:"type:uuid;index" json:"tenant_id,omitempty"`
+	Tenant          *Tenant           `gorm:"constraint:OnDelete:RESTRICT" json:"-"`
+	Name            string            `gorm:"type:varchar(32);not null" json:"name"`
+	Schedule        string            `gorm:"type:varchar(32);not null" json:"schedule" validate:"cron"`
+	Timezone        string            `gorm:"type:varchar(64);default:UTC;not null" json:"timezone" validate:"timezone"`
+	Url             string            `gorm:"type:varchar(2048);not null" json:"url"`
+	Method          string            `gorm:"type:varchar(8);not null" json:"method"`
+	Headers         map[string]string `gorm:"type:jsonb;serializer:json;default:null" json:"headers,omitempty" validate:"max=32,dive,keys,httpheader,endkeys,httpheadervalue"`
+	Body            string            `gorm:"type:text;default:null" json:"body,omitempty" validate:"max=65536"`
+	ContentType     string            `gorm:"type:varchar(255);default:null" json:"content_type,omitempty" validate:"omitempty,mediatype"`
+	Success         int               `gorm:"type:smallint;default:200;not null" json:"success"`
+	SuccessCriteria *SuccessCriteria  `gorm:"type:jsonb;serializer:json;default:null" json:"success_criteria,omitempty"`
+	Timeout         int               `gorm:"type:smallint;default:60;not null" json:"timeout" validate:"gte=1,lte=300"`
+	Retry           int               `gorm:"type:smallint;default:3;not null" json:"retry" validate:"gte=1,lte=10"`
+	RetryPolicy     *RetryPolicy      `gorm:"type:jsonb;serializer:json;default:null" json:"retry_policy,omitempty"`
+	Channels        []Channel         `gorm:"type:jsonb;serializer:json;default:null" json:"channels,omitempty" validate:"max=8,dive"`
+	Enabled         *bool             `gorm:"type:bool;default:true;not null" json:"enabled"`
+	Secret          string            `gorm:"type:text;default:null" json:"-"`
+	CreatedAt       time.Time         `gorm:"autoCreateTime;not null" json:"created_at"`
+	UpdatedAt       time.Time         `gorm:"autoUpdateTime;not null" json:"updated_at"`
+	DeletedAt       gorm.DeletedAt    `gorm:"index" json:"-"`
 }
 
 type TriggerCollection []*Trigger
 
+// TriggerSecret reveals the signing secret of a trigger, which only happens
+// in the responses of the requests that create or rotate it.
+type TriggerSecret struct {
+	Secret string `json:"secret"`
+}
+
+// TriggerSnapshot is the part of a trigger that can be shared with whoever is
+// told about it. The request the trigger makes and its channels are left out,
+// as their URLs, headers and body may hold credentials.
+type TriggerSnapshot struct {
+	ID        uuid.UUID  `json:"id"`
+	TenantID  *uuid.UUID `json:"tenant_id,omitempty"`
+	Name      string     `json:"name"`
+	Schedule  string     `json:"schedule"`
+	Timezone  string     `json:"timezone"`
+	Enabled   *bool      `json:"enabled"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Snapshot returns the shareable part of the trigger, nil for a nil trigger.
+func (t *Trigger) Snapshot() *TriggerSnapshot {
+	if t == nil {
+		return nil
+	}
+
+	return &TriggerSnapshot{
+		ID:        t.ID,
+		TenantID:  t.TenantID,
+		Name:      t.Name,
+		Schedule:  t.Schedule,
+		Timezone:  t.Timezone,
+		Enabled:   t.Enabled,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+}
+
+// IsEnabled reports whether the trigger should be scheduled, an unset
+// Enabled field means the database default, which is enabled. Deleted
+// triggers stay suspended until they are restored or purged.
+func (t *Trigger) IsEnabled() bool {
+	return (t.Enabled == nil || *t.Enabled) && !t.DeletedAt.Valid
+}
+
+func (t *Trigger) AuditResource() (string, uuid.UUID, *uuid.UUID) {
+	return ResourceTrigger, t.ID, t.TenantID
+}
+
+func (t *Trigger) AuditSnapshot() any {
+	return t.Snapshot()
+}
+
+// AuditRedacted leaves out the request the trigger makes and its channels.
+func (t *Trigger) AuditRedacted() []string {
+	return []string{"body", "channels", "headers", "url"}
+}
+
 func (t *Trigger) ToHAL(selfHref string) (root hal.Resource) {
 	root = hal.NewResourceObject()
-	root.AddData(t)
+	_ = AddJSON(root, t)
 
 	selfRel := hal.NewSelfLinkRelation()
 	selfLink := &hal.LinkObject{Href: selfHref}
@@ -95,7 +161,7 @@ func (collection TriggerCollection) ToHAL(selfHref string, queryString url.Value
 	triggers, _ := hal.NewResourceRelation("triggers")
 	triggers.SetResources(embedded)
 	root.AddResource(triggers)
-	root.AddData(Result{len(collection), collection})
+	_ = AddJSON(root, Result{len(collection), collection})
 
 	return
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/thanhpk/randstr"
+	"gorm.io/gorm"
 )
 
 func TestWorkspaceJsonMarshal(t *testing.T) {
@@ -27,6 +28,7 @@ func TestWorkspaceJsonMarshal(t *testing.T) {
 		Success   int       `json:"success"`
 		Timeout   int       `json:"timeout"`
 		Retry     int       `json:"retry"`
+		Enabled   *bool     `json:"enabled"`
 		CreatedAt time.Time `json:"created_at"`
 		UpdatedAt time.Time `json:"updated_at"`
 	}{
@@ -78,6 +80,7 @@ func TestSingleWorkspaceHAL(t *testing.T) {
 		Name      string    `json:"name"`
 		Method    string    `json:"method"`
 		Retry     int       `json:"retry"`
+		Enabled   *bool     `json:"enabled"`
 		Schedule  string    `json:"schedule"`
 		Success   int       `json:"success"`
 		Timeout   int       `json:"timeout"`
@@ -182,3 +185,42 @@ func TestMultipleWorkspacesHAL(t *testing.T) {
 	expected, _ := JSONRemarshal(hal) // Sort keys to match with HAL's marshaling
 	assert.Equal(t, string(expected), string(actual))
 }
+
+func TestTriggerIsEnabled(t *testing.T) {
+	enabled, disabled := true, false
+
+	assert.True(t, (&Trigger{}).IsEnabled())
+	assert.True(t, (&Trigger{Enabled: &enabled}).IsEnabled())
+	assert.False(t, (&Trigger{Enabled: &disabled}).IsEnabled())
+	assert.False(t, (&Trigger{Enabled: &enabled, DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}).IsEnabled())
+}
+
+func TestTriggerSecretHidden(t *testing.T) {
+	trigger := Trigger{ID: uuid.New(), Secret: randstr.Hex(32)}
+
+	b, err := json.Marshal(trigger)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), trigger.Secret)
+
+	resource := trigger.ToHAL("/" + randstr.String(16))
+	b, err = json.Marshal(resource.ToMap().Content)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), trigger.Secret)
+}
+
+func TestTriggerPoliciesHAL(t *testing.T) {
+	trigger := Trigger{
+		ID:              uuid.New(),
+		RetryPolicy:     &RetryPolicy{Strategy: Fixed, Statuses: []int{429, 503}},
+		SuccessCriteria: &SuccessCriteria{Statuses: []string{"2xx"}, Body: &BodyAssertion{JSONPath: "$.ok", Equals: json.RawMessage(`true`)}},
+		Channels:        []Channel{{Kind: Email, To: []string{"ops@example.com"}, On: []string{OnFailure}}},
+	}
+
+	resource := TriggerCollection{&trigger}.ToHAL("/triggers", url.Values{})
+	actual, err := json.Marshal(resource.ToMap().Content)
+	assert.NoError(t, err)
+
+	assert.Contains(t, string(actual), `"retry_policy":{"statuses":[429,503],"strategy":"fixed"}`)
+	assert.Contains(t, string(actual), `"success_criteria":{"body":{"equals":true,"json_path":"$.ok"},"statuses":["2xx"]}`)
+	assert.Contains(t, string(actual), `"channels":[{"kind":"email","on":["failure"],"to":["ops@example.com"]}]`)
+}
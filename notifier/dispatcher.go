@@ -0,0 +1,139 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+	"github.com/skhaz/scheduler/signature"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/clock"
+)
+
+const (
+	EventHeader    = "X-Scheduler-Event"
+	DeliveryHeader = "X-Scheduler-Delivery"
+
+	// deliveryAttempts is how many times an event is posted to a subscription
+	// before giving up, waiting firstRetry after the first failed attempt and
+	// doubling the wait after every other one, up to maxRetry.
+	deliveryAttempts = 8
+	firstRetry       = 30 * time.Second
+	maxRetry         = time.Hour
+)
+
+// Dispatcher posts the lifecycle events of triggers waiting in the outbox to
+// their subscriptions. A delivery is only marked as delivered after the
+// subscription acknowledged it with a 2xx, so events may be delivered more
+// than once and subscriptions should deduplicate them by their ID.
+type Dispatcher struct {
+	registry *repository.RepositoryRegistry
+	client   *http.Client
+	clock    clock.Clock
+	interval time.Duration
+	logger   *zap.Logger
+}
+
+func NewDispatcher(registry *repository.RepositoryRegistry, client *http.Client, clock clock.Clock, interval time.Duration, logger *zap.Logger) *Dispatcher {
+	return &Dispatcher{
+		registry: registry,
+		client:   client,
+		clock:    clock,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Start dispatches every interval until ctx is done.
+func (d *Dispatcher) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, d.Dispatch, d.interval)
+
+	return nil
+}
+
+// Dispatch attempts every delivery that is due.
+func (d *Dispatcher) Dispatch(ctx context.Context) {
+	e, err := d.registry.Repository("DeliveryRepository")
+	if err != nil {
+		d.logger.Error("failed to dispatch events", zap.Error(err))
+		return
+	}
+
+	outbox, ok := e.(repository.Outbox)
+	if !ok {
+		d.logger.Error("failed to dispatch events", zap.Error(repository.ErrNoOutbox))
+		return
+	}
+
+	for ctx.Err() == nil {
+		deliveries, err := outbox.Due(d.clock.Now(), pageSize)
+		if err != nil {
+			d.logger.Error("failed to list deliveries", zap.Error(err))
+			return
+		}
+
+		for _, delivery := range deliveries {
+			d.deliver(ctx, delivery)
+
+			if _, err := e.Update(delivery.ID, delivery); err != nil {
+				d.logger.Error("failed to update delivery", zap.Stringer("delivery", delivery.ID), zap.Error(err))
+				return
+			}
+		}
+
+		if len(deliveries) < pageSize {
+			return
+		}
+	}
+}
+
+// deliver posts the event of delivery to its subscription once and stores the
+// outcome in delivery, scheduling the next attempt when it failed.
+func (d *Dispatcher) deliver(ctx context.Context, delivery *model.Delivery) {
+	subscription := delivery.Subscription
+
+	if !subscription.IsEnabled() {
+		delivery.State = model.Undelivered
+		delivery.LastError = "the subscription is disabled"
+
+		return
+	}
+
+	now := d.clock.Now()
+
+	delivery.Attempts++
+
+	body, err := json.Marshal(delivery.Event)
+	if err == nil {
+		header := http.Header{
+			"Content-Type": {"application/json"},
+			EventHeader:    {delivery.Event.Type},
+			DeliveryHeader: {delivery.ID.String()},
+		}
+		signature.SetHeaders(header, subscription.Secret, now, body)
+
+		delivery.StatusCode, err = post(ctx, d.client, subscription.URL, header, body)
+	}
+
+	if err == nil {
+		delivery.State = model.Delivered
+		delivery.DeliveredAt = &now
+
+		return
+	}
+
+	d.logger.Warn("failed to deliver event", zap.Stringer("delivery", delivery.ID), zap.Error(err))
+	delivery.LastError = err.Error()
+
+	if delivery.Attempts >= deliveryAttempts {
+		delivery.State = model.Undelivered
+
+		return
+	}
+
+	delivery.NextAttemptAt = now.Add(min(firstRetry<<(delivery.Attempts-1), maxRetry))
+}
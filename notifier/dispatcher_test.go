@@ -0,0 +1,168 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+	"github.com/skhaz/scheduler/signature"
+	"github.com/stretchr/testify/assert"
+	"github.com/thanhpk/randstr"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+// DeliveryRepository is an outbox kept in memory.
+type DeliveryRepository struct {
+	mu         sync.Mutex
+	deliveries []*model.Delivery
+}
+
+func (r *DeliveryRepository) Configure(db *gorm.DB)          {}
+func (r *DeliveryRepository) Get(id any) (any, error)        { return nil, nil }
+func (r *DeliveryRepository) Create(entity any) (any, error) { return entity, nil }
+func (r *DeliveryRepository) Delete(id any) (bool, error)    { return true, nil }
+func (r *DeliveryRepository) List(after time.Time, limit int, scopes ...repository.Scope) (any, error) {
+	return nil, nil
+}
+
+func (r *DeliveryRepository) Publish(event *model.Event) error { return nil }
+
+func (r *DeliveryRepository) Due(now time.Time, limit int) (model.DeliveryCollection, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due model.DeliveryCollection
+	for _, delivery := range r.deliveries {
+		if delivery.State == model.Delivering && !delivery.NextAttemptAt.After(now) && len(due) < limit {
+			snapshot := *delivery
+			due = append(due, &snapshot)
+		}
+	}
+
+	return due, nil
+}
+
+func (r *DeliveryRepository) Update(id any, entity any) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existing := range r.deliveries {
+		if existing.ID == id {
+			snapshot := *entity.(*model.Delivery)
+			r.deliveries[i] = &snapshot
+		}
+	}
+
+	return true, nil
+}
+
+func newDelivery(url, secret string, event *model.Event) *model.Delivery {
+	return &model.Delivery{
+		ID:            uuid.New(),
+		Subscription:  &model.Subscription{ID: uuid.New(), URL: url, Secret: secret},
+		Event:         event,
+		State:         model.Delivering,
+		NextAttemptAt: epoch,
+	}
+}
+
+func newDispatcher(client *http.Client, deliveries ...*model.Delivery) (*Dispatcher, *DeliveryRepository, *clocktesting.FakeClock) {
+	outbox := &DeliveryRepository{deliveries: deliveries}
+	clock := clocktesting.NewFakeClock(epoch)
+
+	return NewDispatcher(repository.NewRepositoryRegistry(nil, outbox), client, clock, time.Second, zap.NewNop()), outbox, clock
+}
+
+func TestDispatch(t *testing.T) {
+	secret := randstr.Hex(32)
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- r
+		bodies <- body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	trigger := &model.Trigger{ID: uuid.New(), Name: randstr.String(16), Secret: randstr.Hex(32)}
+	event := model.NewEvent(model.TriggerCreated, trigger)
+	event.ID = uuid.New()
+	delivery := newDelivery(server.URL, secret, event)
+
+	d, outbox, clock := newDispatcher(server.Client(), delivery)
+	clock.SetTime(time.Now())
+	d.Dispatch(context.Background())
+
+	r, body := <-requests, <-bodies
+	assert.Equal(t, model.TriggerCreated, r.Header.Get(EventHeader))
+	assert.Equal(t, delivery.ID.String(), r.Header.Get(DeliveryHeader))
+	assert.NoError(t, signature.Verify(secret, r.Header, body, time.Minute))
+
+	var payload map[string]any
+	assert.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, event.ID.String(), payload["id"])
+	assert.Equal(t, model.TriggerCreated, payload["type"])
+	assert.Equal(t, trigger.Name, payload["trigger"].(map[string]any)["name"])
+	assert.NotContains(t, string(body), trigger.Secret)
+
+	assert.Equal(t, model.Delivered, outbox.deliveries[0].State)
+	assert.Equal(t, http.StatusNoContent, outbox.deliveries[0].StatusCode)
+	assert.Equal(t, 1, outbox.deliveries[0].Attempts)
+	assert.NotNil(t, outbox.deliveries[0].DeliveredAt)
+}
+
+func TestDispatchBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	event := model.NewEvent(model.TriggerDeleted, &model.Trigger{ID: uuid.New()})
+	d, outbox, clock := newDispatcher(server.Client(), newDelivery(server.URL, "secret", event))
+
+	d.Dispatch(context.Background())
+
+	delivery := outbox.deliveries[0]
+	assert.Equal(t, model.Delivering, delivery.State)
+	assert.Equal(t, http.StatusServiceUnavailable, delivery.StatusCode)
+	assert.Contains(t, delivery.LastError, "503 Service Unavailable")
+	assert.Equal(t, epoch.Add(firstRetry), delivery.NextAttemptAt)
+
+	// Not due yet.
+	d.Dispatch(context.Background())
+	assert.Equal(t, 1, outbox.deliveries[0].Attempts)
+
+	for attempt := 2; attempt <= deliveryAttempts; attempt++ {
+		clock.SetTime(outbox.deliveries[0].NextAttemptAt)
+		d.Dispatch(context.Background())
+	}
+
+	delivery = outbox.deliveries[0]
+	assert.Equal(t, model.Undelivered, delivery.State)
+	assert.Equal(t, deliveryAttempts, delivery.Attempts)
+}
+
+func TestDispatchDisabled(t *testing.T) {
+	disabled := false
+	delivery := newDelivery("http://127.0.0.1:1", "secret", model.NewEvent(model.TriggerPaused, &model.Trigger{ID: uuid.New()}))
+	delivery.Subscription.Enabled = &disabled
+
+	d, outbox, _ := newDispatcher(http.DefaultClient, delivery)
+	d.Dispatch(context.Background())
+
+	assert.Equal(t, model.Undelivered, outbox.deliveries[0].State)
+	assert.Equal(t, 0, outbox.deliveries[0].Attempts)
+	assert.Equal(t, "the subscription is disabled", outbox.deliveries[0].LastError)
+}
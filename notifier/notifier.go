@@ -0,0 +1,258 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/clock"
+)
+
+const (
+	pageSize = 100
+
+	// attempts is how many times a notification is tried, waiting one second
+	// and doubling the wait after every failed attempt.
+	attempts = 3
+
+	// lookback is how far before the notifier started executions are still
+	// notified about, so the ones that ended while there was no leader are
+	// not missed. Older ones never are, even if nobody was told about them.
+	lookback = time.Hour
+
+	// slack is how far before the previous run executions are listed again,
+	// so the ones recorded while it was running are not missed.
+	slack = time.Minute
+)
+
+// Notifier watches the executions of the triggers that have channels, as
+// recorded in the executions table, and notifies them about failures and
+// recoveries. Each channel is notified at most once about each event, even
+// across replicas, as every notification is reserved in the notifications
+// table before being delivered.
+type Notifier struct {
+	registry *repository.RepositoryRegistry
+	senders  map[string]Sender
+	clock    clock.Clock
+	interval time.Duration
+	logger   *zap.Logger
+
+	// since is when the notifier started, minus lookback, and cursor is when
+	// the previous run that went through every trigger started, minus slack.
+	since  time.Time
+	cursor time.Time
+}
+
+func NewNotifier(registry *repository.RepositoryRegistry, senders map[string]Sender, clock clock.Clock, interval time.Duration, logger *zap.Logger) *Notifier {
+	return &Notifier{
+		registry: registry,
+		senders:  senders,
+		clock:    clock,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Start notifies every interval until ctx is done.
+func (n *Notifier) Start(ctx context.Context) error {
+	n.since = n.clock.Now().Add(-lookback)
+	n.cursor = n.since
+
+	wait.UntilWithContext(ctx, n.Notify, n.interval)
+
+	return nil
+}
+
+// Notify goes through the triggers once. The executions recorded before the
+// previous run are only looked at again when that run failed somewhere.
+func (n *Notifier) Notify(ctx context.Context) {
+	started := n.clock.Now()
+
+	triggerRepository := n.registry.MustRepository("TriggerRepository")
+
+	complete := true
+
+	var after *model.Trigger
+	for {
+		e, err := triggerRepository.List(time.Time{}, pageSize, repository.AfterTrigger(after))
+		if err != nil {
+			n.logger.Error("failed to list triggers", zap.Error(err))
+			return
+		}
+
+		triggers := e.(model.TriggerCollection)
+		for _, trigger := range triggers {
+			if len(trigger.Channels) == 0 {
+				continue
+			}
+
+			if err := n.notify(ctx, trigger); err != nil {
+				n.logger.Error("failed to notify", zap.Stringer("trigger", trigger.ID), zap.Error(err))
+				complete = false
+			}
+		}
+
+		last, ok := model.Last(triggers)
+		if !ok || len(triggers) < pageSize {
+			break
+		}
+
+		after = last
+	}
+
+	if complete {
+		n.cursor = started.Add(-slack)
+	}
+}
+
+// notify notifies the channels of trigger about its executions recorded as
+// finished since the cursor, in the order they started. The failures in a row
+// are counted from the history of the trigger, however old.
+func (n *Notifier) notify(ctx context.Context, trigger *model.Trigger) error {
+	executions := n.registry.MustRepository("ExecutionRepository")
+
+	history, ok := executions.(repository.History)
+	if !ok {
+		return errors.New("executions have no history")
+	}
+
+	e, err := n.registry.Repository("NotificationRepository")
+	if err != nil {
+		return err
+	}
+
+	notifications, ok := e.(repository.Reserver)
+	if !ok {
+		return errors.New("notifications cannot be reserved")
+	}
+
+	var after time.Time
+	for {
+		c, err := executions.List(after, pageSize, repository.WhereTrigger(trigger.ID), repository.WhereFinishedSince(n.cursor))
+		if err != nil {
+			return err
+		}
+
+		finished := c.(model.ExecutionCollection)
+		for _, execution := range finished {
+			if execution.FinishedAt.Before(n.since) {
+				continue
+			}
+
+			streak, err := history.Streak(trigger.ID, execution.StartedAt)
+			if err != nil {
+				return err
+			}
+
+			failed := execution.Phase != model.Succeeded
+			recovered := !failed && streak > 0
+
+			if failed {
+				streak++
+			} else {
+				streak = 0
+			}
+
+			for i, channel := range trigger.Channels {
+				event := channel.Event(failed, recovered, streak)
+				if event == "" {
+					continue
+				}
+
+				notification := &model.Notification{
+					TriggerID:   trigger.ID,
+					ExecutionID: execution.ID,
+					Channel:     i,
+					Kind:        channel.Kind,
+					Event:       event,
+					State:       model.Delivering,
+				}
+
+				reserved, err := notifications.Reserve(notification)
+				if err != nil {
+					return err
+				}
+
+				if !reserved {
+					continue
+				}
+
+				message := &Message{
+					Event:       event,
+					TriggerID:   trigger.ID.String(),
+					TriggerName: trigger.Name,
+					Consecutive: streak,
+					Execution:   execution,
+					secret:      trigger.Secret,
+				}
+
+				n.deliver(ctx, channel, notification, message)
+
+				if _, err := e.Update(notification.ID, notification); err != nil {
+					return err
+				}
+			}
+		}
+
+		last, ok := model.Last(finished)
+		if !ok || len(finished) < pageSize {
+			return nil
+		}
+
+		after = last.StartedAt
+	}
+}
+
+// deliver sends message through the sender of the kind of channel, retrying
+// on errors, and stores the outcome in notification.
+func (n *Notifier) deliver(ctx context.Context, channel model.Channel, notification *model.Notification, message *Message) {
+	sender, ok := n.senders[channel.Kind]
+	if !ok {
+		notification.State = model.Undelivered
+		notification.LastError = fmt.Sprintf("%s notifications are not configured", channel.Kind)
+
+		return
+	}
+
+	delay := time.Second
+
+	for notification.Attempts < attempts {
+		notification.Attempts++
+
+		message.sentAt = n.clock.Now()
+
+		err := sender.Send(ctx, channel, message)
+		if err == nil {
+			deliveredAt := n.clock.Now()
+			notification.State = model.Delivered
+			notification.LastError = ""
+			notification.DeliveredAt = &deliveredAt
+
+			return
+		}
+
+		n.logger.Warn("failed to deliver notification", zap.Stringer("notification", notification.ID), zap.Error(err))
+		notification.LastError = err.Error()
+
+		if notification.Attempts == attempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			notification.State = model.Undelivered
+
+			return
+		case <-n.clock.After(delay):
+		}
+
+		delay *= 2
+	}
+
+	notification.State = model.Undelivered
+}
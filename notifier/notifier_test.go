@@ -0,0 +1,309 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/thanhpk/randstr"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+type TriggerRepository struct {
+	triggers model.TriggerCollection
+}
+
+func (r *TriggerRepository) Configure(db *gorm.DB)                   {}
+func (r *TriggerRepository) Get(id any) (any, error)                 { return nil, nil }
+func (r *TriggerRepository) Create(entity any) (any, error)          { return entity, nil }
+func (r *TriggerRepository) Update(id any, entity any) (bool, error) { return true, nil }
+func (r *TriggerRepository) Delete(id any) (bool, error)             { return true, nil }
+func (r *TriggerRepository) List(after time.Time, limit int, scopes ...repository.Scope) (any, error) {
+	return r.triggers, nil
+}
+
+// ExecutionRepository lists the finished executions, in the order they
+// started, whatever the scopes.
+type ExecutionRepository struct {
+	executions model.ExecutionCollection
+}
+
+func (r *ExecutionRepository) Configure(db *gorm.DB)                   {}
+func (r *ExecutionRepository) Get(id any) (any, error)                 { return nil, nil }
+func (r *ExecutionRepository) Create(entity any) (any, error)          { return entity, nil }
+func (r *ExecutionRepository) Update(id any, entity any) (bool, error) { return true, nil }
+func (r *ExecutionRepository) Delete(id any) (bool, error)             { return true, nil }
+func (r *ExecutionRepository) List(after time.Time, limit int, scopes ...repository.Scope) (any, error) {
+	finished := model.ExecutionCollection{}
+	for _, execution := range r.executions {
+		if execution.FinishedAt != nil {
+			finished = append(finished, execution)
+		}
+	}
+
+	return finished, nil
+}
+
+func (r *ExecutionRepository) Streak(trigger any, before time.Time) (int, error) {
+	streak := 0
+	for _, execution := range r.executions {
+		switch {
+		case execution.FinishedAt == nil || !execution.StartedAt.Before(before):
+		case execution.Phase == model.Succeeded:
+			streak = 0
+		default:
+			streak++
+		}
+	}
+
+	return streak, nil
+}
+
+// NotificationRepository keeps notifications in memory, unique per channel,
+// execution and event as the notifications table.
+type NotificationRepository struct {
+	mu            sync.Mutex
+	notifications []*model.Notification
+}
+
+func (r *NotificationRepository) Configure(db *gorm.DB)          {}
+func (r *NotificationRepository) Get(id any) (any, error)        { return nil, nil }
+func (r *NotificationRepository) Create(entity any) (any, error) { return entity, nil }
+func (r *NotificationRepository) Delete(id any) (bool, error)    { return true, nil }
+func (r *NotificationRepository) List(after time.Time, limit int, scopes ...repository.Scope) (any, error) {
+	return nil, nil
+}
+
+func (r *NotificationRepository) Reserve(entity any) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := entity.(*model.Notification)
+	for _, existing := range r.notifications {
+		if existing.ExecutionID == n.ExecutionID && existing.Channel == n.Channel && existing.Event == n.Event {
+			return false, nil
+		}
+	}
+
+	n.ID = uuid.New()
+	snapshot := *n
+	r.notifications = append(r.notifications, &snapshot)
+
+	return true, nil
+}
+
+func (r *NotificationRepository) Update(id any, entity any) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existing := range r.notifications {
+		if existing.ID == id {
+			snapshot := *entity.(*model.Notification)
+			r.notifications[i] = &snapshot
+		}
+	}
+
+	return true, nil
+}
+
+func (r *NotificationRepository) list() []model.Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	notifications := make([]model.Notification, 0, len(r.notifications))
+	for _, n := range r.notifications {
+		notifications = append(notifications, *n)
+	}
+
+	return notifications
+}
+
+// recorder is a Sender that records the messages it receives, after failing
+// the given number of times.
+type recorder struct {
+	mu       sync.Mutex
+	failures int
+	messages []*Message
+}
+
+func (s *recorder) Send(ctx context.Context, channel model.Channel, message *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("connection refused")
+	}
+
+	s.messages = append(s.messages, message)
+
+	return nil
+}
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newExecutions returns one execution per phase, a minute apart.
+func newExecutions(phases ...string) model.ExecutionCollection {
+	executions := make(model.ExecutionCollection, 0, len(phases))
+	for i, phase := range phases {
+		startedAt := epoch.Add(time.Duration(i) * time.Minute)
+		finishedAt := startedAt.Add(time.Second)
+
+		execution := &model.Execution{ID: uuid.New(), Name: randstr.String(16), Phase: phase, StartedAt: startedAt}
+		if phase != model.Running {
+			execution.FinishedAt = &finishedAt
+		}
+
+		executions = append(executions, execution)
+	}
+
+	return executions
+}
+
+func newNotifier(trigger *model.Trigger, executions model.ExecutionCollection, senders map[string]Sender) (*Notifier, *NotificationRepository, *clocktesting.FakeClock) {
+	notifications := &NotificationRepository{}
+	registry := repository.NewRepositoryRegistry(nil,
+		&TriggerRepository{triggers: model.TriggerCollection{trigger}},
+		&ExecutionRepository{executions: executions},
+		notifications,
+	)
+
+	clock := clocktesting.NewFakeClock(epoch.Add(time.Hour))
+
+	return NewNotifier(registry, senders, clock, time.Minute, zap.NewNop()), notifications, clock
+}
+
+func TestNotify(t *testing.T) {
+	trigger := &model.Trigger{
+		ID:   uuid.New(),
+		Name: randstr.String(16),
+		Channels: []model.Channel{
+			{Kind: model.Webhook, URL: "https://example.com", On: []string{model.OnFailure}},
+			{Kind: model.Slack, URL: "https://example.com", On: []string{model.OnRecovery, model.OnConsecutive}, Consecutive: 2},
+		},
+	}
+
+	executions := newExecutions(model.Succeeded, model.Failed, model.Error, model.Failed, model.Succeeded, model.Succeeded, model.Running)
+
+	webhook, slack := &recorder{}, &recorder{}
+	n, notifications, _ := newNotifier(trigger, executions, map[string]Sender{model.Webhook: webhook, model.Slack: slack})
+
+	n.Notify(context.Background())
+
+	assert.Len(t, webhook.messages, 3)
+	for i, message := range webhook.messages {
+		assert.Equal(t, model.OnFailure, message.Event)
+		assert.Equal(t, executions[i+1].ID, message.Execution.ID)
+		assert.Equal(t, i+1, message.Consecutive)
+	}
+
+	assert.Len(t, slack.messages, 2)
+	assert.Equal(t, model.OnConsecutive, slack.messages[0].Event)
+	assert.Equal(t, executions[2].ID, slack.messages[0].Execution.ID)
+	assert.Equal(t, model.OnRecovery, slack.messages[1].Event)
+	assert.Equal(t, executions[4].ID, slack.messages[1].Execution.ID)
+
+	for _, notification := range notifications.list() {
+		assert.Equal(t, model.Delivered, notification.State)
+		assert.Equal(t, 1, notification.Attempts)
+		assert.NotNil(t, notification.DeliveredAt)
+	}
+
+	// The next run starts from this one.
+	assert.Equal(t, epoch.Add(time.Hour-time.Minute), n.cursor)
+
+	// Every channel is notified once about each event.
+	n.Notify(context.Background())
+	assert.Len(t, webhook.messages, 3)
+	assert.Len(t, slack.messages, 2)
+	assert.Len(t, notifications.list(), 5)
+}
+
+func TestNotifyRetry(t *testing.T) {
+	trigger := &model.Trigger{
+		ID:       uuid.New(),
+		Name:     randstr.String(16),
+		Channels: []model.Channel{{Kind: model.Webhook, URL: "https://example.com", On: []string{model.OnFailure}}},
+	}
+
+	webhook := &recorder{failures: 1}
+	n, notifications, clock := newNotifier(trigger, newExecutions(model.Failed), map[string]Sender{model.Webhook: webhook})
+
+	done := make(chan struct{})
+	go func() {
+		n.Notify(context.Background())
+		close(done)
+	}()
+
+	assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+	clock.Step(time.Second)
+	<-done
+
+	assert.Len(t, webhook.messages, 1)
+
+	list := notifications.list()
+	assert.Len(t, list, 1)
+	assert.Equal(t, model.Delivered, list[0].State)
+	assert.Equal(t, 2, list[0].Attempts)
+}
+
+func TestNotifyUndelivered(t *testing.T) {
+	trigger := &model.Trigger{
+		ID:   uuid.New(),
+		Name: randstr.String(16),
+		Channels: []model.Channel{
+			{Kind: model.Webhook, URL: "https://example.com", On: []string{model.OnFailure}},
+			{Kind: model.Email, To: []string{"ops@example.com"}, On: []string{model.OnFailure}},
+		},
+	}
+
+	webhook := &recorder{failures: attempts}
+	n, notifications, clock := newNotifier(trigger, newExecutions(model.Failed), map[string]Sender{model.Webhook: webhook})
+
+	done := make(chan struct{})
+	go func() {
+		n.Notify(context.Background())
+		close(done)
+	}()
+
+	for i := 1; i < attempts; i++ {
+		assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+		clock.Step(time.Duration(i) * time.Second)
+	}
+	<-done
+
+	list := notifications.list()
+	assert.Len(t, list, 2)
+
+	assert.Equal(t, model.Undelivered, list[0].State)
+	assert.Equal(t, attempts, list[0].Attempts)
+	assert.Equal(t, "connection refused", list[0].LastError)
+
+	assert.Equal(t, model.Undelivered, list[1].State)
+	assert.Equal(t, "email notifications are not configured", list[1].LastError)
+}
+
+func TestNotifySince(t *testing.T) {
+	trigger := &model.Trigger{
+		ID:       uuid.New(),
+		Name:     randstr.String(16),
+		Channels: []model.Channel{{Kind: model.Webhook, URL: "https://example.com", On: []string{model.OnFailure}}},
+	}
+
+	webhook := &recorder{}
+	n, _, _ := newNotifier(trigger, newExecutions(model.Failed, model.Failed), map[string]Sender{model.Webhook: webhook})
+	n.since = epoch.Add(time.Minute)
+
+	n.Notify(context.Background())
+
+	assert.Len(t, webhook.messages, 1)
+	assert.Equal(t, 2, webhook.messages[0].Consecutive)
+}
@@ -0,0 +1,166 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/signature"
+)
+
+// Message is what a channel is told about an execution.
+type Message struct {
+	Event       string           `json:"event"`
+	TriggerID   string           `json:"trigger_id"`
+	TriggerName string           `json:"trigger_name"`
+	Consecutive int              `json:"consecutive_failures"`
+	Execution   *model.Execution `json:"execution"`
+
+	secret string
+	sentAt time.Time
+}
+
+// Subject is a one line summary of the message.
+func (m *Message) Subject() string {
+	switch m.Event {
+	case model.OnRecovery:
+		return fmt.Sprintf("Trigger %s recovered", m.TriggerName)
+	case model.OnConsecutive:
+		return fmt.Sprintf("Trigger %s failed %d times in a row", m.TriggerName, m.Consecutive)
+	}
+
+	return fmt.Sprintf("Trigger %s failed", m.TriggerName)
+}
+
+// Text describes the execution the message is about.
+func (m *Message) Text() string {
+	var b strings.Builder
+
+	b.WriteString(m.Subject() + ".\n\n")
+	fmt.Fprintf(&b, "Execution: %s\n", m.Execution.Name)
+	fmt.Fprintf(&b, "Phase: %s\n", m.Execution.Phase)
+	if m.Execution.StatusCode != 0 {
+		fmt.Fprintf(&b, "Status code: %d\n", m.Execution.StatusCode)
+	}
+	if m.Execution.Message != "" {
+		fmt.Fprintf(&b, "Message: %s\n", m.Execution.Message)
+	}
+	fmt.Fprintf(&b, "Started at: %s\n", m.Execution.StartedAt.Format(time.RFC3339))
+
+	return b.String()
+}
+
+// Sender delivers messages to one kind of channel.
+type Sender interface {
+	Send(ctx context.Context, channel model.Channel, message *Message) error
+}
+
+// Webhook posts the message as JSON, signed with the secret of the trigger
+// the same way as the requests of the trigger itself.
+type Webhook struct {
+	Client *http.Client
+}
+
+func (w *Webhook) Send(ctx context.Context, channel model.Channel, message *Message) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{"Content-Type": {"application/json"}}
+	signature.SetHeaders(header, message.secret, message.sentAt, body)
+
+	_, err = post(ctx, w.Client, channel.URL, header, body)
+
+	return err
+}
+
+// Slack posts the message in the payload of Slack incoming webhooks, which
+// Mattermost, Rocket.Chat and others accept as well.
+type Slack struct {
+	Client *http.Client
+}
+
+func (s *Slack) Send(ctx context.Context, channel model.Channel, message *Message) error {
+	body, err := json.Marshal(map[string]string{"text": message.Text()})
+	if err != nil {
+		return err
+	}
+
+	_, err = post(ctx, s.Client, channel.URL, http.Header{"Content-Type": {"application/json"}}, body)
+
+	return err
+}
+
+// post sends body to url and returns the status of the response, which is an
+// error unless it is 2xx.
+func post(ctx context.Context, client *http.Client, url string, header http.Header, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header = header
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("%s responded with %s", url, resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// SMTP sends the message by email. Without Username, no authentication is
+// attempted, and STARTTLS is used whenever the server offers it.
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (s *SMTP) Send(ctx context.Context, channel model.Channel, message *Message) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(channel.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", strings.NewReplacer("\r", " ", "\n", " ").Replace(message.Subject()))
+	fmt.Fprintf(&b, "Date: %s\r\n", message.sentAt.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(message.Text(), "\n", "\r\n"))
+
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+
+	done := make(chan error, 1)
+	go func() { done <- smtp.SendMail(addr, auth, s.From, channel.To, []byte(b.String())) }()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-done:
+		return err
+	}
+}
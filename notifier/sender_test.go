@@ -0,0 +1,194 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/signature"
+	"github.com/stretchr/testify/assert"
+	"github.com/thanhpk/randstr"
+)
+
+func TestWebhookSend(t *testing.T) {
+	secret := randstr.Hex(32)
+	bodies := make(chan []byte, 1)
+	headers := make(chan http.Header, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+		headers <- r.Header
+	}))
+	defer server.Close()
+
+	execution := newExecutions(model.Failed)[0]
+	message := &Message{Event: model.OnFailure, TriggerName: "curl", Consecutive: 1, Execution: execution, secret: secret, sentAt: time.Now()}
+
+	err := (&Webhook{Client: server.Client()}).Send(context.Background(), model.Channel{URL: server.URL}, message)
+	assert.NoError(t, err)
+
+	body, header := <-bodies, <-headers
+	assert.NoError(t, signature.Verify(secret, header, body, time.Minute))
+
+	var payload map[string]any
+	assert.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, model.OnFailure, payload["event"])
+	assert.Equal(t, "curl", payload["trigger_name"])
+	assert.Equal(t, execution.ID.String(), payload["execution"].(map[string]any)["id"])
+}
+
+func TestSlackSend(t *testing.T) {
+	bodies := make(chan []byte, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer server.Close()
+
+	execution := newExecutions(model.Succeeded)[0]
+	message := &Message{Event: model.OnRecovery, TriggerName: "curl", Execution: execution}
+
+	err := (&Slack{Client: server.Client()}).Send(context.Background(), model.Channel{URL: server.URL}, message)
+	assert.NoError(t, err)
+
+	var payload map[string]string
+	assert.NoError(t, json.Unmarshal(<-bodies, &payload))
+	assert.Contains(t, payload["text"], "Trigger curl recovered.")
+	assert.Contains(t, payload["text"], "Execution: "+execution.Name)
+}
+
+func TestPostError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	message := &Message{Execution: newExecutions(model.Failed)[0]}
+
+	err := (&Slack{Client: server.Client()}).Send(context.Background(), model.Channel{URL: server.URL}, message)
+	assert.ErrorContains(t, err, "410 Gone")
+}
+
+// mail is what the SMTP server received in one transaction.
+type mail struct {
+	from string
+	to   []string
+	data string
+}
+
+// newSMTPServer starts a minimal SMTP server on the loopback interface that
+// accepts every message and sends it to the returned channel.
+func newSMTPServer(t *testing.T) (string, int, <-chan mail) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	mails := make(chan mail, 1)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go serveSMTP(conn, mails)
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+
+	return addr.IP.String(), addr.Port, mails
+}
+
+func serveSMTP(conn net.Conn, mails chan<- mail) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+	var m mail
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		command := strings.TrimSpace(line)
+		switch verb := strings.ToUpper(strings.SplitN(command, " ", 2)[0]); verb {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "MAIL":
+			m = mail{from: strings.Trim(strings.TrimPrefix(command, "MAIL FROM:"), "<>")}
+			reply("250 OK")
+		case "RCPT":
+			m.to = append(m.to, strings.Trim(strings.TrimPrefix(command, "RCPT TO:"), "<>"))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+
+			m.data = data.String()
+			mails <- m
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPSend(t *testing.T) {
+	host, port, mails := newSMTPServer(t)
+
+	execution := newExecutions(model.Failed)[0]
+	execution.StatusCode = http.StatusServiceUnavailable
+	message := &Message{Event: model.OnConsecutive, TriggerName: "curl", Consecutive: 3, Execution: execution, sentAt: time.Now()}
+
+	sender := &SMTP{Host: host, Port: port, From: "scheduler@example.com"}
+	channel := model.Channel{Kind: model.Email, To: []string{"ops@example.com", "dev@example.com"}}
+
+	assert.NoError(t, sender.Send(context.Background(), channel, message))
+
+	m := <-mails
+	assert.Equal(t, "scheduler@example.com", m.from)
+	assert.Equal(t, channel.To, m.to)
+	assert.Contains(t, m.data, "To: ops@example.com, dev@example.com\r\n")
+	assert.Contains(t, m.data, "Subject: Trigger curl failed 3 times in a row\r\n")
+	assert.Contains(t, m.data, "Status code: "+strconv.Itoa(http.StatusServiceUnavailable)+"\r\n")
+}
+
+func TestSMTPSendUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	message := &Message{Execution: newExecutions(model.Failed)[0], sentAt: time.Now()}
+	sender := &SMTP{Host: "127.0.0.1", Port: port, From: "scheduler@example.com"}
+
+	assert.Error(t, sender.Send(context.Background(), model.Channel{To: []string{"ops@example.com"}}, message))
+}
@@ -0,0 +1,280 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+const (
+	// refreshInterval is how long a key set fetched from a URL is kept before
+	// an unknown key ID fetches it again, so tokens signed with made up key
+	// IDs cannot flood the identity provider.
+	refreshInterval = time.Minute
+
+	// retryInterval is how long a failed fetch is reported to every request
+	// before the key set is fetched again, so the requests made while the
+	// identity provider is down do not pile up on it.
+	retryInterval = 5 * time.Second
+)
+
+var (
+	ErrUnknownKey        = errors.New("the token is signed with an unknown key")
+	ErrKeySetUnavailable = errors.New("the key set cannot be read")
+)
+
+// JWK is a public key of a JSON Web Key Set, RSA or elliptic curve ones only.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Key is a public key of a key set, Alg is empty when any algorithm that fits
+// the key may be used with it.
+type Key struct {
+	Alg    string
+	Public crypto.PublicKey
+}
+
+// ParseJWKS returns the signing keys of a key set by their ID, skipping the
+// keys meant for encryption and the ones of unsupported types.
+func ParseJWKS(data []byte) (map[string]Key, error) {
+	var set JWKS
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]Key, len(set.Keys))
+
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		public, err := jwk.PublicKey()
+		if errors.Is(err, errUnsupportedKey) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", jwk.Kid, err)
+		}
+
+		keys[jwk.Kid] = Key{Alg: jwk.Alg, Public: public}
+	}
+
+	return keys, nil
+}
+
+var errUnsupportedKey = errors.New("unsupported key type")
+
+func (jwk *JWK) PublicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var (
+			curve elliptic.Curve
+			point ecdh.Curve
+		)
+
+		switch jwk.Crv {
+		case "P-256":
+			curve, point = elliptic.P256(), ecdh.P256()
+		case "P-384":
+			curve, point = elliptic.P384(), ecdh.P384()
+		case "P-521":
+			curve, point = elliptic.P521(), ecdh.P521()
+		default:
+			return nil, fmt.Errorf("%w: curve %s", errUnsupportedKey, jwk.Crv)
+		}
+
+		x, err := decodeInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		// The uncompressed encoding of the point is parsed only to check that
+		// it is on the curve.
+		size := (curve.Params().BitSize + 7) / 8
+		uncompressed := append([]byte{4}, append(x.FillBytes(make([]byte, size)), y.FillBytes(make([]byte, size))...)...)
+		if _, err := point.NewPublicKey(uncompressed); err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedKey, jwk.Kty)
+	}
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) == 0 {
+		return nil, errors.New("empty key parameter")
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
+
+// KeySet holds the keys tokens are verified with, read from a local file or
+// fetched from a URL, usually the jwks_uri of the identity provider. Keys
+// fetched from a URL are fetched again when a token is signed with a key
+// missing from the set, so the provider can rotate its keys. Concurrent
+// requests share a single fetch, made without holding the lock.
+type KeySet struct {
+	source string
+	client *http.Client
+	clock  clock.Clock
+
+	mu       sync.Mutex
+	keys     map[string]Key
+	fetched  time.Time
+	fetching chan struct{}
+	failed   time.Time
+	err      error
+}
+
+// NewKeySet returns the key set at source, a http(s) URL or the path of a file.
+// client should have a timeout, as requests wait for the key set to be fetched.
+func NewKeySet(source string, client *http.Client, clock clock.Clock) *KeySet {
+	return &KeySet{source: source, client: client, clock: clock}
+}
+
+func (s *KeySet) remote() bool {
+	return strings.HasPrefix(s.source, "https://") || strings.HasPrefix(s.source, "http://")
+}
+
+// Key returns the key with the given ID. It returns an ErrKeySetUnavailable
+// error when the key set cannot be read, which is a fault of the identity
+// provider rather than of the token.
+func (s *KeySet) Key(kid string) (Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		if key, ok := s.keys[kid]; ok {
+			return key, nil
+		}
+
+		if s.keys != nil && (!s.remote() || s.clock.Since(s.fetched) < refreshInterval) {
+			return Key{}, ErrUnknownKey
+		}
+
+		if s.err != nil && s.clock.Since(s.failed) < retryInterval {
+			return Key{}, fmt.Errorf("%w: %w", ErrKeySetUnavailable, s.err)
+		}
+
+		if s.fetching == nil {
+			break
+		}
+
+		// Another request is fetching the key set, whose outcome is checked
+		// again once it is done.
+		fetching := s.fetching
+		s.mu.Unlock()
+		<-fetching
+		s.mu.Lock()
+	}
+
+	fetching := make(chan struct{})
+	s.fetching = fetching
+
+	s.mu.Unlock()
+	keys, err := s.load()
+	s.mu.Lock()
+
+	s.fetching = nil
+	close(fetching)
+
+	if err != nil {
+		s.failed, s.err = s.clock.Now(), err
+		return Key{}, fmt.Errorf("%w: %w", ErrKeySetUnavailable, err)
+	}
+
+	s.keys, s.fetched, s.err = keys, s.clock.Now(), nil
+
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+
+	return Key{}, ErrUnknownKey
+}
+
+func (s *KeySet) load() (map[string]Key, error) {
+	if !s.remote() {
+		data, err := os.ReadFile(s.source)
+		if err != nil {
+			return nil, err
+		}
+
+		return ParseJWKS(data)
+	}
+
+	resp, err := s.client.Get(s.source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching the key set: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseJWKS(data)
+}
@@ -0,0 +1,116 @@
+package oidc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	clock "k8s.io/utils/clock/testing"
+)
+
+func TestParseJWKS(t *testing.T) {
+	keys, err := ParseJWKS(testJWKS())
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+	assert.Equal(t, "ES256", keys["ec"].Alg)
+
+	keys, err = ParseJWKS([]byte(`{"keys":[{"kty":"RSA","kid":"enc","use":"enc","n":"AQAB","e":"AQAB"},{"kty":"oct","kid":"hmac","k":"c2VjcmV0"}]}`))
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	_, err = ParseJWKS([]byte(`{"keys":[{"kty":"EC","kid":"ec","crv":"P-256","x":"AQ","y":"AQ"}]}`))
+	assert.Error(t, err)
+}
+
+func TestKeySetURL(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write(testJWKS())
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	fakeClock := clock.NewFakeClock(now)
+	verifier := NewVerifier(Config{Issuer: testIssuer, Audience: testAudience}, NewKeySet(server.URL, server.Client(), fakeClock), fakeClock)
+
+	_, err := verifier.Verify(sign(t, "ES256", "ec", validClaims(now)))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, requests)
+
+	// Unknown keys fetch the key set again, once per refresh interval.
+	_, err = verifier.Verify(sign(t, "RS256", "rotated", validClaims(now)))
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	assert.Equal(t, 1, requests)
+
+	fakeClock.Step(refreshInterval)
+
+	_, err = verifier.Verify(sign(t, "RS256", "rotated", validClaims(now)))
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	assert.Equal(t, 2, requests)
+}
+
+func TestKeySetUnavailable(t *testing.T) {
+	var requests, status atomic.Int32
+	status.Store(http.StatusBadGateway)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(int(status.Load()))
+		_, _ = w.Write(testJWKS())
+	}))
+	defer server.Close()
+
+	fakeClock := clock.NewFakeClock(time.Now())
+	keys := NewKeySet(server.URL, server.Client(), fakeClock)
+
+	// A failed fetch is reported until the retry interval is over.
+	_, err := keys.Key("ec")
+	assert.ErrorIs(t, err, ErrKeySetUnavailable)
+
+	_, err = keys.Key("ec")
+	assert.ErrorIs(t, err, ErrKeySetUnavailable)
+	assert.Equal(t, int32(1), requests.Load())
+
+	status.Store(http.StatusOK)
+	fakeClock.Step(retryInterval)
+
+	_, err = keys.Key("ec")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestKeySetSingleFetch(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		_, _ = w.Write(testJWKS())
+	}))
+	defer server.Close()
+
+	keys := NewKeySet(server.URL, server.Client(), clock.NewFakeClock(time.Now()))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := keys.Key("ec")
+			assert.NoError(t, err)
+		}()
+	}
+
+	assert.Eventually(t, func() bool { return requests.Load() == 1 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), requests.Load())
+}
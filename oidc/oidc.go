@@ -0,0 +1,287 @@
+// Package oidc verifies the JSON Web Tokens issued by an OpenID Connect
+// provider, so its users can call the API with their ID or access tokens.
+//
+// Tokens must be signed with one of the RS, PS or ES algorithms by a key of the
+// key set of the provider, be issued by the configured issuer for the
+// configured audience, and be used between their nbf and exp claims, both
+// given some leeway for clock skew. Symmetric and unsigned tokens are rejected.
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+var (
+	ErrMalformed            = errors.New("the token is malformed")
+	ErrUnsupportedAlgorithm = errors.New("the token is signed with an unsupported algorithm")
+	ErrInvalidSignature     = errors.New("the token signature is invalid")
+	ErrInvalidIssuer        = errors.New("the token is issued by another issuer")
+	ErrInvalidAudience      = errors.New("the token is meant for another audience")
+	ErrExpired              = errors.New("the token is expired")
+	ErrNotYetValid          = errors.New("the token is not valid yet")
+)
+
+var hashes = map[string]crypto.Hash{
+	"256": crypto.SHA256,
+	"384": crypto.SHA384,
+	"512": crypto.SHA512,
+}
+
+// curves are the curves of the keys each ES algorithm is used with.
+var curves = map[string]string{
+	"ES256": "P-256",
+	"ES384": "P-384",
+	"ES512": "P-521",
+}
+
+type Config struct {
+	Issuer   string
+	Audience string
+
+	// TenantClaim and RolesClaim name the claims holding the tenant ID and the
+	// roles of the principal, both are optional in tokens.
+	TenantClaim string
+	RolesClaim  string
+
+	// AdminRole is the role, in RolesClaim, of the admins whose tokens need no
+	// tenant. Without it, no token is an admin's.
+	AdminRole string
+
+	Leeway time.Duration
+}
+
+// Claims are the claims of a verified token the principal is made from.
+// Scopes come from the space separated scope claim, and Admin tells whether
+// Roles holds the admin role of the config.
+type Claims struct {
+	Issuer    string
+	Subject   string
+	Scopes    []string
+	Roles     []string
+	Tenant    string
+	Admin     bool
+	ExpiresAt time.Time
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// audience is the aud claim, either a string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*a = audience{s}
+		return nil
+	}
+
+	return json.Unmarshal(data, (*[]string)(a))
+}
+
+type Verifier struct {
+	config Config
+	keys   *KeySet
+	clock  clock.Clock
+}
+
+func NewVerifier(config Config, keys *KeySet, clock clock.Clock) *Verifier {
+	return &Verifier{config: config, keys: keys, clock: clock}
+}
+
+// IsJWT reports whether token looks like a JWT, rather than an API key.
+func IsJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// Verify checks the signature and the claims of token, and returns the claims.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, err
+	}
+
+	key, err := v.keys.Key(h.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.Alg != "" && key.Alg != h.Alg {
+		return nil, fmt.Errorf("%w: %s for a %s key", ErrUnsupportedAlgorithm, h.Alg, key.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	if err := verifySignature(h.Alg, key.Public, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return nil, err
+	}
+
+	return v.claims(raw)
+}
+
+func (v *Verifier) claims(raw map[string]json.RawMessage) (*Claims, error) {
+	var (
+		issuer, subject, scope, tenant string
+		aud                            audience
+		exp, nbf                       float64
+		roles                          []string
+	)
+
+	for name, value := range map[string]any{
+		"iss":   &issuer,
+		"sub":   &subject,
+		"aud":   &aud,
+		"exp":   &exp,
+		"nbf":   &nbf,
+		"scope": &scope,
+	} {
+		if data, ok := raw[name]; ok {
+			if err := json.Unmarshal(data, value); err != nil {
+				return nil, fmt.Errorf("%w: claim %s: %w", ErrMalformed, name, err)
+			}
+		}
+	}
+
+	if data, ok := raw[v.config.TenantClaim]; ok && v.config.TenantClaim != "" {
+		if err := json.Unmarshal(data, &tenant); err != nil {
+			return nil, fmt.Errorf("%w: claim %s: %w", ErrMalformed, v.config.TenantClaim, err)
+		}
+	}
+
+	if data, ok := raw[v.config.RolesClaim]; ok && v.config.RolesClaim != "" {
+		if err := json.Unmarshal(data, &roles); err != nil {
+			return nil, fmt.Errorf("%w: claim %s: %w", ErrMalformed, v.config.RolesClaim, err)
+		}
+	}
+
+	if issuer != v.config.Issuer {
+		return nil, ErrInvalidIssuer
+	}
+
+	if !slices.Contains(aud, v.config.Audience) {
+		return nil, ErrInvalidAudience
+	}
+
+	if subject == "" {
+		return nil, fmt.Errorf("%w: the sub claim is missing", ErrMalformed)
+	}
+
+	now := v.clock.Now()
+
+	expiresAt := unixTime(exp)
+	if exp == 0 || !now.Before(expiresAt.Add(v.config.Leeway)) {
+		return nil, ErrExpired
+	}
+
+	if nbf != 0 && now.Before(unixTime(nbf).Add(-v.config.Leeway)) {
+		return nil, ErrNotYetValid
+	}
+
+	return &Claims{
+		Issuer:    issuer,
+		Subject:   subject,
+		Scopes:    strings.Fields(scope),
+		Roles:     roles,
+		Tenant:    tenant,
+		Admin:     v.config.AdminRole != "" && slices.Contains(roles, v.config.AdminRole),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return ErrMalformed
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	return nil
+}
+
+func verifySignature(alg string, public crypto.PublicKey, signed string, signature []byte) error {
+	if len(alg) != 5 {
+		return fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+	}
+
+	hash, ok := hashes[alg[2:]]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+	}
+
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch family := alg[:2]; family {
+	case "RS", "PS":
+		key, ok := public.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %s for a non RSA key", ErrUnsupportedAlgorithm, alg)
+		}
+
+		var err error
+		if family == "RS" {
+			err = rsa.VerifyPKCS1v15(key, hash, digest, signature)
+		} else {
+			err = rsa.VerifyPSS(key, hash, digest, signature, nil)
+		}
+
+		if err != nil {
+			return ErrInvalidSignature
+		}
+	case "ES":
+		key, ok := public.(*ecdsa.PublicKey)
+		if !ok || key.Curve.Params().Name != curves[alg] {
+			return fmt.Errorf("%w: %s for a non %s key", ErrUnsupportedAlgorithm, alg, curves[alg])
+		}
+
+		// The signature is r and s, each padded to the size of the curve.
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return ErrInvalidSignature
+		}
+
+		r, s := new(big.Int).SetBytes(signature[:size]), new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return ErrInvalidSignature
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	clock "k8s.io/utils/clock/testing"
+)
+
+const (
+	testIssuer   = "https://id.example.com"
+	testAudience = "scheduler"
+)
+
+var (
+	rsaKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _  = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+)
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func testJWKS() []byte {
+	set := JWKS{Keys: []JWK{
+		{Kty: "RSA", Kid: "rsa", Use: "sig", N: encode(rsaKey.N.Bytes()), E: encode(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{Kty: "EC", Kid: "ec", Alg: "ES256", Crv: "P-256", X: encode(ecKey.X.FillBytes(make([]byte, 32))), Y: encode(ecKey.Y.FillBytes(make([]byte, 32)))},
+	}}
+
+	data, _ := json.Marshal(set)
+
+	return data
+}
+
+func sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	h, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	c, _ := json.Marshal(claims)
+	signed := encode(h) + "." + encode(c)
+
+	digest := crypto.SHA256.New()
+	digest.Write([]byte(signed))
+
+	var (
+		signature []byte
+		err       error
+	)
+
+	switch alg {
+	case "RS256":
+		signature, err = rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest.Sum(nil))
+	case "ES256":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, ecKey, digest.Sum(nil))
+		if err == nil {
+			signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	default:
+		signature = []byte("signature")
+	}
+
+	assert.NoError(t, err)
+
+	return signed + "." + encode(signature)
+}
+
+func validClaims(now time.Time) map[string]any {
+	return map[string]any{
+		"iss":    testIssuer,
+		"aud":    []string{"another", testAudience},
+		"sub":    "alice",
+		"exp":    now.Add(time.Hour).Unix(),
+		"nbf":    now.Add(-time.Minute).Unix(),
+		"scope":  "openid triggers:read",
+		"roles":  []string{"operator"},
+		"tenant": "0b3c5f0e-6f5a-4a70-9c38-54a4a4c6bd44",
+	}
+}
+
+func newFileVerifier(t *testing.T, clock *clock.FakeClock) *Verifier {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(path, testJWKS(), 0o600))
+
+	config := Config{Issuer: testIssuer, Audience: testAudience, TenantClaim: "tenant", RolesClaim: "roles", AdminRole: "scheduler-admin", Leeway: time.Minute}
+
+	return NewVerifier(config, NewKeySet(path, http.DefaultClient, clock), clock)
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+	verifier := newFileVerifier(t, clock.NewFakeClock(now))
+
+	for _, alg := range []string{"RS256", "ES256"} {
+		kid := map[string]string{"RS256": "rsa", "ES256": "ec"}[alg]
+
+		claims, err := verifier.Verify(sign(t, alg, kid, validClaims(now)))
+		assert.NoError(t, err, alg)
+		assert.Equal(t, "alice", claims.Subject)
+		assert.Equal(t, []string{"openid", "triggers:read"}, claims.Scopes)
+		assert.Equal(t, []string{"operator"}, claims.Roles)
+		assert.Equal(t, "0b3c5f0e-6f5a-4a70-9c38-54a4a4c6bd44", claims.Tenant)
+		assert.False(t, claims.Admin)
+	}
+
+	admin := validClaims(now)
+	admin["roles"] = []string{"operator", "scheduler-admin"}
+
+	claims, err := verifier.Verify(sign(t, "RS256", "rsa", admin))
+	assert.NoError(t, err)
+	assert.True(t, claims.Admin)
+}
+
+func TestVerifyClaims(t *testing.T) {
+	now := time.Now()
+	verifier := newFileVerifier(t, clock.NewFakeClock(now))
+
+	for expected, change := range map[error]func(map[string]any){
+		ErrInvalidIssuer:   func(c map[string]any) { c["iss"] = "https://evil.example.com" },
+		ErrInvalidAudience: func(c map[string]any) { c["aud"] = "another" },
+		ErrExpired:         func(c map[string]any) { c["exp"] = now.Add(-2 * time.Minute).Unix() },
+		ErrNotYetValid:     func(c map[string]any) { c["nbf"] = now.Add(2 * time.Minute).Unix() },
+		ErrMalformed:       func(c map[string]any) { delete(c, "sub") },
+	} {
+		claims := validClaims(now)
+		change(claims)
+
+		_, err := verifier.Verify(sign(t, "RS256", "rsa", claims))
+		assert.ErrorIs(t, err, expected)
+	}
+
+	claims := validClaims(now)
+	delete(claims, "exp")
+	_, err := verifier.Verify(sign(t, "RS256", "rsa", claims))
+	assert.ErrorIs(t, err, ErrExpired)
+}
+
+func TestVerifyLeeway(t *testing.T) {
+	now := time.Now()
+	verifier := newFileVerifier(t, clock.NewFakeClock(now))
+
+	claims := validClaims(now)
+	claims["exp"] = now.Add(-30 * time.Second).Unix()
+
+	_, err := verifier.Verify(sign(t, "RS256", "rsa", claims))
+	assert.NoError(t, err)
+}
+
+func TestVerifySignature(t *testing.T) {
+	now := time.Now()
+	verifier := newFileVerifier(t, clock.NewFakeClock(now))
+
+	token := sign(t, "RS256", "rsa", validClaims(now))
+	parts := strings.Split(token, ".")
+
+	claims := validClaims(now)
+	claims["sub"] = "mallory"
+	forged, _ := json.Marshal(claims)
+
+	_, err := verifier.Verify(parts[0] + "." + encode(forged) + "." + parts[2])
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = verifier.Verify(sign(t, "none", "rsa", validClaims(now)))
+	assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+
+	_, err = verifier.Verify(sign(t, "HS256", "rsa", validClaims(now)))
+	assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+
+	// The EC key is only for ES256.
+	_, err = verifier.Verify(sign(t, "RS256", "ec", validClaims(now)))
+	assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+
+	_, err = verifier.Verify(sign(t, "RS256", "unknown", validClaims(now)))
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	_, err = verifier.Verify("sk_notatoken")
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestIsJWT(t *testing.T) {
+	assert.True(t, IsJWT(sign(t, "RS256", "rsa", validClaims(time.Now()))))
+	assert.False(t, IsJWT("sk_development"))
+}
@@ -0,0 +1,110 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+	"github.com/skhaz/scheduler/workflow"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/clock"
+)
+
+// Purger deletes for good the triggers soft deleted more than retention ago,
+// along with their objects in the cluster. Until then, a deleted trigger can
+// be restored.
+type Purger struct {
+	registry  *repository.RepositoryRegistry
+	wf        workflow.Interface
+	clock     clock.Clock
+	interval  time.Duration
+	retention time.Duration
+	logger    *zap.Logger
+}
+
+func NewPurger(registry *repository.RepositoryRegistry, wf workflow.Interface, clock clock.Clock, interval, retention time.Duration, logger *zap.Logger) *Purger {
+	return &Purger{
+		registry:  registry,
+		wf:        wf,
+		clock:     clock,
+		interval:  interval,
+		retention: retention,
+		logger:    logger,
+	}
+}
+
+// Start purges every interval until ctx is done.
+func (p *Purger) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(context.Context) { p.Purge() }, p.interval)
+
+	return nil
+}
+
+// Purge deletes the triggers whose retention is over and returns their IDs.
+// A trigger whose objects cannot be removed from the cluster is kept, so the
+// next run tries again.
+func (p *Purger) Purge() []string {
+	var purged []string
+
+	triggerRepository := p.registry.MustRepository("TriggerRepository")
+	if _, ok := triggerRepository.(repository.Restorer); !ok {
+		p.logger.Error("failed to purge triggers", zap.Error(repository.ErrNoRestorer))
+		return nil
+	}
+
+	before := p.clock.Now().Add(-p.retention)
+
+	var after *model.Trigger
+	for {
+		e, err := triggerRepository.List(time.Time{}, pageSize, repository.WhereDeletedBefore(before), repository.AfterTrigger(after))
+		if err != nil {
+			p.logger.Error("failed to list deleted triggers", zap.Error(err))
+			break
+		}
+
+		triggers := e.(model.TriggerCollection)
+		for _, trigger := range triggers {
+			if err := p.purge(trigger); err != nil {
+				p.logger.Error("failed to purge trigger", zap.Stringer("trigger", trigger.ID), zap.Error(err))
+				continue
+			}
+
+			purged = append(purged, trigger.ID.String())
+		}
+
+		last, ok := model.Last(triggers)
+		if !ok || len(triggers) < pageSize {
+			break
+		}
+
+		after = last
+	}
+
+	p.logger.Info("purged triggers", zap.Strings("purged", purged))
+
+	return purged
+}
+
+func (p *Purger) purge(trigger *model.Trigger) error {
+	manifest, err := p.wf.Render(trigger)
+	if err != nil {
+		return err
+	}
+
+	return p.registry.Transaction(func(registry *repository.RepositoryRegistry) error {
+		ok, err := registry.MustRepository("TriggerRepository").(repository.Restorer).Purge(trigger.ID)
+		if err != nil || !ok {
+			return err
+		}
+
+		results, err := p.wf.Apply(manifest, workflow.Displace)
+
+		for _, result := range results {
+			p.logger.Info("applied manifest", zap.String("op", string(workflow.Displace)), zap.Stringer("result", result))
+		}
+
+		return err
+	})
+}
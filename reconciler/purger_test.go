@@ -0,0 +1,47 @@
+package reconciler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/skhaz/scheduler/repository"
+	"github.com/skhaz/scheduler/workflow"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func newPurger(r *TriggerRepository, wf workflow.Interface) *Purger {
+	registry := repository.NewRepositoryRegistry(nil, r)
+
+	return NewPurger(registry, wf, clocktesting.NewFakeClock(time.Now()), time.Millisecond, time.Hour, zap.NewNop())
+}
+
+func TestPurge(t *testing.T) {
+	triggers := newTriggers(2)
+	r := &TriggerRepository{triggers: triggers}
+	wf := &Workflow{}
+
+	purged := newPurger(r, wf).Purge()
+	assert.Equal(t, []string{triggers[0].ID.String(), triggers[1].ID.String()}, purged)
+	assert.Equal(t, []any{triggers[0].ID, triggers[1].ID}, r.purged)
+	assert.Equal(t, 2, r.scopes)
+	assert.Equal(t, purged, wf.applied[workflow.Displace])
+}
+
+func TestPurgeApplyError(t *testing.T) {
+	triggers := newTriggers(1)
+	wf := &Workflow{applyErr: errors.New("the cluster is unreachable")}
+
+	purged := newPurger(&TriggerRepository{triggers: triggers}, wf).Purge()
+	assert.Empty(t, purged)
+}
+
+func TestPurgeListError(t *testing.T) {
+	wf := &Workflow{}
+
+	purged := newPurger(&TriggerRepository{err: errors.New("connection refused")}, wf).Purge()
+	assert.Empty(t, purged)
+	assert.Empty(t, wf.applied)
+}
@@ -0,0 +1,187 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+	"github.com/skhaz/scheduler/workflow"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	pageSize = 100
+
+	// grace is how old a namespace must be to be pruned, so the namespace of
+	// a trigger whose creation has not been committed yet is left alone.
+	grace = 10 * time.Minute
+)
+
+type Interface interface {
+	Last() (*model.Reconciliation, error)
+}
+
+// Reconciler periodically compares the triggers table with the cluster. It
+// recreates missing objects, replaces drifted ones and prunes namespaces left
+// behind by triggers that no longer exist. Soft deleted triggers still exist
+// until purged, and are kept suspended. The last reconciliation is kept in the
+// database, so it can be read from any replica and not only from the leader.
+type Reconciler struct {
+	registry *repository.RepositoryRegistry
+	wf       workflow.Interface
+	interval time.Duration
+	logger   *zap.Logger
+}
+
+func NewReconciler(registry *repository.RepositoryRegistry, wf workflow.Interface, interval time.Duration, logger *zap.Logger) *Reconciler {
+	return &Reconciler{
+		registry: registry,
+		wf:       wf,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Start reconciles every interval until ctx is done, which makes the
+// reconciler a controller-runtime manager.Runnable.
+func (r *Reconciler) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(context.Context) { r.Reconcile() }, r.interval)
+
+	return nil
+}
+
+// Last returns the last reconciliation to finish, on whichever replica it
+// ran, or gorm.ErrRecordNotFound when none has finished yet.
+func (r *Reconciler) Last() (*model.Reconciliation, error) {
+	e, err := r.registry.MustRepository("ReconciliationRepository").Get(model.LastReconciliation)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.(*model.Reconciliation), nil
+}
+
+func (r *Reconciler) Reconcile() *model.Reconciliation {
+	result := &model.Reconciliation{ID: model.LastReconciliation, StartedAt: time.Now()}
+
+	known, complete := map[string]bool{}, true
+	triggerRepository := r.registry.MustRepository("TriggerRepository")
+
+	var after *model.Trigger
+	for {
+		// Deleted triggers keep their suspended objects until they are purged.
+		e, err := triggerRepository.List(time.Time{}, pageSize, repository.WithDeleted, repository.AfterTrigger(after))
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			complete = false
+
+			break
+		}
+
+		triggers := e.(model.TriggerCollection)
+		for _, trigger := range triggers {
+			known[trigger.ID.String()] = true
+			result.Checked++
+
+			if err := r.reconcile(trigger, result); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%v: %v", trigger.ID, err))
+			}
+		}
+
+		last, ok := model.Last(triggers)
+		if !ok || len(triggers) < pageSize {
+			break
+		}
+
+		after = last
+	}
+
+	// Without the full list of triggers, every namespace would look orphaned.
+	if complete {
+		if err := r.prune(known, result); err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		}
+	}
+
+	result.FinishedAt = time.Now()
+
+	r.logger.Info("reconciled triggers",
+		zap.Int("checked", result.Checked),
+		zap.Strings("created", result.Created),
+		zap.Strings("updated", result.Updated),
+		zap.Strings("removed", result.Removed),
+		zap.Strings("errors", result.Errors),
+	)
+
+	if _, err := r.registry.MustRepository("ReconciliationRepository").Create(result); err != nil {
+		r.logger.Error("failed to save reconciliation", zap.Error(err))
+	}
+
+	return result
+}
+
+func (r *Reconciler) reconcile(trigger *model.Trigger, result *model.Reconciliation) error {
+	manifest, err := r.wf.Render(trigger)
+	if err != nil {
+		return err
+	}
+
+	missing, drifted, err := r.wf.Diff(manifest)
+	if err != nil {
+		return err
+	}
+
+	if len(missing) > 0 {
+		if err := r.apply(missing, workflow.Deploy); err != nil {
+			return err
+		}
+
+		result.Created = append(result.Created, trigger.ID.String())
+	}
+
+	if len(drifted) > 0 {
+		if err := r.apply(drifted, workflow.Replace); err != nil {
+			return err
+		}
+
+		result.Updated = append(result.Updated, trigger.ID.String())
+	}
+
+	return nil
+}
+
+func (r *Reconciler) apply(manifest []byte, op workflow.Operation) error {
+	results, err := r.wf.Apply(manifest, op)
+
+	for _, result := range results {
+		r.logger.Info("applied manifest", zap.String("op", string(op)), zap.Stringer("result", result))
+	}
+
+	return err
+}
+
+func (r *Reconciler) prune(known map[string]bool, result *model.Reconciliation) error {
+	namespaces, err := r.wf.Namespaces(time.Now().Add(-grace))
+	if err != nil {
+		return err
+	}
+
+	for _, namespace := range namespaces {
+		if known[namespace] {
+			continue
+		}
+
+		if err := r.wf.Prune(namespace); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%v: %v", namespace, err))
+
+			continue
+		}
+
+		result.Removed = append(result.Removed, namespace)
+	}
+
+	return nil
+}
@@ -0,0 +1,257 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+	"github.com/skhaz/scheduler/workflow"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type TriggerRepository struct {
+	err      error
+	triggers model.TriggerCollection
+	scopes   int
+	purged   []any
+}
+
+func (r *TriggerRepository) Configure(db *gorm.DB) {
+}
+
+func (r *TriggerRepository) List(after time.Time, limit int, scopes ...repository.Scope) (any, error) {
+	r.scopes = len(scopes)
+	return r.triggers, r.err
+}
+
+func (r *TriggerRepository) Get(id any) (any, error) {
+	return nil, r.err
+}
+
+func (r *TriggerRepository) Create(entity any) (any, error) {
+	return nil, r.err
+}
+
+func (r *TriggerRepository) Update(id any, entity any) (bool, error) {
+	return false, r.err
+}
+
+func (r *TriggerRepository) Delete(id any) (bool, error) {
+	return false, r.err
+}
+
+func (r *TriggerRepository) Deleted(id any) (any, error) {
+	return nil, r.err
+}
+
+func (r *TriggerRepository) Restore(id any) (bool, error) {
+	return false, r.err
+}
+
+func (r *TriggerRepository) Purge(id any) (bool, error) {
+	if r.err != nil {
+		return false, r.err
+	}
+
+	r.purged = append(r.purged, id)
+
+	return true, nil
+}
+
+// ReconciliationRepository keeps the last reconciliation created, as the
+// database does.
+type ReconciliationRepository struct {
+	mu   sync.Mutex
+	last *model.Reconciliation
+}
+
+func (r *ReconciliationRepository) Configure(db *gorm.DB) {
+}
+
+func (r *ReconciliationRepository) List(after time.Time, limit int, scopes ...repository.Scope) (any, error) {
+	return nil, nil
+}
+
+func (r *ReconciliationRepository) Get(id any) (any, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.last == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return r.last, nil
+}
+
+func (r *ReconciliationRepository) Create(entity any) (any, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.last = entity.(*model.Reconciliation)
+
+	return r.last, nil
+}
+
+func (r *ReconciliationRepository) Update(id any, entity any) (bool, error) {
+	return false, nil
+}
+
+func (r *ReconciliationRepository) Delete(id any) (bool, error) {
+	return false, nil
+}
+
+// Workflow reports the manifests listed in missing and drifted as out of
+// sync. The manifest of a trigger is its ID.
+type Workflow struct {
+	workflow.Interface
+
+	missing    map[string]bool
+	drifted    map[string]bool
+	namespaces []string
+	before     time.Time
+	applyErr   error
+	applied    map[workflow.Operation][]string
+	pruned     []string
+	executions map[string]model.ExecutionCollection
+}
+
+func (wf *Workflow) Render(trigger *model.Trigger) ([]byte, error) {
+	return []byte(trigger.ID.String()), nil
+}
+
+func (wf *Workflow) Diff(manifest []byte) ([]byte, []byte, error) {
+	var missing, drifted []byte
+
+	if wf.missing[string(manifest)] {
+		missing = manifest
+	}
+
+	if wf.drifted[string(manifest)] {
+		drifted = manifest
+	}
+
+	return missing, drifted, nil
+}
+
+func (wf *Workflow) Apply(manifest []byte, op workflow.Operation) ([]workflow.Result, error) {
+	if wf.applyErr != nil {
+		return nil, wf.applyErr
+	}
+
+	if wf.applied == nil {
+		wf.applied = map[workflow.Operation][]string{}
+	}
+
+	wf.applied[op] = append(wf.applied[op], string(manifest))
+
+	return []workflow.Result{{Kind: "CronWorkflow", Name: string(manifest), Action: workflow.Applied}}, nil
+}
+
+func (wf *Workflow) Executions(namespace string) (model.ExecutionCollection, error) {
+	return wf.executions[namespace], nil
+}
+
+func (wf *Workflow) Namespaces(before time.Time) ([]string, error) {
+	wf.before = before
+
+	return wf.namespaces, nil
+}
+
+func (wf *Workflow) Prune(namespace string) error {
+	wf.pruned = append(wf.pruned, namespace)
+
+	return nil
+}
+
+func newTriggers(n int) model.TriggerCollection {
+	triggers := make(model.TriggerCollection, 0, n)
+	for i := 0; i < n; i++ {
+		triggers = append(triggers, &model.Trigger{ID: uuid.New(), CreatedAt: time.Now()})
+	}
+
+	return triggers
+}
+
+func newReconciler(r *TriggerRepository, wf workflow.Interface) *Reconciler {
+	registry := repository.NewRepositoryRegistry(nil, r, &ReconciliationRepository{})
+
+	return NewReconciler(registry, wf, time.Millisecond, zap.NewNop())
+}
+
+func TestReconcile(t *testing.T) {
+	triggers := newTriggers(3)
+	created, updated, orphan := triggers[0].ID.String(), triggers[1].ID.String(), uuid.NewString()
+
+	wf := &Workflow{
+		missing:    map[string]bool{created: true},
+		drifted:    map[string]bool{updated: true},
+		namespaces: []string{created, updated, triggers[2].ID.String(), orphan},
+	}
+
+	rec := newReconciler(&TriggerRepository{triggers: triggers}, wf)
+
+	_, err := rec.Last()
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	result := rec.Reconcile()
+	assert.Equal(t, 3, result.Checked)
+	assert.Equal(t, []string{created}, result.Created)
+	assert.Equal(t, []string{updated}, result.Updated)
+	assert.Equal(t, []string{orphan}, result.Removed)
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, []string{created}, wf.applied[workflow.Deploy])
+	assert.Equal(t, []string{updated}, wf.applied[workflow.Replace])
+	assert.Equal(t, []string{orphan}, wf.pruned)
+	assert.WithinDuration(t, time.Now().Add(-grace), wf.before, time.Second)
+
+	last, err := rec.Last()
+	assert.NoError(t, err)
+	assert.Same(t, result, last)
+	assert.Equal(t, model.LastReconciliation, last.ID)
+}
+
+func TestReconcileApplyError(t *testing.T) {
+	triggers := newTriggers(1)
+
+	wf := &Workflow{
+		missing:  map[string]bool{triggers[0].ID.String(): true},
+		applyErr: errors.New("the cluster is unreachable"),
+	}
+
+	result := newReconciler(&TriggerRepository{triggers: triggers}, wf).Reconcile()
+	assert.Empty(t, result.Created)
+	assert.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0], triggers[0].ID.String())
+}
+
+func TestReconcileListError(t *testing.T) {
+	wf := &Workflow{namespaces: []string{uuid.NewString()}}
+
+	result := newReconciler(&TriggerRepository{err: errors.New("connection refused")}, wf).Reconcile()
+	assert.Equal(t, []string{"connection refused"}, result.Errors)
+	assert.Empty(t, result.Removed)
+	assert.Empty(t, wf.pruned)
+}
+
+func TestStart(t *testing.T) {
+	rec := newReconciler(&TriggerRepository{}, &Workflow{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- rec.Start(ctx) }()
+
+	assert.Eventually(t, func() bool {
+		_, err := rec.Last()
+		return err == nil
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,94 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+	"github.com/skhaz/scheduler/workflow"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Recorder copies the executions found in the cluster to the executions
+// table, so their history survives the garbage collection done by the cluster
+// and the API can read it from the table alone.
+type Recorder struct {
+	registry *repository.RepositoryRegistry
+	wf       workflow.Interface
+	interval time.Duration
+	logger   *zap.Logger
+}
+
+func NewRecorder(registry *repository.RepositoryRegistry, wf workflow.Interface, interval time.Duration, logger *zap.Logger) *Recorder {
+	return &Recorder{
+		registry: registry,
+		wf:       wf,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Start records every interval until ctx is done.
+func (r *Recorder) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(context.Context) { r.Record() }, r.interval)
+
+	return nil
+}
+
+// Record goes through the triggers once and returns how many executions it
+// recorded. A trigger whose executions cannot be read is skipped, so the next
+// run tries again.
+func (r *Recorder) Record() int {
+	recorded := 0
+
+	triggerRepository := r.registry.MustRepository("TriggerRepository")
+
+	var after *model.Trigger
+	for {
+		e, err := triggerRepository.List(time.Time{}, pageSize, repository.AfterTrigger(after))
+		if err != nil {
+			r.logger.Error("failed to list triggers", zap.Error(err))
+			break
+		}
+
+		triggers := e.(model.TriggerCollection)
+		for _, trigger := range triggers {
+			n, err := r.record(trigger)
+			if err != nil {
+				r.logger.Error("failed to record executions", zap.Stringer("trigger", trigger.ID), zap.Error(err))
+			}
+
+			recorded += n
+		}
+
+		last, ok := model.Last(triggers)
+		if !ok || len(triggers) < pageSize {
+			break
+		}
+
+		after = last
+	}
+
+	return recorded
+}
+
+func (r *Recorder) record(trigger *model.Trigger) (int, error) {
+	executions, err := r.wf.Executions(trigger.ID.String())
+	if err != nil {
+		return 0, err
+	}
+
+	history := r.registry.MustRepository("ExecutionRepository")
+
+	for i, execution := range executions {
+		execution.TriggerID = trigger.ID
+
+		if _, err := history.Create(execution); err != nil {
+			return i, err
+		}
+	}
+
+	return len(executions), nil
+}
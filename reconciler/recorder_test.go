@@ -0,0 +1,76 @@
+package reconciler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ExecutionRepository struct {
+	err     error
+	created []*model.Execution
+}
+
+func (r *ExecutionRepository) Configure(db *gorm.DB) {
+}
+
+func (r *ExecutionRepository) List(after time.Time, limit int, scopes ...repository.Scope) (any, error) {
+	return model.ExecutionCollection(r.created), r.err
+}
+
+func (r *ExecutionRepository) Get(id any) (any, error) {
+	return nil, r.err
+}
+
+func (r *ExecutionRepository) Create(entity any) (any, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	r.created = append(r.created, entity.(*model.Execution))
+
+	return entity, nil
+}
+
+func (r *ExecutionRepository) Update(id any, entity any) (bool, error) {
+	return false, r.err
+}
+
+func (r *ExecutionRepository) Delete(id any) (bool, error) {
+	return false, r.err
+}
+
+func TestRecord(t *testing.T) {
+	triggers := newTriggers(2)
+	executions := &ExecutionRepository{}
+	wf := &Workflow{executions: map[string]model.ExecutionCollection{
+		triggers[0].ID.String(): {{ID: uuid.New(), Phase: model.Succeeded}, {ID: uuid.New(), Phase: model.Running}},
+	}}
+
+	registry := repository.NewRepositoryRegistry(nil, &TriggerRepository{triggers: triggers}, executions)
+
+	recorded := NewRecorder(registry, wf, time.Millisecond, zap.NewNop()).Record()
+	assert.Equal(t, 2, recorded)
+	assert.Len(t, executions.created, 2)
+	assert.Equal(t, triggers[0].ID, executions.created[0].TriggerID)
+	assert.Equal(t, triggers[0].ID, executions.created[1].TriggerID)
+}
+
+func TestRecordError(t *testing.T) {
+	triggers := newTriggers(1)
+	wf := &Workflow{executions: map[string]model.ExecutionCollection{
+		triggers[0].ID.String(): {{ID: uuid.New(), Phase: model.Succeeded}},
+	}}
+
+	registry := repository.NewRepositoryRegistry(nil, &TriggerRepository{triggers: triggers}, &ExecutionRepository{err: errors.New("connection refused")})
+
+	recorded := NewRecorder(registry, wf, time.Millisecond, zap.NewNop()).Record()
+	assert.Zero(t, recorded)
+}
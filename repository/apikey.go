@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/skhaz/scheduler/model"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository struct {
+	GormRepository
+}
+
+func WhereHash(hash string) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("hash = ?", hash)
+	}
+}
+
+func (r *APIKeyRepository) List(after time.Time, limit int, scopes ...Scope) (any, error) {
+	var c model.APIKeyCollection
+
+	err := r.db.Scopes(scopes...).Order("created_at").Where("created_at > ?", after).Limit(limit).Find(&c).Error
+
+	return c, err
+}
+
+func (r *APIKeyRepository) Get(id any) (any, error) {
+	var k *model.APIKey
+
+	err := r.db.Where("id = ?", id).First(&k).Error
+
+	return k, err
+}
+
+func (r *APIKeyRepository) Create(entity any) (any, error) {
+	k := entity.(*model.APIKey)
+
+	err := r.db.Create(k).Error
+
+	return k, err
+}
+
+func (r *APIKeyRepository) Update(id any, entity any) (bool, error) {
+	k := entity.(*model.APIKey)
+
+	if err := r.db.Model(k).Where("id = ?", id).Updates(k).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *APIKeyRepository) Delete(id any) (bool, error) {
+	if err := r.db.Delete(&model.APIKey{}, "id = ?", id).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
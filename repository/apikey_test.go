@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupAPIKeys() (conn *sql.DB, mock sqlmock.Sqlmock, repository APIKeyRepository) {
+	conn, mock, db := mockDB()
+
+	repository = APIKeyRepository{}
+
+	repository.Configure(db)
+
+	return
+}
+
+func TestListAPIKeysByHash(t *testing.T) {
+	conn, mock, repository := setupAPIKeys()
+	defer conn.Close()
+
+	hash := model.HashAPIKey("sk_test")
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "api_keys" WHERE created_at > $1 AND hash = $2 ORDER BY created_at LIMIT 1`)).
+		WithArgs(AnyTime{}, hash).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "hash", "scopes"}).AddRow(uuid.New(), hash, `["triggers:read"]`))
+
+	c, err := repository.List(time.Time{}, 1, WhereHash(hash))
+	assert.NoError(t, err)
+	assert.Len(t, c, 1)
+	assert.Equal(t, []string{model.TriggersRead}, c.(model.APIKeyCollection)[0].Scopes)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	conn, mock, repository := setupAPIKeys()
+	defer conn.Close()
+
+	id := uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "api_keys" SET "revoked_at"=$1,"updated_at"=$2 WHERE id = $3`)).
+		WithArgs(now, AnyTime{}, id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ok, err := repository.Update(id, &model.APIKey{RevokedAt: &now})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"gorm.io/gorm"
+)
+
+var ErrAppendOnly = errors.New("audit entries cannot be changed")
+
+type AuditEntryRepository struct {
+	GormRepository
+}
+
+// ScopeTenant restricts every query to the entries about the resources of the
+// tenant.
+func (r *AuditEntryRepository) ScopeTenant(id uuid.UUID) {
+	r.db = r.db.Where("audit_entries.tenant_id = ?", id).Session(&gorm.Session{})
+}
+
+func WhereResource(resource string) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("resource = ?", resource)
+	}
+}
+
+func WhereResourceID(id any) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("resource_id = ?", id)
+	}
+}
+
+func WhereActor(actor string) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("actor = ?", actor)
+	}
+}
+
+func WhereAction(action string) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("action = ?", action)
+	}
+}
+
+func (r *AuditEntryRepository) List(after time.Time, limit int, scopes ...Scope) (any, error) {
+	var c model.AuditEntryCollection
+
+	err := r.db.Scopes(scopes...).Order("created_at").Where("created_at > ?", after).Limit(limit).Find(&c).Error
+
+	return c, err
+}
+
+func (r *AuditEntryRepository) Get(id any) (any, error) {
+	var e *model.AuditEntry
+
+	err := r.db.Where("id = ?", id).First(&e).Error
+
+	return e, err
+}
+
+func (r *AuditEntryRepository) Create(entity any) (any, error) {
+	e := entity.(*model.AuditEntry)
+
+	err := r.db.Create(e).Error
+
+	return e, err
+}
+
+// Update fails, as the audit log is append only.
+func (r *AuditEntryRepository) Update(id any, entity any) (bool, error) {
+	return false, ErrAppendOnly
+}
+
+// Delete fails, as the audit log is append only.
+func (r *AuditEntryRepository) Delete(id any) (bool, error) {
+	return false, ErrAppendOnly
+}
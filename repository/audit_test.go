@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupAuditEntries() (conn *sql.DB, mock sqlmock.Sqlmock, repository AuditEntryRepository) {
+	conn, mock, db := mockDB()
+
+	repository = AuditEntryRepository{}
+
+	repository.Configure(db)
+
+	return
+}
+
+func TestListAuditEntries(t *testing.T) {
+	conn, mock, repository := setupAuditEntries()
+	defer conn.Close()
+
+	tenant, trigger := uuid.New(), uuid.New()
+	repository.ScopeTenant(tenant)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "audit_entries" WHERE audit_entries.tenant_id = $1 AND created_at > $2 AND resource = $3 AND resource_id = $4 AND action = $5 ORDER BY created_at LIMIT 10`)).
+		WithArgs(tenant, AnyTime{}, model.ResourceTrigger, trigger, model.ActionPause).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "resource", "resource_id", "action", "diff"}).AddRow(uuid.New(), model.ResourceTrigger, trigger, model.ActionPause, `[{"field":"enabled","before":true,"after":false}]`))
+
+	c, err := repository.List(time.Time{}, 10, WhereResource(model.ResourceTrigger), WhereResourceID(trigger), WhereAction(model.ActionPause))
+	assert.NoError(t, err)
+	assert.Len(t, c, 1)
+	assert.Equal(t, []model.Change{{Field: "enabled", Before: true, After: false}}, c.(model.AuditEntryCollection)[0].Diff)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuditEntriesAppendOnly(t *testing.T) {
+	conn, mock, repository := setupAuditEntries()
+	defer conn.Close()
+
+	_, err := repository.Update(uuid.New(), &model.AuditEntry{Actor: "mallory"})
+	assert.ErrorIs(t, err, ErrAppendOnly)
+
+	_, err = repository.Delete(uuid.New())
+	assert.ErrorIs(t, err, ErrAppendOnly)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
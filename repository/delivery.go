@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/skhaz/scheduler/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrNoOutbox = errors.New("deliveries are not kept in an outbox")
+
+// Outbox is implemented by the repositories that keep the events waiting to be
+// delivered to subscriptions.
+type Outbox interface {
+	Publish(event *model.Event) error
+	Due(now time.Time, limit int) (model.DeliveryCollection, error)
+}
+
+// DeliveryRepository is the outbox of the lifecycle events of triggers. As
+// events are published in the transaction that changes the trigger, an event
+// is delivered if and only if its change is committed.
+type DeliveryRepository struct {
+	GormRepository
+}
+
+const publishQuery = `INSERT INTO deliveries (subscription_id, event_id, next_attempt_at, created_at, updated_at)
+SELECT id, ?, ?, ?, ? FROM subscriptions
+WHERE enabled AND events @> jsonb_build_array(?::text) AND (tenant_id IS NULL OR tenant_id = ?)`
+
+func WhereSubscription(id any) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("subscription_id = ?", id)
+	}
+}
+
+// Publish stores event along with a delivery to each enabled subscription to
+// its type, due right away. Only the subscriptions of the tenant of the event
+// and the ones of no tenant are told about it.
+func (r *DeliveryRepository) Publish(event *model.Event) error {
+	if err := r.db.Create(event).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	return r.db.Exec(publishQuery, event.ID, now, now, now, event.Type, event.TenantID).Error
+}
+
+// Due returns up to limit deliveries that are still being delivered and whose
+// next attempt is due at now, along with their subscription and event.
+func (r *DeliveryRepository) Due(now time.Time, limit int) (model.DeliveryCollection, error) {
+	var c model.DeliveryCollection
+
+	err := r.db.Preload("Subscription").Preload("Event").
+		Where("state = ? AND next_attempt_at <= ?", model.Delivering, now).
+		Order("next_attempt_at").Limit(limit).Find(&c).Error
+
+	return c, err
+}
+
+func (r *DeliveryRepository) List(after time.Time, limit int, scopes ...Scope) (any, error) {
+	var c model.DeliveryCollection
+
+	err := r.db.Scopes(scopes...).Order("created_at").Where("created_at > ?", after).Limit(limit).Find(&c).Error
+
+	return c, err
+}
+
+func (r *DeliveryRepository) Get(id any) (any, error) {
+	var d *model.Delivery
+
+	err := r.db.Preload("Event").Where("id = ?", id).First(&d).Error
+
+	return d, err
+}
+
+func (r *DeliveryRepository) Create(entity any) (any, error) {
+	d := entity.(*model.Delivery)
+
+	err := r.db.Omit(clause.Associations).Create(d).Error
+
+	return d, err
+}
+
+func (r *DeliveryRepository) Update(id any, entity any) (bool, error) {
+	d := entity.(*model.Delivery)
+
+	// Every column but the keys is written, so the error of a failed attempt
+	// is cleared by a later successful one.
+	if err := r.db.Model(d).Select("*").Omit(clause.Associations, "id", "subscription_id", "event_id", "created_at").Where("id = ?", id).Updates(d).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *DeliveryRepository) Delete(id any) (bool, error) {
+	if err := r.db.Delete(&model.Delivery{}, "id = ?", id).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupDeliveries() (conn *sql.DB, mock sqlmock.Sqlmock, repository DeliveryRepository) {
+	conn, mock, db := mockDB()
+
+	repository = DeliveryRepository{}
+
+	repository.Configure(db)
+
+	return
+}
+
+func TestPublishEvent(t *testing.T) {
+	conn, mock, repository := setupDeliveries()
+	defer conn.Close()
+
+	id := uuid.New()
+	tenantID := uuid.New()
+	trigger := &model.Trigger{ID: uuid.New(), TenantID: &tenantID, Name: "trigger"}
+	event := model.NewEvent(model.TriggerCreated, trigger)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "events" ("type","trigger_id","tenant_id","trigger","created_at") VALUES ($1,$2,$3,$4,$5) RETURNING "id"`)).
+		WithArgs(model.TriggerCreated, trigger.ID, trigger.TenantID, sqlmock.AnyArg(), AnyTime{}).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+	mock.ExpectCommit()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO deliveries`)+".*"+regexp.QuoteMeta(`WHERE enabled AND events @> jsonb_build_array($5::text) AND (tenant_id IS NULL OR tenant_id = $6)`)).
+		WithArgs(id, AnyTime{}, AnyTime{}, AnyTime{}, model.TriggerCreated, trigger.TenantID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	assert.NoError(t, repository.Publish(event))
+	assert.Equal(t, id, event.ID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDueDeliveries(t *testing.T) {
+	conn, mock, repository := setupDeliveries()
+	defer conn.Close()
+
+	id, subscriptionID, eventID, now := uuid.New(), uuid.New(), uuid.New(), time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "deliveries" WHERE state = $1 AND next_attempt_at <= $2 ORDER BY next_attempt_at LIMIT 10`)).
+		WithArgs(model.Delivering, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "subscription_id", "event_id", "state"}).AddRow(id, subscriptionID, eventID, model.Delivering))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "events" WHERE "events"."id" = $1`)).
+		WithArgs(eventID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type", "trigger"}).AddRow(eventID, model.TriggerDeleted, `{"name":"trigger"}`))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "subscriptions" WHERE "subscriptions"."id" = $1`)).
+		WithArgs(subscriptionID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url"}).AddRow(subscriptionID, "https://example.com"))
+
+	deliveries, err := repository.Due(now, 10)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, "https://example.com", deliveries[0].Subscription.URL)
+	assert.Equal(t, model.TriggerDeleted, deliveries[0].Event.Type)
+	assert.Equal(t, "trigger", deliveries[0].Event.Trigger.Name)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListDeliveries(t *testing.T) {
+	conn, mock, repository := setupDeliveries()
+	defer conn.Close()
+
+	subscriptionID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "deliveries" WHERE created_at > $1 AND subscription_id = $2 ORDER BY created_at LIMIT 1`)).
+		WithArgs(AnyTime{}, subscriptionID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "state", "status_code"}).AddRow(uuid.New(), model.Undelivered, 503))
+
+	c, err := repository.List(time.Now(), 1, WhereSubscription(subscriptionID))
+	assert.NoError(t, err)
+	assert.Len(t, c, 1)
+	assert.Equal(t, 503, c.(model.DeliveryCollection)[0].StatusCode)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateDelivery(t *testing.T) {
+	conn, mock, repository := setupDeliveries()
+	defer conn.Close()
+
+	id := uuid.New()
+	now := time.Now()
+	delivery := &model.Delivery{
+		ID:           id,
+		Subscription: &model.Subscription{ID: uuid.New()},
+		Event:        &model.Event{ID: uuid.New()},
+		State:        model.Delivered,
+		Attempts:     1,
+		StatusCode:   204,
+		DeliveredAt:  &now,
+	}
+
+	// The error of the previous attempt is cleared.
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "deliveries" SET "state"=$1,"attempts"=$2,"next_attempt_at"=$3,"status_code"=$4,"last_error"=$5,"delivered_at"=$6,"updated_at"=$7 WHERE id = $8 AND "id" = $9`)).
+		WithArgs(model.Delivered, 1, AnyTime{}, 204, "", now, AnyTime{}, id, id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ok, err := repository.Update(id, delivery)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/skhaz/scheduler/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// History is implemented by the repositories that keep the past executions
+// of the triggers.
+type History interface {
+	Streak(trigger any, before time.Time) (int, error)
+}
+
+type ExecutionRepository struct {
+	GormRepository
+}
+
+const streakQuery = `SELECT count(*) FROM executions
+WHERE trigger_id = ? AND finished_at IS NOT NULL AND phase <> ? AND started_at < ? AND started_at > COALESCE(
+	(SELECT max(started_at) FROM executions WHERE trigger_id = ? AND phase = ? AND started_at < ?),
+	'-infinity'
+)`
+
+func WhereTrigger(id any) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("trigger_id = ?", id)
+	}
+}
+
+// WhereFinishedSince restricts to the finished executions recorded since t.
+func WhereFinishedSince(t time.Time) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("finished_at IS NOT NULL AND updated_at >= ?", t)
+	}
+}
+
+// Streak counts the failed executions of trigger that started before the
+// given time since the last one that succeeded.
+func (r *ExecutionRepository) Streak(trigger any, before time.Time) (int, error) {
+	var n int64
+
+	err := r.db.Raw(streakQuery, trigger, model.Succeeded, before, trigger, model.Succeeded, before).Scan(&n).Error
+
+	return int(n), err
+}
+
+func (r *ExecutionRepository) List(after time.Time, limit int, scopes ...Scope) (any, error) {
+	var c model.ExecutionCollection
+
+	err := r.db.Scopes(scopes...).Order("started_at").Where("started_at > ?", after).Limit(limit).Find(&c).Error
+
+	return c, err
+}
+
+func (r *ExecutionRepository) Get(id any) (any, error) {
+	var e *model.Execution
+
+	err := r.db.Where("id = ?", id).First(&e).Error
+
+	return e, err
+}
+
+// Create inserts the execution or, when it was already recorded by a previous
+// synchronization, refreshes it with the latest state seen in the cluster.
+func (r *ExecutionRepository) Create(entity any) (any, error) {
+	e := entity.(*model.Execution)
+
+	err := r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(e).Error
+
+	return e, err
+}
+
+func (r *ExecutionRepository) Update(id any, entity any) (bool, error) {
+	e := entity.(*model.Execution)
+
+	if err := r.db.Model(e).Where("id = ?", id).Updates(e).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *ExecutionRepository) Delete(id any) (bool, error) {
+	if err := r.db.Delete(&model.Execution{}, "id = ?", id).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/thanhpk/randstr"
+	"gorm.io/gorm"
+)
+
+func setupExecutions() (conn *sql.DB, mock sqlmock.Sqlmock, repository ExecutionRepository) {
+	conn, mock, db := mockDB()
+
+	repository = ExecutionRepository{}
+
+	repository.Configure(db)
+
+	return
+}
+
+func TestListExecutions(t *testing.T) {
+	var err error
+	conn, mock, repository := setupExecutions()
+	defer conn.Close()
+
+	triggerID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "executions" WHERE started_at > $1 AND trigger_id = $2 ORDER BY started_at LIMIT 1`)).
+		WithArgs(AnyTime{}, triggerID).
+		WillReturnRows(sqlmock.NewRows([]string{}))
+
+	var arr any
+	arr, err = repository.List(time.Now(), 1, WhereTrigger(triggerID))
+	assert.NoError(t, err)
+	assert.NotNil(t, arr)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestListFinishedExecutions(t *testing.T) {
+	conn, mock, repository := setupExecutions()
+	defer conn.Close()
+
+	triggerID, since := uuid.New(), time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "executions" WHERE started_at > $1 AND trigger_id = $2 AND (finished_at IS NOT NULL AND updated_at >= $3) ORDER BY started_at LIMIT 1`)).
+		WithArgs(AnyTime{}, triggerID, since).
+		WillReturnRows(sqlmock.NewRows([]string{}))
+
+	_, err := repository.List(time.Time{}, 1, WhereTrigger(triggerID), WhereFinishedSince(since))
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecutionStreak(t *testing.T) {
+	conn, mock, repository := setupExecutions()
+	defer conn.Close()
+
+	triggerID, before := uuid.New(), time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM executions`)).
+		WithArgs(triggerID, model.Succeeded, before, triggerID, model.Succeeded, before).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	streak, err := repository.Streak(triggerID, before)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, streak)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetExecution(t *testing.T) {
+	var err error
+	conn, mock, repository := setupExecutions()
+	defer conn.Close()
+
+	id := uuid.New()
+
+	rows := sqlmock.NewRows([]string{"id", "name", "phase"}).AddRow(id, randstr.String(16), model.Succeeded)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "executions" WHERE id = $1`)).
+		WithArgs(id).
+		WillReturnRows(rows)
+
+	var e any
+	e, err = repository.Get(id)
+	assert.NoError(t, err)
+	assert.Equal(t, model.Succeeded, e.(*model.Execution).Phase)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestCreateExecution(t *testing.T) {
+	var err error
+	conn, mock, repository := setupExecutions()
+	defer conn.Close()
+
+	execution := model.Execution{
+		ID:         uuid.New(),
+		TriggerID:  uuid.New(),
+		Name:       randstr.String(16),
+		Phase:      model.Succeeded,
+		StatusCode: 200,
+		StartedAt:  time.Now(),
+		Duration:   1000,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "executions"`) + ".*" + regexp.QuoteMeta(`ON CONFLICT ("id") DO UPDATE SET`)).
+		WillReturnRows(sqlmock.NewRows([]string{"status_code"}).AddRow(execution.StatusCode))
+	mock.ExpectCommit()
+
+	var e any
+	e, err = repository.Create(&execution)
+	assert.NoError(t, err)
+	assert.NotNil(t, e)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestUpdateExecution(t *testing.T) {
+	var err error
+	conn, mock, repository := setupExecutions()
+	defer conn.Close()
+
+	id := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "executions" SET`)).
+		WithArgs(model.Failed, AnyTime{}, id).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	var e bool
+	e, err = repository.Update(id, &model.Execution{Phase: model.Failed})
+	assert.NoError(t, err)
+	assert.True(t, e)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestDeleteExecution(t *testing.T) {
+	var err error
+	conn, mock, repository := setupExecutions()
+	defer conn.Close()
+
+	id := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "executions"`)).
+		WithArgs(id).
+		WillReturnError(gorm.ErrInvalidTransaction)
+	mock.ExpectRollback()
+
+	var e bool
+	e, err = repository.Delete(id)
+	assert.Error(t, err)
+	assert.False(t, e)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
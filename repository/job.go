@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/skhaz/scheduler/model"
+)
+
+// Queue is implemented by the repositories that hand jobs out to workers.
+type Queue interface {
+	Claim(worker string, limit int, visibility time.Duration, now time.Time) (model.JobCollection, error)
+	Extend(id any, worker string, visibility time.Duration, now time.Time) (bool, error)
+	Complete(id any, worker string) (bool, error)
+	Fail(id any, worker string, reason string, retryAt, now time.Time) (bool, error)
+	Clean(before time.Time) (int64, error)
+}
+
+// JobRepository is a queue of jobs kept in Postgres. Workers claim jobs with
+// SELECT ... FOR UPDATE SKIP LOCKED, so concurrent workers never claim the
+// same job and never wait for each other.
+type JobRepository struct {
+	GormRepository
+}
+
+const claimQuery = `UPDATE jobs SET state = ?, claimed_by = ?, locked_until = ?, attempts = attempts + 1, updated_at = ?
+WHERE id IN (
+	SELECT id FROM jobs
+	WHERE (state = ? AND run_at <= ?) OR (state = ? AND locked_until < ?)
+	ORDER BY run_at
+	LIMIT ?
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING *`
+
+// Claim locks up to limit jobs for worker until now plus visibility. Jobs are
+// due once their run_at has passed, or once the lock of the worker that
+// claimed them has expired.
+func (r *JobRepository) Claim(worker string, limit int, visibility time.Duration, now time.Time) (model.JobCollection, error) {
+	var c model.JobCollection
+
+	err := r.db.Raw(claimQuery,
+		model.Claimed, worker, now.Add(visibility), now,
+		model.Queued, now, model.Claimed, now,
+		limit,
+	).Scan(&c).Error
+
+	return c, err
+}
+
+// Extend pushes the lock of worker on a job to now plus visibility, so a job
+// that runs for longer than visibility is not claimed again. It reports false
+// when worker no longer holds the job.
+func (r *JobRepository) Extend(id any, worker string, visibility time.Duration, now time.Time) (bool, error) {
+	tx := r.db.Model(&model.Job{}).
+		Where("id = ? AND state = ? AND claimed_by = ?", id, model.Claimed, worker).
+		Updates(map[string]any{"locked_until": now.Add(visibility), "updated_at": now})
+
+	return tx.RowsAffected > 0, tx.Error
+}
+
+// Complete marks a job as done, as long as worker still holds it.
+func (r *JobRepository) Complete(id any, worker string) (bool, error) {
+	tx := r.db.Model(&model.Job{}).
+		Where("id = ? AND state = ? AND claimed_by = ?", id, model.Claimed, worker).
+		Updates(map[string]any{"state": model.Done, "locked_until": nil})
+
+	return tx.RowsAffected > 0, tx.Error
+}
+
+// Fail gives a job held by worker back to the queue, to be run again at
+// retryAt, or buries it once it used all of its attempts.
+func (r *JobRepository) Fail(id any, worker string, reason string, retryAt, now time.Time) (bool, error) {
+	tx := r.db.Exec(`UPDATE jobs SET
+	state = CASE WHEN attempts >= max_attempts THEN ? ELSE ? END,
+	run_at = ?, locked_until = NULL, last_error = ?, updated_at = ?
+WHERE id = ? AND state = ? AND claimed_by = ?`,
+		model.Dead, model.Queued, retryAt, reason, now, id, model.Claimed, worker)
+
+	return tx.RowsAffected > 0, tx.Error
+}
+
+// Clean deletes the jobs that were done or buried before the given time and
+// returns how many there were.
+func (r *JobRepository) Clean(before time.Time) (int64, error) {
+	tx := r.db.Where("state IN ? AND updated_at < ?", []string{model.Done, model.Dead}, before).Delete(&model.Job{})
+
+	return tx.RowsAffected, tx.Error
+}
+
+func (r *JobRepository) List(after time.Time, limit int, scopes ...Scope) (any, error) {
+	var c model.JobCollection
+
+	err := r.db.Scopes(scopes...).Order("run_at").Where("run_at > ?", after).Limit(limit).Find(&c).Error
+
+	return c, err
+}
+
+func (r *JobRepository) Get(id any) (any, error) {
+	var j *model.Job
+
+	err := r.db.Where("id = ?", id).First(&j).Error
+
+	return j, err
+}
+
+func (r *JobRepository) Create(entity any) (any, error) {
+	j := entity.(*model.Job)
+
+	err := r.db.Create(j).Error
+
+	return j, err
+}
+
+func (r *JobRepository) Update(id any, entity any) (bool, error) {
+	j := entity.(*model.Job)
+
+	if err := r.db.Model(j).Where("id = ?", id).Updates(j).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *JobRepository) Delete(id any) (bool, error) {
+	if err := r.db.Delete(&model.Job{}, "id = ?", id).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
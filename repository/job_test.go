@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/thanhpk/randstr"
+)
+
+func setupJobs() (conn *sql.DB, mock sqlmock.Sqlmock, repository JobRepository) {
+	conn, mock, db := mockDB()
+
+	repository = JobRepository{}
+
+	repository.Configure(db)
+
+	return
+}
+
+func TestClaimJobs(t *testing.T) {
+	conn, mock, repository := setupJobs()
+	defer conn.Close()
+
+	id, worker, now := uuid.New(), randstr.String(16), time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE SKIP LOCKED`)).
+		WithArgs(model.Claimed, worker, now.Add(time.Minute), now, model.Queued, now, model.Claimed, now, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "state", "claimed_by", "attempts"}).AddRow(id, model.Claimed, worker, 1))
+
+	jobs, err := repository.Claim(worker, 10, time.Minute, now)
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, id, jobs[0].ID)
+	assert.Equal(t, worker, jobs[0].ClaimedBy)
+	assert.Equal(t, 1, jobs[0].Attempts)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExtendJob(t *testing.T) {
+	conn, mock, repository := setupJobs()
+	defer conn.Close()
+
+	id, worker, now := uuid.New(), randstr.String(16), time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "jobs" SET "locked_until"=$1,"updated_at"=$2 WHERE id = $3 AND state = $4 AND claimed_by = $5`)).
+		WithArgs(now.Add(time.Minute), now, id, model.Claimed, worker).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ok, err := repository.Extend(id, worker, time.Minute, now)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCompleteJob(t *testing.T) {
+	conn, mock, repository := setupJobs()
+	defer conn.Close()
+
+	id, worker := uuid.New(), randstr.String(16)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "jobs" SET "locked_until"=$1,"state"=$2,"updated_at"=$3 WHERE id = $4 AND state = $5 AND claimed_by = $6`)).
+		WithArgs(nil, model.Done, AnyTime{}, id, model.Claimed, worker).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ok, err := repository.Complete(id, worker)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCompleteJobReclaimed(t *testing.T) {
+	conn, mock, repository := setupJobs()
+	defer conn.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "jobs"`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	ok, err := repository.Complete(uuid.New(), randstr.String(16))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestFailJob(t *testing.T) {
+	conn, mock, repository := setupJobs()
+	defer conn.Close()
+
+	id, worker, now := uuid.New(), randstr.String(16), time.Now()
+	retryAt := now.Add(time.Minute)
+
+	mock.ExpectExec(regexp.QuoteMeta(`state = CASE WHEN attempts >= max_attempts THEN $1 ELSE $2 END`)).
+		WithArgs(model.Dead, model.Queued, retryAt, "trigger not found", now, id, model.Claimed, worker).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ok, err := repository.Fail(id, worker, "trigger not found", retryAt, now)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCleanJobs(t *testing.T) {
+	conn, mock, repository := setupJobs()
+	defer conn.Close()
+
+	before := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "jobs" WHERE state IN ($1,$2) AND updated_at < $3`)).
+		WithArgs(model.Done, model.Dead, before).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	n, err := repository.Clean(before)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateJob(t *testing.T) {
+	conn, mock, repository := setupJobs()
+	defer conn.Close()
+
+	job := model.Job{TriggerID: uuid.New(), RunAt: time.Now()}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "jobs"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectCommit()
+
+	_, err := repository.Create(&job)
+	assert.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, job.ID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
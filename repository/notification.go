@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/skhaz/scheduler/model"
+	"gorm.io/gorm/clause"
+)
+
+// reclaimAfter is how long a notification can be delivering before it is
+// taken for abandoned, by a notifier that stopped between reserving and
+// delivering it, and can be reserved again.
+const reclaimAfter = 10 * time.Minute
+
+// Reserver is implemented by the repositories whose entities are unique per
+// event, so whoever inserts one first is the only one to act on it.
+type Reserver interface {
+	Reserve(entity any) (bool, error)
+}
+
+type NotificationRepository struct {
+	GormRepository
+}
+
+// Reserve inserts the notification unless its channel was already notified
+// about the same event of the execution, and reports whether it did. A
+// notification left delivering for longer than reclaimAfter is reserved again.
+func (r *NotificationRepository) Reserve(entity any) (bool, error) {
+	n := entity.(*model.Notification)
+
+	now := time.Now()
+
+	tx := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "execution_id"}, {Name: "channel"}, {Name: "event"}},
+		DoUpdates: clause.Assignments(map[string]any{"updated_at": now}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "notifications.state = ? AND notifications.updated_at < ?", Vars: []any{model.Delivering, now.Add(-reclaimAfter)}},
+		}},
+	}).Create(n)
+
+	return tx.RowsAffected > 0, tx.Error
+}
+
+func (r *NotificationRepository) List(after time.Time, limit int, scopes ...Scope) (any, error) {
+	var c model.NotificationCollection
+
+	err := r.db.Scopes(scopes...).Order("created_at").Where("created_at > ?", after).Limit(limit).Find(&c).Error
+
+	return c, err
+}
+
+func (r *NotificationRepository) Get(id any) (any, error) {
+	var n *model.Notification
+
+	err := r.db.Where("id = ?", id).First(&n).Error
+
+	return n, err
+}
+
+func (r *NotificationRepository) Create(entity any) (any, error) {
+	n := entity.(*model.Notification)
+
+	err := r.db.Create(n).Error
+
+	return n, err
+}
+
+func (r *NotificationRepository) Update(id any, entity any) (bool, error) {
+	n := entity.(*model.Notification)
+
+	// Every column is written, so the error of a failed attempt is cleared by
+	// a later successful one.
+	if err := r.db.Model(n).Select("*").Omit("id", "created_at").Where("id = ?", id).Updates(n).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *NotificationRepository) Delete(id any) (bool, error) {
+	if err := r.db.Delete(&model.Notification{}, "id = ?", id).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
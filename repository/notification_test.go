@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupNotifications() (conn *sql.DB, mock sqlmock.Sqlmock, repository NotificationRepository) {
+	conn, mock, db := mockDB()
+
+	repository = NotificationRepository{}
+
+	repository.Configure(db)
+
+	return
+}
+
+func TestReserveNotification(t *testing.T) {
+	for rows, reserved := range map[*sqlmock.Rows]bool{
+		sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()): true,
+		sqlmock.NewRows([]string{"id"}):                    false,
+	} {
+		conn, mock, repository := setupNotifications()
+
+		notification := &model.Notification{
+			TriggerID:   uuid.New(),
+			ExecutionID: uuid.New(),
+			Channel:     1,
+			Kind:        model.Webhook,
+			Event:       model.OnFailure,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "notifications"`) + ".*" + regexp.QuoteMeta(`ON CONFLICT ("execution_id","channel","event") DO UPDATE SET "updated_at"=`) + ".*" + regexp.QuoteMeta(`WHERE notifications.state = `)).
+			WillReturnRows(rows)
+		mock.ExpectCommit()
+
+		ok, err := repository.Reserve(notification)
+		assert.NoError(t, err)
+		assert.Equal(t, reserved, ok)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+		conn.Close()
+	}
+}
+
+func TestListNotifications(t *testing.T) {
+	conn, mock, repository := setupNotifications()
+	defer conn.Close()
+
+	triggerID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "notifications" WHERE created_at > $1 AND trigger_id = $2 ORDER BY created_at LIMIT 1`)).
+		WithArgs(AnyTime{}, triggerID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "state"}).AddRow(uuid.New(), model.Delivered))
+
+	c, err := repository.List(time.Now(), 1, WhereTrigger(triggerID))
+	assert.NoError(t, err)
+	assert.Len(t, c, 1)
+	assert.Equal(t, model.Delivered, c.(model.NotificationCollection)[0].State)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateNotification(t *testing.T) {
+	conn, mock, repository := setupNotifications()
+	defer conn.Close()
+
+	id := uuid.New()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "notifications" SET`) + ".*" + regexp.QuoteMeta(`"last_error"=`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ok, err := repository.Update(id, &model.Notification{ID: id, State: model.Delivered, Attempts: 2, DeliveredAt: &now})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/skhaz/scheduler/model"
+	"gorm.io/gorm/clause"
+)
+
+// ReconciliationRepository keeps the last reconciliation only, under
+// model.LastReconciliation.
+type ReconciliationRepository struct {
+	GormRepository
+}
+
+func (r *ReconciliationRepository) List(after time.Time, limit int, scopes ...Scope) (any, error) {
+	var c []*model.Reconciliation
+
+	err := r.db.Scopes(scopes...).Order("finished_at").Where("finished_at > ?", after).Limit(limit).Find(&c).Error
+
+	return c, err
+}
+
+func (r *ReconciliationRepository) Get(id any) (any, error) {
+	var rec *model.Reconciliation
+
+	err := r.db.Where("id = ?", id).First(&rec).Error
+
+	return rec, err
+}
+
+// Create inserts the reconciliation, or replaces the one with the same ID.
+func (r *ReconciliationRepository) Create(entity any) (any, error) {
+	rec := entity.(*model.Reconciliation)
+
+	err := r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(rec).Error
+
+	return rec, err
+}
+
+func (r *ReconciliationRepository) Update(id any, entity any) (bool, error) {
+	rec := entity.(*model.Reconciliation)
+
+	if err := r.db.Model(rec).Select("*").Where("id = ?", id).Updates(rec).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *ReconciliationRepository) Delete(id any) (bool, error) {
+	if err := r.db.Delete(&model.Reconciliation{}, "id = ?", id).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupReconciliations() (conn *sql.DB, mock sqlmock.Sqlmock, repository ReconciliationRepository) {
+	conn, mock, db := mockDB()
+
+	repository = ReconciliationRepository{}
+
+	repository.Configure(db)
+
+	return
+}
+
+func TestGetReconciliation(t *testing.T) {
+	conn, mock, repository := setupReconciliations()
+	defer conn.Close()
+
+	id := uuid.NewString()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "reconciliations" WHERE id = $1 ORDER BY "reconciliations"."id" LIMIT 1`)).
+		WithArgs(model.LastReconciliation).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "checked", "created"}).AddRow(model.LastReconciliation, 2, `["`+id+`"]`))
+
+	e, err := repository.Get(model.LastReconciliation)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, e.(*model.Reconciliation).Checked)
+	assert.Equal(t, []string{id}, e.(*model.Reconciliation).Created)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateReconciliation(t *testing.T) {
+	conn, mock, repository := setupReconciliations()
+	defer conn.Close()
+
+	now := time.Now()
+	rec := &model.Reconciliation{ID: model.LastReconciliation, StartedAt: now, FinishedAt: now, Checked: 1}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "reconciliations" ("id","started_at","finished_at","checked","created","updated","removed","errors") VALUES ($1,$2,$3,$4,$5,$6,$7,$8) ON CONFLICT ("id") DO UPDATE SET "started_at"="excluded"."started_at"`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	_, err := repository.Create(rec)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
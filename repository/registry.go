@@ -5,12 +5,15 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+type Scope = func(*gorm.DB) *gorm.DB
+
 type Repository interface {
 	Configure(*gorm.DB)
-	List(time.Time, int) (any, error)
+	List(time.Time, int, ...Scope) (any, error)
 	Get(any) (any, error)
 	Create(any) (any, error)
 	Update(any, entity any) (bool, error)
@@ -25,10 +28,17 @@ func (r *GormRepository) Configure(db *gorm.DB) {
 	r.db = db
 }
 
+// TenantScoped is implemented by the repositories whose entities belong to a
+// tenant, so they can be restricted to the entities of one.
+type TenantScoped interface {
+	ScopeTenant(id uuid.UUID)
+}
+
 type RepositoryRegistry struct {
 	registry map[string]Repository
 
-	db *gorm.DB
+	db     *gorm.DB
+	tenant *uuid.UUID
 }
 
 func NewRepositoryRegistry(db *gorm.DB, v ...Repository) *RepositoryRegistry {
@@ -49,6 +59,51 @@ func (r *RepositoryRegistry) registerRepositories(repositories []Repository) {
 	}
 }
 
+// Transaction runs fn with a registry whose repositories share a database
+// transaction, committed only when fn returns nil. A registry without a
+// database, as used by tests, runs fn with itself.
+func (r *RepositoryRegistry) Transaction(fn func(*RepositoryRegistry) error) error {
+	if r.db == nil {
+		return fn(r)
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(r.clone(tx, r.tenant))
+	})
+}
+
+// ForTenant returns a registry whose tenant scoped repositories only see, and
+// only create, the entities of the tenant.
+func (r *RepositoryRegistry) ForTenant(id uuid.UUID) *RepositoryRegistry {
+	return r.clone(r.db, &id)
+}
+
+// clone returns a registry with copies of the repositories of r configured
+// with db and scoped to tenant.
+func (r *RepositoryRegistry) clone(db *gorm.DB, tenant *uuid.UUID) *RepositoryRegistry {
+	registry := &RepositoryRegistry{
+		db:       db,
+		tenant:   tenant,
+		registry: map[string]Repository{},
+	}
+
+	for repositoryName, v := range r.registry {
+		clone := reflect.New(reflect.TypeOf(v).Elem())
+		clone.Elem().Set(reflect.ValueOf(v).Elem())
+
+		repository := clone.Interface().(Repository)
+		repository.Configure(db)
+
+		if scoped, ok := repository.(TenantScoped); ok && tenant != nil {
+			scoped.ScopeTenant(*tenant)
+		}
+
+		registry.registry[repositoryName] = repository
+	}
+
+	return registry
+}
+
 func (r *RepositoryRegistry) Repository(repositoryName string) (Repository, error) {
 	if repository, ok := r.registry[repositoryName]; ok {
 		return repository, nil
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -22,7 +23,7 @@ type TestRepository struct {
 func (r *TestRepository) Configure(db *gorm.DB) {
 }
 
-func (r *TestRepository) List(after time.Time, limit int) (any, error) {
+func (r *TestRepository) List(after time.Time, limit int, scopes ...Scope) (any, error) {
 	return nil, nil
 }
 
@@ -106,3 +107,73 @@ func TestMustRepositoryNotFound(t *testing.T) {
 
 	assert.Panics(t, func() { registry.MustRepository(nonExistRepositoryName) })
 }
+
+type Record struct {
+	ID   uint
+	Name string
+}
+
+type RecordRepository struct {
+	TestRepository
+	GormRepository
+}
+
+func (r *RecordRepository) Configure(db *gorm.DB) {
+	r.GormRepository.Configure(db)
+}
+
+func (r *RecordRepository) Create(entity any) (any, error) {
+	return entity, r.db.Create(entity).Error
+}
+
+func TestTransactionCommit(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(dsn), &opts)
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&Record{}))
+
+	registry := NewRepositoryRegistry(db, &RecordRepository{})
+
+	err = registry.Transaction(func(tx *RepositoryRegistry) error {
+		assert.NotSame(t, registry.MustRepository("RecordRepository"), tx.MustRepository("RecordRepository"))
+
+		_, err := tx.MustRepository("RecordRepository").Create(&Record{Name: "commit"})
+		return err
+	})
+	assert.NoError(t, err)
+
+	var count int64
+	db.Model(&Record{}).Where("name = ?", "commit").Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestTransactionRollback(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(dsn), &opts)
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&Record{}))
+
+	registry := NewRepositoryRegistry(db, &RecordRepository{})
+	expected := errors.New("the cluster is unreachable")
+
+	err = registry.Transaction(func(tx *RepositoryRegistry) error {
+		if _, err := tx.MustRepository("RecordRepository").Create(&Record{Name: "rollback"}); err != nil {
+			return err
+		}
+
+		return expected
+	})
+	assert.ErrorIs(t, err, expected)
+
+	var count int64
+	db.Model(&Record{}).Where("name = ?", "rollback").Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestTransactionWithoutDatabase(t *testing.T) {
+	registry := NewRepositoryRegistry(nil, &TestRepository{})
+
+	err := registry.Transaction(func(tx *RepositoryRegistry) error {
+		assert.Same(t, registry, tx)
+		return nil
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/skhaz/scheduler/model"
+	"gorm.io/gorm"
+)
+
+type RoleBindingRepository struct {
+	GormRepository
+}
+
+func WhereSubject(subject string) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("subject = ?", subject)
+	}
+}
+
+func (r *RoleBindingRepository) List(after time.Time, limit int, scopes ...Scope) (any, error) {
+	var c model.RoleBindingCollection
+
+	err := r.db.Scopes(scopes...).Order("created_at").Where("created_at > ?", after).Limit(limit).Find(&c).Error
+
+	return c, err
+}
+
+func (r *RoleBindingRepository) Get(id any) (any, error) {
+	var b *model.RoleBinding
+
+	err := r.db.Where("id = ?", id).First(&b).Error
+
+	return b, err
+}
+
+func (r *RoleBindingRepository) Create(entity any) (any, error) {
+	b := entity.(*model.RoleBinding)
+
+	err := r.db.Create(b).Error
+
+	return b, err
+}
+
+func (r *RoleBindingRepository) Update(id any, entity any) (bool, error) {
+	b := entity.(*model.RoleBinding)
+
+	if err := r.db.Model(b).Where("id = ?", id).Updates(b).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *RoleBindingRepository) Delete(id any) (bool, error) {
+	if err := r.db.Delete(&model.RoleBinding{}, "id = ?", id).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
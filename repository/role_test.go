@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRoleBindings() (conn *sql.DB, mock sqlmock.Sqlmock, repository RoleBindingRepository) {
+	conn, mock, db := mockDB()
+
+	repository = RoleBindingRepository{}
+
+	repository.Configure(db)
+
+	return
+}
+
+func TestListRoleBindingsBySubject(t *testing.T) {
+	conn, mock, repository := setupRoleBindings()
+	defer conn.Close()
+
+	subject := uuid.NewString()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "role_bindings" WHERE created_at > $1 AND subject = $2 ORDER BY created_at LIMIT 4`)).
+		WithArgs(AnyTime{}, subject).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "subject", "role"}).AddRow(uuid.New(), subject, model.Operator))
+
+	c, err := repository.List(time.Time{}, len(model.Roles), WhereSubject(subject))
+	assert.NoError(t, err)
+	assert.Len(t, c, 1)
+	assert.Equal(t, model.Operator, c.(model.RoleBindingCollection)[0].Role)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"gorm.io/gorm"
+)
+
+type SubscriptionRepository struct {
+	GormRepository
+
+	tenant *uuid.UUID
+}
+
+// ScopeTenant restricts every query to the subscriptions of the tenant, and
+// makes the subscriptions created belong to it.
+func (r *SubscriptionRepository) ScopeTenant(id uuid.UUID) {
+	r.tenant = &id
+	r.db = r.db.Where("subscriptions.tenant_id = ?", id).Session(&gorm.Session{})
+}
+
+func (r *SubscriptionRepository) List(after time.Time, limit int, scopes ...Scope) (any, error) {
+	var c model.SubscriptionCollection
+
+	err := r.db.Scopes(scopes...).Order("created_at").Where("created_at > ?", after).Limit(limit).Find(&c).Error
+
+	return c, err
+}
+
+func (r *SubscriptionRepository) Get(id any) (any, error) {
+	var s *model.Subscription
+
+	err := r.db.Where("id = ?", id).First(&s).Error
+
+	return s, err
+}
+
+func (r *SubscriptionRepository) Create(entity any) (any, error) {
+	s := entity.(*model.Subscription)
+
+	if r.tenant != nil {
+		s.TenantID = r.tenant
+	}
+
+	err := r.db.Create(s).Error
+
+	return s, err
+}
+
+func (r *SubscriptionRepository) Update(id any, entity any) (bool, error) {
+	s := entity.(*model.Subscription)
+
+	if err := r.db.Model(s).Where("id = ?", id).Updates(s).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Delete removes the subscription, its deliveries go along with it.
+func (r *SubscriptionRepository) Delete(id any) (bool, error) {
+	if err := r.db.Delete(&model.Subscription{}, "id = ?", id).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupSubscriptions() (conn *sql.DB, mock sqlmock.Sqlmock, repository SubscriptionRepository) {
+	conn, mock, db := mockDB()
+
+	repository = SubscriptionRepository{}
+
+	repository.Configure(db)
+
+	return
+}
+
+func TestListSubscriptions(t *testing.T) {
+	conn, mock, repository := setupSubscriptions()
+	defer conn.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "subscriptions" WHERE created_at > $1 ORDER BY created_at LIMIT 1`)).
+		WithArgs(AnyTime{}).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "events"}).AddRow(uuid.New(), "https://example.com", `["trigger.created"]`))
+
+	c, err := repository.List(time.Now(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, c, 1)
+	assert.Equal(t, []string{model.TriggerCreated}, c.(model.SubscriptionCollection)[0].Events)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateSubscription(t *testing.T) {
+	conn, mock, repository := setupSubscriptions()
+	defer conn.Close()
+
+	id := uuid.New()
+	subscription := &model.Subscription{URL: "https://example.com", Events: []string{model.TriggerCreated, model.TriggerDeleted}, Secret: "secret"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "subscriptions" ("tenant_id","url","events","enabled","secret","created_at","updated_at") VALUES ($1,$2,$3,$4,$5,$6,$7) RETURNING "id"`)).
+		WithArgs(nil, subscription.URL, `["trigger.created","trigger.deleted"]`, true, subscription.Secret, AnyTime{}, AnyTime{}).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+	mock.ExpectCommit()
+
+	e, err := repository.Create(subscription)
+	assert.NoError(t, err)
+	assert.Equal(t, id, e.(*model.Subscription).ID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteSubscription(t *testing.T) {
+	conn, mock, repository := setupSubscriptions()
+	defer conn.Close()
+
+	id := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "subscriptions" WHERE id = $1`)).
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ok, err := repository.Delete(id)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/skhaz/scheduler/model"
+	"gorm.io/gorm/clause"
+)
+
+var ErrNoLocker = errors.New("tenants cannot be locked")
+
+// Locker is implemented by the repositories whose entities can be locked
+// until the end of the transaction, so the checks made against them hold
+// until then.
+type Locker interface {
+	Lock(id any) (any, error)
+}
+
+type TenantRepository struct {
+	GormRepository
+}
+
+func (r *TenantRepository) List(after time.Time, limit int, scopes ...Scope) (any, error) {
+	var c model.TenantCollection
+
+	err := r.db.Scopes(scopes...).Order("created_at").Where("created_at > ?", after).Limit(limit).Find(&c).Error
+
+	return c, err
+}
+
+func (r *TenantRepository) Get(id any) (any, error) {
+	var t *model.Tenant
+
+	err := r.db.Where("id = ?", id).First(&t).Error
+
+	return t, err
+}
+
+// Lock gets the tenant and locks its row, so the triggers of the tenant are
+// not created by concurrent transactions while the quotas are checked.
+func (r *TenantRepository) Lock(id any) (any, error) {
+	var t *model.Tenant
+
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&t).Error
+
+	return t, err
+}
+
+func (r *TenantRepository) Create(entity any) (any, error) {
+	t := entity.(*model.Tenant)
+
+	err := r.db.Create(t).Error
+
+	return t, err
+}
+
+func (r *TenantRepository) Update(id any, entity any) (bool, error) {
+	t := entity.(*model.Tenant)
+
+	if err := r.db.Model(t).Where("id = ?", id).Updates(t).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Delete removes the tenant, along with its webhooks and API keys. Tenants
+// that still own triggers cannot be deleted.
+func (r *TenantRepository) Delete(id any) (bool, error) {
+	if err := r.db.Delete(&model.Tenant{}, "id = ?", id).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/thanhpk/randstr"
+)
+
+func setupTenants() (conn *sql.DB, mock sqlmock.Sqlmock, repository TenantRepository) {
+	conn, mock, db := mockDB()
+
+	repository = TenantRepository{}
+
+	repository.Configure(db)
+
+	return
+}
+
+func TestGetTenant(t *testing.T) {
+	conn, mock, repository := setupTenants()
+	defer conn.Close()
+
+	id := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "tenants" WHERE id = $1 ORDER BY "tenants"."id" LIMIT 1`)).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "max_triggers", "min_interval"}).AddRow(id, 10, 300))
+
+	e, err := repository.Get(id)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, e.(*model.Tenant).MaxTriggers)
+	assert.Equal(t, 300, e.(*model.Tenant).MinInterval)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLockTenant(t *testing.T) {
+	conn, mock, repository := setupTenants()
+	defer conn.Close()
+
+	id := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "tenants" WHERE id = $1 ORDER BY "tenants"."id" LIMIT 1 FOR UPDATE`)).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "max_triggers", "min_interval"}).AddRow(id, 10, 300))
+
+	e, err := repository.Lock(id)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, e.(*model.Tenant).MaxTriggers)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestForTenant(t *testing.T) {
+	conn, mock, db := mockDB()
+	defer conn.Close()
+
+	tenantID := uuid.New()
+	registry := NewRepositoryRegistry(db, &TriggerRepository{}, &ExecutionRepository{}).ForTenant(tenantID)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "triggers" WHERE triggers.tenant_id = $1 AND created_at > $2 AND "triggers"."deleted_at" IS NULL ORDER BY created_at LIMIT 10`)).
+		WithArgs(tenantID, AnyTime{}).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err := registry.MustRepository("TriggerRepository").List(time.Time{}, 10)
+	assert.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "triggers" WHERE triggers.tenant_id = $1 AND "triggers"."deleted_at" IS NULL`)).
+		WithArgs(tenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	count, err := registry.MustRepository("TriggerRepository").(Counter).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	// Executions do not belong to tenants, they are reached through triggers.
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "executions" WHERE started_at > $1 ORDER BY started_at LIMIT 10`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err = registry.MustRepository("ExecutionRepository").List(time.Now(), 10)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestForTenantTransaction(t *testing.T) {
+	conn, mock, db := mockDB()
+	defer conn.Close()
+
+	tenantID, id := uuid.New(), uuid.New()
+	registry := NewRepositoryRegistry(db, &TriggerRepository{}).ForTenant(tenantID)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "triggers"`)).
+		WithArgs(&tenantID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), AnyTime{}, AnyTime{}, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "triggers" SET "deleted_at"=$1 WHERE triggers.tenant_id = $2 AND id = $3`)).
+		WithArgs(AnyTime{}, tenantID, id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := registry.Transaction(func(registry *RepositoryRegistry) error {
+		repository := registry.MustRepository("TriggerRepository")
+
+		e, err := repository.Create(&model.Trigger{Name: randstr.String(16)})
+		if err != nil {
+			return err
+		}
+
+		assert.Equal(t, &tenantID, e.(*model.Trigger).TenantID)
+
+		_, err = repository.Delete(id)
+		return err
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
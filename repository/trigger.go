@@ -1,24 +1,111 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/skhaz/scheduler/model"
+	"gorm.io/gorm"
 )
 
 const (
 	Order = "created_at"
 )
 
+// Counter is implemented by the repositories that can count their entities.
+type Counter interface {
+	Count(scopes ...Scope) (int64, error)
+}
+
+var (
+	ErrNoRestorer = errors.New("triggers cannot be restored")
+	ErrNoReplacer = errors.New("triggers cannot be replaced")
+)
+
+// Replacer is implemented by the repositories that can overwrite every field
+// of an entity, zero values included, unlike Update which skips them.
+type Replacer interface {
+	Replace(id any, entity any) (bool, error)
+}
+
+// Restorer is implemented by the repositories whose entities are soft
+// deleted, so they can be brought back, or purged for good.
+type Restorer interface {
+	Deleted(id any) (any, error)
+	Restore(id any) (bool, error)
+	Purge(id any) (bool, error)
+}
+
 type TriggerRepository struct {
 	GormRepository
+
+	tenant *uuid.UUID
+}
+
+// ScopeTenant restricts every query to the triggers of the tenant, and makes
+// the triggers created belong to it.
+func (r *TriggerRepository) ScopeTenant(id uuid.UUID) {
+	r.tenant = &id
+	r.db = r.db.Where("triggers.tenant_id = ?", id).Session(&gorm.Session{})
+}
+
+func (r *TriggerRepository) Count(scopes ...Scope) (int64, error) {
+	var count int64
+
+	err := r.db.Model(&model.Trigger{}).Scopes(scopes...).Count(&count).Error
+
+	return count, err
+}
+
+func WhereTenant(id uuid.UUID) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("tenant_id = ?", id)
+	}
+}
+
+func WhereEnabled(enabled bool) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("enabled = ?", enabled)
+	}
+}
+
+// WithDeleted includes the soft deleted triggers.
+func WithDeleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// OnlyDeleted restricts to the soft deleted triggers.
+func OnlyDeleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped().Where("triggers.deleted_at IS NOT NULL")
+}
+
+// AfterTrigger pages through the triggers by creation time and then by ID, so
+// the ones created at the same time are neither skipped nor listed twice. It
+// starts from the beginning when trigger is nil, and is meant to be passed to
+// List along with a zero after.
+func AfterTrigger(trigger *model.Trigger) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		if trigger == nil {
+			return db.Order("triggers.id")
+		}
+
+		return db.Where("(triggers.created_at, triggers.id) > (?, ?)", trigger.CreatedAt, trigger.ID).Order("triggers.id")
+	}
 }
 
-func (r *TriggerRepository) List(after time.Time, limit int) (any, error) {
+// WhereDeletedBefore restricts to the triggers soft deleted before t.
+func WhereDeletedBefore(t time.Time) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped().Where("triggers.deleted_at < ?", t)
+	}
+}
+
+func (r *TriggerRepository) List(after time.Time, limit int, scopes ...Scope) (any, error) {
 	var c model.TriggerCollection
 
-	err := r.db.Limit(limit).Order(Order).Where(fmt.Sprintf("%s > ?", Order), after).Limit(limit).Find(&c).Error
+	err := r.db.Scopes(scopes...).Limit(limit).Order(Order).Where(fmt.Sprintf("%s > ?", Order), after).Limit(limit).Find(&c).Error
 
 	return c, err
 }
@@ -34,6 +121,10 @@ func (r *TriggerRepository) Get(id any) (any, error) {
 func (r *TriggerRepository) Create(entity any) (any, error) {
 	e := entity.(*model.Trigger)
 
+	if r.tenant != nil {
+		e.TenantID = r.tenant
+	}
+
 	err := r.db.Create(e).Error
 
 	return e, err
@@ -49,6 +140,19 @@ func (r *TriggerRepository) Update(id any, entity any) (bool, error) {
 	return true, nil
 }
 
+// Replace writes every field of the trigger but its ID, tenant, secret and
+// timestamps, so the fields a full update clears are cleared in the table too.
+func (r *TriggerRepository) Replace(id any, entity any) (bool, error) {
+	e := entity.(*model.Trigger)
+
+	tx := r.db.Model(e).Select("*").Omit("id", "tenant_id", "secret", "created_at", "deleted_at").Where("id = ?", id).Updates(e)
+	if tx.Error != nil {
+		return false, tx.Error
+	}
+
+	return tx.RowsAffected > 0, nil
+}
+
 func (r *TriggerRepository) Delete(id any) (bool, error) {
 	if err := r.db.Delete(&model.Trigger{}, "id = ?", id).Error; err != nil {
 		return false, err
@@ -56,3 +160,50 @@ func (r *TriggerRepository) Delete(id any) (bool, error) {
 
 	return true, nil
 }
+
+// Deleted returns the trigger with the given ID if it is soft deleted.
+func (r *TriggerRepository) Deleted(id any) (any, error) {
+	var e *model.Trigger
+
+	err := r.db.Scopes(OnlyDeleted).Where("id = ?", id).First(&e).Error
+
+	return e, err
+}
+
+// Restore undoes the soft deletion of a trigger.
+func (r *TriggerRepository) Restore(id any) (bool, error) {
+	tx := r.db.Scopes(OnlyDeleted).Model(&model.Trigger{}).Where("id = ?", id).Update("deleted_at", nil)
+	if tx.Error != nil {
+		return false, tx.Error
+	}
+
+	return tx.RowsAffected > 0, nil
+}
+
+// Purge deletes a trigger for good, along with its executions, notifications
+// and the jobs queued for it. Its audit log is kept, and so are its events and
+// their deliveries, as the log of the webhooks, which hold the trigger without
+// its headers and body.
+func (r *TriggerRepository) Purge(id any) (bool, error) {
+	tx := r.db.Unscoped().Delete(&model.Trigger{}, "id = ?", id)
+	if tx.Error != nil || tx.RowsAffected == 0 {
+		return false, tx.Error
+	}
+
+	// The tenant condition of r.db is about the triggers table.
+	db := r.db.Session(&gorm.Session{NewDB: true})
+
+	if err := db.Delete(&model.Notification{}, "trigger_id = ?", id).Error; err != nil {
+		return false, err
+	}
+
+	if err := db.Delete(&model.Execution{}, "trigger_id = ?", id).Error; err != nil {
+		return false, err
+	}
+
+	if err := db.Delete(&model.Job{}, "trigger_id = ?", id).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -15,7 +15,7 @@ import (
 	"gorm.io/gorm"
 )
 
-func setup() (conn *sql.DB, mock sqlmock.Sqlmock, repository TriggerRepository) {
+func mockDB() (conn *sql.DB, mock sqlmock.Sqlmock, db *gorm.DB) {
 	var err error
 
 	conn, mock, err = sqlmock.New()
@@ -24,11 +24,17 @@ func setup() (conn *sql.DB, mock sqlmock.Sqlmock, repository TriggerRepository)
 	}
 
 	dialector := postgres.New(postgres.Config{Conn: conn, PreferSimpleProtocol: true})
-	db, err := gorm.Open(dialector, &gorm.Config{})
+	db, err = gorm.Open(dialector, &gorm.Config{})
 	if err != nil {
 		panic(err)
 	}
 
+	return
+}
+
+func setup() (conn *sql.DB, mock sqlmock.Sqlmock, repository TriggerRepository) {
+	conn, mock, db := mockDB()
+
 	repository = TriggerRepository{}
 
 	repository.Configure(db)
@@ -52,6 +58,24 @@ func TestListWorkspaces(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestListWorkspacesEnabled(t *testing.T) {
+	var err error
+	conn, mock, repository := setup()
+	defer conn.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "triggers" WHERE created_at > $1 AND enabled = $2`)).
+		WithArgs(AnyTime{}, false).
+		WillReturnRows(sqlmock.NewRows([]string{}))
+
+	var arr any
+	arr, err = repository.List(time.Now(), 1, WhereEnabled(false))
+	assert.NoError(t, err)
+	assert.NotNil(t, arr)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
 func TestGetWorkspace(t *testing.T) {
 	var err error
 	conn, mock, repository := setup()
@@ -98,7 +122,7 @@ func TestCreateWorkspace(t *testing.T) {
 
 	mock.ExpectBegin()
 	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "triggers"`)).
-		WithArgs(trigger.Name, trigger.Schedule, trigger.Timezone, trigger.Url, trigger.Method, trigger.Success, trigger.Timeout, trigger.Retry, trigger.CreatedAt, trigger.UpdatedAt, trigger.DeletedAt, trigger.ID).
+		WithArgs(trigger.TenantID, trigger.Name, trigger.Schedule, trigger.Timezone, trigger.Url, trigger.Method, trigger.Success, trigger.Timeout, trigger.Retry, true, trigger.CreatedAt, trigger.UpdatedAt, trigger.DeletedAt, trigger.ID).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(trigger.ID))
 	mock.ExpectCommit()
 
@@ -167,6 +191,30 @@ func TestUpdateWorkspaceError(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestReplaceWorkspace(t *testing.T) {
+	var err error
+	conn, mock, repository := setup()
+	defer conn.Close()
+
+	trigger := model.Trigger{
+		ID:   uuid.New(),
+		Name: randstr.String(16),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "triggers" SET .*"body"=.*"content_type"=.*WHERE id = `).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	var e bool
+	e, err = repository.Replace(trigger.ID, &trigger)
+	assert.NoError(t, err)
+	assert.True(t, e)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
 func TestDeleteWorkspace(t *testing.T) {
 	var err error
 	conn, mock, repository := setup()
@@ -210,3 +258,137 @@ func TestDeleteWorkspaceError(t *testing.T) {
 	err = mock.ExpectationsWereMet()
 	assert.NoError(t, err)
 }
+
+func TestListDeletedWorkspaces(t *testing.T) {
+	conn, mock, repository := setup()
+	defer conn.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "triggers" WHERE created_at > $1 AND triggers.deleted_at IS NOT NULL`)).
+		WithArgs(AnyTime{}).
+		WillReturnRows(sqlmock.NewRows([]string{}))
+
+	_, err := repository.List(time.Time{}, 1, OnlyDeleted)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListWorkspacesDeletedBefore(t *testing.T) {
+	conn, mock, repository := setup()
+	defer conn.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "triggers" WHERE created_at > $1 AND triggers.deleted_at < $2`)).
+		WithArgs(AnyTime{}, AnyTime{}).
+		WillReturnRows(sqlmock.NewRows([]string{}))
+
+	_, err := repository.List(time.Time{}, 1, WhereDeletedBefore(time.Now()))
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListWorkspacesAfterTrigger(t *testing.T) {
+	conn, mock, repository := setup()
+	defer conn.Close()
+
+	trigger := &model.Trigger{ID: uuid.New(), CreatedAt: time.Now()}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "triggers" WHERE created_at > $1 AND (triggers.created_at, triggers.id) > ($2, $3) AND "triggers"."deleted_at" IS NULL ORDER BY created_at,triggers.id`)).
+		WithArgs(AnyTime{}, AnyTime{}, trigger.ID).
+		WillReturnRows(sqlmock.NewRows([]string{}))
+
+	_, err := repository.List(time.Time{}, 1, AfterTrigger(trigger))
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeletedWorkspace(t *testing.T) {
+	conn, mock, repository := setup()
+	defer conn.Close()
+
+	uid := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "triggers" WHERE id = $1 AND triggers.deleted_at IS NOT NULL`)).
+		WithArgs(uid).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "deleted_at"}).AddRow(uid, time.Now()))
+
+	e, err := repository.Deleted(uid)
+	assert.NoError(t, err)
+	assert.True(t, e.(*model.Trigger).DeletedAt.Valid)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestoreWorkspace(t *testing.T) {
+	conn, mock, repository := setup()
+	defer conn.Close()
+
+	uid := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "triggers" SET "deleted_at"=$1,"updated_at"=$2 WHERE id = $3 AND triggers.deleted_at IS NOT NULL`)).
+		WithArgs(nil, AnyTime{}, uid).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ok, err := repository.Restore(uid)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurgeWorkspace(t *testing.T) {
+	conn, mock, repository := setup()
+	defer conn.Close()
+
+	tenant, uid := uuid.New(), uuid.New()
+	repository.ScopeTenant(tenant)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "triggers" WHERE triggers.tenant_id = $1 AND id = $2`)).
+		WithArgs(tenant, uid).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "notifications" WHERE trigger_id = $1`)).
+		WithArgs(uid).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "executions" WHERE trigger_id = $1`)).
+		WithArgs(uid).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "jobs" WHERE trigger_id = $1`)).
+		WithArgs(uid).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ok, err := repository.Purge(uid)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurgeWorkspaceMissing(t *testing.T) {
+	conn, mock, repository := setup()
+	defer conn.Close()
+
+	uid := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "triggers" WHERE id = $1`)).
+		WithArgs(uid).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	ok, err := repository.Purge(uid)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
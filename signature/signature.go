@@ -0,0 +1,75 @@
+// Package signature implements the scheme used to sign the requests sent by
+// triggers, so receivers can tell them apart from forged ones.
+//
+// Every request carries the X-Scheduler-Timestamp header, holding the Unix time
+// at which it was sent, and the X-Scheduler-Signature header, holding "sha256="
+// followed by the hex encoded HMAC-SHA256 of "<timestamp>.<body>" keyed by the
+// secret of the trigger. Receivers should recompute the signature and reject
+// requests whose timestamp is too far from their own clock to prevent replays.
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TimestampHeader = "X-Scheduler-Timestamp"
+	SignatureHeader = "X-Scheduler-Signature"
+	Prefix          = "sha256="
+)
+
+var (
+	ErrMissingHeader    = errors.New("signature headers are missing")
+	ErrInvalidTimestamp = errors.New("signature timestamp is invalid")
+	ErrExpired          = errors.New("signature timestamp is outside of the tolerance")
+	ErrMismatch         = errors.New("signature does not match")
+)
+
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return Prefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SetHeaders signs body at the given time and stores the result in header.
+func SetHeaders(header http.Header, secret string, now time.Time, body []byte) {
+	timestamp := now.Unix()
+
+	header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	header.Set(SignatureHeader, Sign(secret, timestamp, body))
+}
+
+// Verify checks the signature headers of a request against its body, the
+// timestamp must be within tolerance of the current time.
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration) error {
+	value, signature := header.Get(TimestampHeader), header.Get(SignatureHeader)
+	if value == "" || signature == "" {
+		return ErrMissingHeader
+	}
+
+	timestamp, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+
+	if delta := time.Since(time.Unix(timestamp, 0)); delta > tolerance || delta < -tolerance {
+		return ErrExpired
+	}
+
+	expected := Sign(secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return ErrMismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package signature
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thanhpk/randstr"
+)
+
+func TestSign(t *testing.T) {
+	// echo -n "1700000000.{}" | openssl dgst -sha256 -hmac "secret"
+	expected := "sha256=b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163"
+	actual := Sign("secret", 1700000000, []byte("{}"))
+	assert.Equal(t, expected, actual)
+	assert.NotEqual(t, actual, Sign("secret", 1700000001, []byte("{}")))
+	assert.NotEqual(t, actual, Sign("another", 1700000000, []byte("{}")))
+}
+
+func TestVerify(t *testing.T) {
+	secret := randstr.Hex(32)
+	body := []byte(randstr.String(64))
+	header := http.Header{}
+
+	SetHeaders(header, secret, time.Now(), body)
+
+	assert.NoError(t, Verify(secret, header, body, time.Minute))
+}
+
+func TestVerifyMissingHeader(t *testing.T) {
+	assert.ErrorIs(t, Verify("secret", http.Header{}, nil, time.Minute), ErrMissingHeader)
+}
+
+func TestVerifyInvalidTimestamp(t *testing.T) {
+	header := http.Header{}
+	header.Set(TimestampHeader, "now")
+	header.Set(SignatureHeader, Sign("secret", 0, nil))
+
+	assert.ErrorIs(t, Verify("secret", header, nil, time.Minute), ErrInvalidTimestamp)
+}
+
+func TestVerifyExpired(t *testing.T) {
+	header := http.Header{}
+
+	SetHeaders(header, "secret", time.Now().Add(-time.Hour), nil)
+
+	assert.ErrorIs(t, Verify("secret", header, nil, time.Minute), ErrExpired)
+}
+
+func TestVerifyMismatch(t *testing.T) {
+	header := http.Header{}
+
+	SetHeaders(header, "secret", time.Now(), []byte("original"))
+
+	assert.ErrorIs(t, Verify("secret", header, []byte("tampered"), time.Minute), ErrMismatch)
+	assert.ErrorIs(t, Verify("another", header, []byte("original"), time.Minute), ErrMismatch)
+}
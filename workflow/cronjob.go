@@ -0,0 +1,204 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	kubernetes "k8s.io/client-go/kubernetes"
+)
+
+var CronJobResource = schema.GroupVersionResource{
+	Group:    "batch",
+	Version:  "v1",
+	Resource: "cronjobs",
+}
+
+var JobResource = schema.GroupVersionResource{
+	Group:    "batch",
+	Version:  "v1",
+	Resource: "jobs",
+}
+
+var PodResource = schema.GroupVersionResource{
+	Version:  "v1",
+	Resource: "pods",
+}
+
+// CronJob runs triggers as plain batch/v1 CronJobs, for clusters without
+// Argo Workflows. Applying manifests, diffing and pruning are shared with
+// Workflow, only the objects and how their runs are read differ.
+type CronJob struct {
+	*Workflow
+}
+
+func NewCronJob(ctx context.Context, api dynamic.Interface, clientset kubernetes.Interface) *CronJob {
+	return &CronJob{Workflow: NewWorkflow(ctx, api, clientset)}
+}
+
+// Render returns the Namespace, Secret and CronJob of trigger.
+func (cj *CronJob) Render(trigger *model.Trigger) ([]byte, error) {
+	return Render("cronjob.yaml", trigger)
+}
+
+func (cj *CronJob) Suspend(namespace, name string, suspend bool) error {
+	return cj.suspend(CronJobResource, namespace, name, suspend)
+}
+
+func (cj *CronJob) Executions(namespace string) (model.ExecutionCollection, error) {
+	jobs, err := cj.api.Resource(JobResource).Namespace(namespace).List(cj.ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := cj.api.Resource(PodResource).Namespace(namespace).List(cj.ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	executions := make(model.ExecutionCollection, 0, len(jobs.Items))
+	for i := range jobs.Items {
+		execution, err := NewJobExecution(&jobs.Items[i], pods.Items)
+		if err != nil {
+			return nil, err
+		}
+
+		executions = append(executions, execution)
+	}
+
+	return executions, nil
+}
+
+// Submit creates a one-off Job from the template of a CronJob, which is what
+// "kubectl create job --from=cronjob/<name>" does.
+func (cj *CronJob) Submit(namespace, name string) (*model.Execution, error) {
+	cronJob, err := cj.api.Resource(CronJobResource).Namespace(namespace).Get(cj.ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	spec, found, err := unstructured.NestedMap(cronJob.Object, "spec", "jobTemplate", "spec")
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, fmt.Errorf("cronjob %s/%s has no jobTemplate", namespace, name)
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]any{"spec": spec}}
+	obj.SetAPIVersion("batch/v1")
+	obj.SetKind("Job")
+	obj.SetNamespace(namespace)
+	obj.SetGenerateName(name + "-")
+	obj.SetAnnotations(map[string]string{"cronjob.kubernetes.io/instantiate": "manual"})
+	obj.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion: cronJob.GetAPIVersion(),
+		Kind:       cronJob.GetKind(),
+		Name:       cronJob.GetName(),
+		UID:        cronJob.GetUID(),
+	}})
+
+	created, err := cj.api.Resource(JobResource).Namespace(namespace).Create(cj.ctx, obj, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJobExecution(created, nil)
+}
+
+// NewJobExecution reads the state of a Job, the HTTP status code is the
+// termination message of the pod of the Job that finished last.
+func NewJobExecution(obj *unstructured.Unstructured, pods []unstructured.Unstructured) (*model.Execution, error) {
+	id, err := uuid.Parse(string(obj.GetUID()))
+	if err != nil {
+		return nil, err
+	}
+
+	execution := &model.Execution{
+		ID:        id,
+		Name:      obj.GetName(),
+		Phase:     model.Pending,
+		StartedAt: obj.GetCreationTimestamp().Time,
+	}
+
+	if startedAt, ok := nestedTime(obj.Object, "status", "startTime"); ok {
+		execution.StartedAt = startedAt
+		execution.Phase = model.Running
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, condition := range conditions {
+		condition, ok := condition.(map[string]any)
+		if !ok || condition["status"] != "True" {
+			continue
+		}
+
+		switch condition["type"] {
+		case "Complete":
+			execution.Phase = model.Succeeded
+		case "Failed":
+			execution.Phase = model.Failed
+		default:
+			continue
+		}
+
+		if finishedAt, ok := nestedTime(condition, "lastTransitionTime"); ok {
+			execution.FinishedAt = &finishedAt
+		}
+	}
+
+	if finishedAt, ok := nestedTime(obj.Object, "status", "completionTime"); ok {
+		execution.FinishedAt = &finishedAt
+	}
+
+	if execution.FinishedAt != nil {
+		execution.Duration = execution.FinishedAt.Sub(execution.StartedAt).Milliseconds()
+	}
+
+	var last time.Time
+	for i := range pods {
+		if !ownedBy(&pods[i], obj) {
+			continue
+		}
+
+		statuses, _, _ := unstructured.NestedSlice(pods[i].Object, "status", "containerStatuses")
+		for _, status := range statuses {
+			status, ok := status.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			message, _, _ := unstructured.NestedString(status, "state", "terminated", "message")
+			code, err := strconv.Atoi(strings.TrimSpace(message))
+			if err != nil {
+				continue
+			}
+
+			if finishedAt, _ := nestedTime(status, "state", "terminated", "finishedAt"); !finishedAt.Before(last) {
+				last = finishedAt
+				execution.StatusCode = code
+			}
+		}
+	}
+
+	return execution, nil
+}
+
+func ownedBy(obj, owner *unstructured.Unstructured) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID == owner.GetUID() {
+			return true
+		}
+	}
+
+	return false
+}
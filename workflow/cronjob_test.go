@@ -0,0 +1,219 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/thanhpk/randstr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newBatchObject(kind, namespace, name string, fields map[string]any) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: fields}
+	obj.SetAPIVersion("batch/v1")
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+
+	return obj
+}
+
+func newPod(namespace string, owner *unstructured.Unstructured, finishedAt time.Time, message string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"status": map[string]any{
+			"containerStatuses": []any{
+				map[string]any{
+					"name": "curl",
+					"state": map[string]any{
+						"terminated": map[string]any{
+							"finishedAt": finishedAt.Format(time.RFC3339),
+							"message":    message,
+						},
+					},
+				},
+			},
+		},
+	}}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("Pod")
+	obj.SetNamespace(namespace)
+	obj.SetName(randstr.String(16))
+	obj.SetOwnerReferences([]metav1.OwnerReference{{Kind: "Job", Name: owner.GetName(), UID: owner.GetUID()}})
+
+	return obj
+}
+
+func newCronJob(objects ...runtime.Object) *CronJob {
+	return &CronJob{Workflow: newWorkflow(objects...)}
+}
+
+func TestNewJobExecution(t *testing.T) {
+	id := uuid.New()
+	started := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	finished := started.Add(2 * time.Second)
+
+	obj := newBatchObject("Job", uuid.NewString(), randstr.String(16), map[string]any{
+		"status": map[string]any{
+			"startTime": started.Format(time.RFC3339),
+			"conditions": []any{
+				map[string]any{
+					"type":               "Failed",
+					"status":             "True",
+					"lastTransitionTime": finished.Format(time.RFC3339),
+				},
+			},
+		},
+	})
+	obj.SetUID(types.UID(id.String()))
+
+	other := newBatchObject("Job", obj.GetNamespace(), randstr.String(16), map[string]any{})
+	other.SetUID(types.UID(uuid.NewString()))
+
+	pods := []unstructured.Unstructured{
+		*newPod(obj.GetNamespace(), obj, started, "503"),
+		*newPod(obj.GetNamespace(), obj, finished, "500\n"),
+		*newPod(obj.GetNamespace(), other, finished.Add(time.Second), "200"),
+	}
+
+	execution, err := NewJobExecution(obj, pods)
+	assert.NoError(t, err)
+	assert.Equal(t, id, execution.ID)
+	assert.Equal(t, model.Failed, execution.Phase)
+	assert.Equal(t, 500, execution.StatusCode)
+	assert.Equal(t, started, execution.StartedAt.UTC())
+	assert.Equal(t, int64(2000), execution.Duration)
+}
+
+func TestNewJobExecutionPhases(t *testing.T) {
+	obj := newBatchObject("Job", uuid.NewString(), randstr.String(16), map[string]any{})
+	obj.SetUID(types.UID(uuid.NewString()))
+
+	execution, err := NewJobExecution(obj, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, model.Pending, execution.Phase)
+	assert.Nil(t, execution.FinishedAt)
+
+	assert.NoError(t, unstructured.SetNestedField(obj.Object, time.Now().Format(time.RFC3339), "status", "startTime"))
+
+	execution, err = NewJobExecution(obj, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, model.Running, execution.Phase)
+
+	assert.NoError(t, unstructured.SetNestedSlice(obj.Object, []any{
+		map[string]any{"type": "Complete", "status": "True"},
+	}, "status", "conditions"))
+	assert.NoError(t, unstructured.SetNestedField(obj.Object, time.Now().Format(time.RFC3339), "status", "completionTime"))
+
+	execution, err = NewJobExecution(obj, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, model.Succeeded, execution.Phase)
+	assert.NotNil(t, execution.FinishedAt)
+}
+
+func TestCronJobExecutions(t *testing.T) {
+	namespace := uuid.NewString()
+
+	obj := newBatchObject("Job", namespace, randstr.String(16), map[string]any{
+		"status": map[string]any{
+			"conditions": []any{map[string]any{"type": "Complete", "status": "True"}},
+		},
+	})
+	obj.SetUID(types.UID(uuid.NewString()))
+
+	other := newBatchObject("Job", uuid.NewString(), randstr.String(16), map[string]any{})
+	other.SetUID(types.UID(uuid.NewString()))
+
+	executions, err := newCronJob(obj, other, newPod(namespace, obj, time.Now(), "204")).Executions(namespace)
+	assert.NoError(t, err)
+	assert.Len(t, executions, 1)
+	assert.Equal(t, model.Succeeded, executions[0].Phase)
+	assert.Equal(t, 204, executions[0].StatusCode)
+}
+
+func TestCronJobSuspend(t *testing.T) {
+	namespace, name := uuid.NewString(), randstr.String(16)
+
+	cj := newCronJob(newBatchObject("CronJob", namespace, name, map[string]any{
+		"spec": map[string]any{"suspend": false},
+	}))
+
+	assert.NoError(t, cj.Suspend(namespace, name, true))
+
+	actual, err := cj.api.Resource(CronJobResource).Namespace(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	suspend, _, _ := unstructured.NestedBool(actual.Object, "spec", "suspend")
+	assert.True(t, suspend)
+}
+
+func TestCronJobSubmit(t *testing.T) {
+	namespace, name := uuid.NewString(), randstr.String(16)
+	id := uuid.New()
+
+	obj := newBatchObject("CronJob", namespace, name, map[string]any{
+		"spec": map[string]any{
+			"schedule": "* * * * *",
+			"jobTemplate": map[string]any{
+				"spec": map[string]any{"backoffLimit": int64(0)},
+			},
+		},
+	})
+	obj.SetUID(types.UID(uuid.NewString()))
+
+	cj := newCronJob(obj)
+	cj.api.(*dynamicfake.FakeDynamicClient).PrependReactor("create", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		created := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		created.SetName(created.GetGenerateName() + randstr.String(5))
+		created.SetUID(types.UID(id.String()))
+		return false, created, nil
+	})
+
+	execution, err := cj.Submit(namespace, name)
+	assert.NoError(t, err)
+	assert.Equal(t, id, execution.ID)
+	assert.Equal(t, model.Pending, execution.Phase)
+
+	list, err := cj.api.Resource(JobResource).Namespace(namespace).List(context.Background(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, list.Items, 1)
+	assert.Equal(t, obj.GetUID(), list.Items[0].GetOwnerReferences()[0].UID)
+	assert.Equal(t, "manual", list.Items[0].GetAnnotations()["cronjob.kubernetes.io/instantiate"])
+}
+
+func TestCronJobSubmitNotFound(t *testing.T) {
+	_, err := newCronJob().Submit(uuid.NewString(), randstr.String(16))
+	assert.Error(t, err)
+}
+
+func TestCronJobApply(t *testing.T) {
+	cj := newCronJob()
+	trigger := newTrigger()
+
+	manifest, err := cj.Render(trigger)
+	assert.NoError(t, err)
+
+	_, err = cj.Apply(manifest, Deploy)
+	assert.NoError(t, err)
+
+	_, err = cj.api.Resource(CronJobResource).Namespace(trigger.ID.String()).Get(context.Background(), trigger.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	missing, drifted, err := cj.Diff(manifest)
+	assert.NoError(t, err)
+	assert.Empty(t, missing)
+
+	// The fake client keeps stringData as is, so only the Secret looks drifted.
+	objects, err := Decode(drifted)
+	assert.NoError(t, err)
+	assert.Len(t, objects, 1)
+	assert.Equal(t, "Secret", objects[0].GetKind())
+}
@@ -0,0 +1,29 @@
+package workflow
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error is returned by Apply when the cluster fails to perform an operation on
+// one of the objects of a manifest.
+type Error struct {
+	Op        Operation
+	Kind      string
+	Namespace string
+	Name      string
+	Err       error
+}
+
+func (e *Error) Error() string {
+	name := e.Name
+	if e.Namespace != "" {
+		name = e.Namespace + "/" + e.Name
+	}
+
+	return fmt.Sprintf("%s %s %s: %v", e.Op, strings.ToLower(e.Kind), name, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
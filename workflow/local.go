@@ -0,0 +1,741 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+	"github.com/skhaz/scheduler/signature"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"k8s.io/utils/clock"
+)
+
+const (
+	// maxExecutions is how many runs of a trigger Local keeps in memory,
+	// older ones are only found in the executions table.
+	maxExecutions = 100
+
+	// maxResponse is how much of a response body is read to check the
+	// assertions of a trigger on it.
+	maxResponse = 1 << 20
+
+	pageSize = 100
+
+	// reloadInterval is how often the leader reloads the triggers, picking up
+	// the ones created, changed or deleted through the other replicas.
+	reloadInterval = 10 * time.Second
+
+	// jobRetention is how long finished jobs are kept in the queue, their
+	// outcome stays in the executions table.
+	jobRetention = 24 * time.Hour
+)
+
+var ErrNotScheduled = errors.New("trigger is not scheduled")
+
+// Local runs triggers from the scheduler process itself, without Kubernetes.
+// The namespace of a trigger is its ID, as in the cluster backends.
+//
+// Only the leader fires triggers, from the schedule it reloads from the
+// triggers table. Apply and Suspend only take effect right away on the
+// leader, the other replicas leave it to the table, and Submit goes through
+// the job queue when there is one so any replica can run a trigger.
+type Local struct {
+	ctx      context.Context
+	registry *repository.RepositoryRegistry
+	client   *http.Client
+	clock    clock.WithTicker
+	logger   *zap.Logger
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+type entry struct {
+	created    time.Time
+	trigger    *model.Trigger
+	manifest   []byte
+	schedule   cron.Schedule
+	location   *time.Location
+	next       time.Time
+	cancel     context.CancelFunc
+	executions model.ExecutionCollection
+}
+
+// localManifest carries the secret and the deletion time, which are never
+// marshaled with a trigger.
+type localManifest struct {
+	*model.Trigger
+	Secret    string         `json:"secret"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at"`
+}
+
+func NewLocal(ctx context.Context, registry *repository.RepositoryRegistry, client *http.Client, clock clock.WithTicker, logger *zap.Logger) *Local {
+	return &Local{
+		ctx:      ctx,
+		registry: registry,
+		client:   client,
+		clock:    clock,
+		logger:   logger,
+		entries:  map[string]*entry{},
+	}
+}
+
+// Start schedules every trigger of the repository, so a restart picks up
+// where the previous process stopped, then fires them until ctx is done,
+// reloading them every reloadInterval.
+func (l *Local) Start(ctx context.Context) error {
+	if err := l.Load(); err != nil {
+		return err
+	}
+
+	ticker := l.clock.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	reload := l.clock.NewTicker(reloadInterval)
+	defer reload.Stop()
+
+	clean := l.clock.NewTicker(time.Hour)
+	defer clean.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C():
+			l.tick(now)
+		case <-reload.C():
+			if err := l.Load(); err != nil {
+				l.logger.Error("failed to reload triggers", zap.Error(err))
+			}
+		case now := <-clean.C():
+			l.clean(now)
+		}
+	}
+}
+
+// clean deletes the jobs that finished more than jobRetention ago.
+func (l *Local) clean(now time.Time) {
+	jobs, ok := l.jobs()
+	if !ok {
+		return
+	}
+
+	if _, err := jobs.(repository.Queue).Clean(now.Add(-jobRetention)); err != nil {
+		l.logger.Error("failed to clean jobs", zap.Error(err))
+	}
+}
+
+// Load schedules the triggers of the repository and unschedules the ones no
+// longer in it. Soft deleted triggers stay scheduled, and never fire, until
+// purged, as the reconciler expects. A trigger that cannot be scheduled is
+// logged and skipped.
+// Entries scheduled within the last reloadInterval are kept even when they
+// are not listed, as their trigger may not be committed yet.
+func (l *Local) Load() error {
+	started := l.clock.Now()
+	listed := map[string]bool{}
+
+	triggerRepository := l.registry.MustRepository("TriggerRepository")
+
+	var after *model.Trigger
+	for {
+		e, err := triggerRepository.List(time.Time{}, pageSize, repository.WithDeleted, repository.AfterTrigger(after))
+		if err != nil {
+			return err
+		}
+
+		triggers := e.(model.TriggerCollection)
+		for _, trigger := range triggers {
+			listed[trigger.ID.String()] = true
+
+			manifest, err := l.Render(trigger)
+			if err == nil {
+				err = l.schedule(trigger, manifest)
+			}
+
+			if err != nil {
+				l.logger.Error("failed to schedule trigger", zap.Stringer("trigger", trigger.ID), zap.Error(err))
+			}
+		}
+
+		last, ok := model.Last(triggers)
+		if !ok || len(triggers) < pageSize {
+			break
+		}
+
+		after = last
+	}
+
+	l.mu.Lock()
+	var gone []string
+	for namespace, e := range l.entries {
+		if !listed[namespace] && e.created.Before(started.Add(-reloadInterval)) {
+			gone = append(gone, namespace)
+		}
+	}
+	l.mu.Unlock()
+
+	for _, namespace := range gone {
+		l.unschedule(namespace)
+	}
+
+	return nil
+}
+
+// Render returns the trigger itself, secret included, as JSON. UpdatedAt is
+// left out since it changes on every write without changing the schedule.
+func (l *Local) Render(trigger *model.Trigger) ([]byte, error) {
+	t := *trigger
+	t.UpdatedAt = time.Time{}
+
+	return json.Marshal(localManifest{Trigger: &t, Secret: t.Secret, DeletedAt: t.DeletedAt})
+}
+
+func decodeLocal(manifest []byte) (*model.Trigger, error) {
+	m := localManifest{Trigger: &model.Trigger{}}
+	if err := json.Unmarshal(manifest, &m); err != nil {
+		return nil, err
+	}
+
+	m.Trigger.Secret = m.Secret
+	m.Trigger.DeletedAt = m.DeletedAt
+
+	return m.Trigger, nil
+}
+
+func (l *Local) Apply(manifest []byte, op Operation) ([]Result, error) {
+	trigger, err := decodeLocal(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	result := Result{Kind: "Trigger", Namespace: trigger.ID.String(), Name: trigger.Name}
+
+	switch op {
+	case Deploy, Replace:
+		if err := l.schedule(trigger, manifest); err != nil {
+			return nil, &Error{Op: op, Kind: result.Kind, Namespace: result.Namespace, Name: result.Name, Err: err}
+		}
+
+		result.Action = Applied
+
+	case Displace:
+		result.Action = Absent
+		if l.unschedule(result.Namespace) {
+			result.Action = Deleted
+		}
+	}
+
+	return []Result{result}, nil
+}
+
+func (l *Local) schedule(trigger *model.Trigger, manifest []byte) error {
+	schedule, err := cron.ParseStandard(trigger.Schedule)
+	if err != nil {
+		return err
+	}
+
+	location, err := time.LoadLocation(trigger.Timezone)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[trigger.ID.String()]
+	if !ok {
+		e = &entry{created: l.clock.Now()}
+		l.entries[trigger.ID.String()] = e
+	}
+
+	// Reloading a trigger that did not change keeps its next firing.
+	if ok && bytes.Equal(e.manifest, manifest) {
+		return nil
+	}
+
+	e.trigger = trigger
+	e.manifest = manifest
+	e.schedule = schedule
+	e.location = location
+	e.next = schedule.Next(l.clock.Now().In(location))
+
+	return nil
+}
+
+func (l *Local) unschedule(namespace string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[namespace]
+	if !ok {
+		return false
+	}
+
+	if e.cancel != nil {
+		e.cancel()
+	}
+
+	delete(l.entries, namespace)
+
+	return true
+}
+
+// Suspend pauses or resumes a scheduled trigger. A trigger that is not
+// scheduled here, as on the replicas that do not lead, is left to the next
+// reload of the leader.
+func (l *Local) Suspend(namespace, name string, suspend bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[namespace]
+	if !ok {
+		return nil
+	}
+
+	enabled := !suspend
+
+	trigger := *e.trigger
+	trigger.Enabled = &enabled
+
+	manifest, err := l.Render(&trigger)
+	if err != nil {
+		return err
+	}
+
+	e.trigger = &trigger
+	e.manifest = manifest
+	e.next = e.schedule.Next(l.clock.Now().In(e.location))
+
+	return nil
+}
+
+func (l *Local) Executions(namespace string) (model.ExecutionCollection, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	executions := model.ExecutionCollection{}
+	if e, ok := l.entries[namespace]; ok {
+		for _, execution := range e.executions {
+			execution := *execution
+			executions = append(executions, &execution)
+		}
+	}
+
+	return executions, nil
+}
+
+// Submit runs a trigger once. With a job queue, the run is enqueued like a
+// due firing and the execution is pending until a worker claims it.
+func (l *Local) Submit(namespace, name string) (*model.Execution, error) {
+	if jobs, ok := l.jobs(); ok {
+		return l.enqueue(jobs, namespace, name)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[namespace]
+	if !ok {
+		return nil, ErrNotScheduled
+	}
+
+	return l.start(e, l.clock.Now()), nil
+}
+
+func (l *Local) enqueue(jobs repository.Repository, namespace, name string) (*model.Execution, error) {
+	id, err := uuid.Parse(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	now := l.clock.Now()
+
+	e, err := jobs.Create(&model.Job{TriggerID: id, RunAt: now, Manual: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.Execution{
+		ID:        e.(*model.Job).ID,
+		TriggerID: id,
+		Name:      fmt.Sprintf("%s-%d", name, now.Unix()),
+		Phase:     model.Pending,
+		StartedAt: now,
+	}, nil
+}
+
+func (l *Local) Diff(manifest []byte) ([]byte, []byte, error) {
+	trigger, err := decodeLocal(manifest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[trigger.ID.String()]
+	switch {
+	case !ok:
+		return manifest, nil, nil
+	case !bytes.Equal(e.manifest, manifest):
+		return nil, manifest, nil
+	}
+
+	return nil, nil, nil
+}
+
+// Namespaces lists the triggers scheduled before the given time.
+func (l *Local) Namespaces(before time.Time) ([]string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	namespaces := make([]string, 0, len(l.entries))
+	for namespace, e := range l.entries {
+		if !e.created.Before(before) {
+			continue
+		}
+
+		namespaces = append(namespaces, namespace)
+	}
+
+	return namespaces, nil
+}
+
+func (l *Local) Prune(namespace string) error {
+	l.unschedule(namespace)
+
+	return nil
+}
+
+// tick fires the triggers that are due. With a job queue, firings are only
+// enqueued, to be run by whichever worker claims them.
+func (l *Local) tick(now time.Time) {
+	jobs, queued := l.jobs()
+
+	var due []*model.Trigger
+
+	l.mu.Lock()
+	for _, e := range l.entries {
+		if !e.trigger.IsEnabled() || now.Before(e.next) {
+			continue
+		}
+
+		e.next = e.schedule.Next(now.In(e.location))
+
+		if queued {
+			due = append(due, e.trigger)
+		} else {
+			l.start(e, now)
+		}
+	}
+	l.mu.Unlock()
+
+	for _, trigger := range due {
+		if _, err := jobs.Create(&model.Job{TriggerID: trigger.ID, RunAt: now}); err != nil {
+			l.logger.Error("failed to enqueue trigger", zap.Stringer("trigger", trigger.ID), zap.Error(err))
+		}
+	}
+}
+
+func (l *Local) jobs() (repository.Repository, bool) {
+	jobs, err := l.registry.Repository("JobRepository")
+	if err != nil {
+		return nil, false
+	}
+
+	_, ok := jobs.(repository.Queue)
+
+	return jobs, ok
+}
+
+// Work claims the jobs enqueued by the leader and runs them until ctx is
+// done. Every replica can run it, the queue hands each job to one worker and
+// gives it to another one when its worker stops extending its lock, which it
+// does every third of visibility while the job runs.
+func (l *Local) Work(ctx context.Context, worker string, visibility time.Duration) error {
+	jobs, ok := l.jobs()
+	if !ok {
+		return errors.New("there is no job queue")
+	}
+
+	queue := jobs.(repository.Queue)
+
+	ticker := l.clock.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C():
+			claimed, err := queue.Claim(worker, pageSize, visibility, now)
+			if err != nil {
+				l.logger.Error("failed to claim jobs", zap.Error(err))
+				continue
+			}
+
+			for _, job := range claimed {
+				go l.runJob(ctx, queue, worker, visibility, job)
+			}
+		}
+	}
+}
+
+func (l *Local) runJob(ctx context.Context, queue repository.Queue, worker string, visibility time.Duration, job *model.Job) {
+	fail := func(reason string) {
+		now := l.clock.Now()
+
+		if _, err := queue.Fail(job.ID, worker, reason, now.Add(time.Minute), now); err != nil {
+			l.logger.Error("failed to fail job", zap.Stringer("job", job.ID), zap.Error(err))
+		}
+	}
+
+	if job.Attempts > job.MaxAttempts {
+		fail("the job used all of its attempts")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go l.heartbeat(ctx, cancel, queue, worker, visibility, job)
+
+	skip := func(msg string) {
+		l.logger.Info(msg, zap.Stringer("job", job.ID), zap.Stringer("trigger", job.TriggerID))
+
+		if _, err := queue.Complete(job.ID, worker); err != nil {
+			l.logger.Error("failed to complete job", zap.Stringer("job", job.ID), zap.Error(err))
+		}
+	}
+
+	triggerRepository := l.registry.MustRepository("TriggerRepository")
+
+	e, err := triggerRepository.Get(job.TriggerID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Get leaves out the soft deleted triggers, whose jobs are not run
+		// whether they were manual or not. Only the jobs of a trigger missing
+		// altogether are failed, as purging a trigger deletes its jobs.
+		if restorer, ok := triggerRepository.(repository.Restorer); ok {
+			if _, deletedErr := restorer.Deleted(job.TriggerID); deletedErr == nil {
+				skip("skipped the job of a deleted trigger")
+				return
+			}
+		}
+	}
+
+	if err != nil {
+		fail(err.Error())
+		return
+	}
+
+	trigger := e.(*model.Trigger)
+
+	// The trigger may have been paused since the job was enqueued.
+	if !trigger.IsEnabled() && !job.Manual {
+		skip("skipped the job of a disabled trigger")
+		return
+	}
+
+	execution := &model.Execution{
+		ID:        job.ID,
+		TriggerID: trigger.ID,
+		Name:      fmt.Sprintf("%s-%d", trigger.Name, job.RunAt.Unix()),
+		Phase:     model.Running,
+		StartedAt: l.clock.Now(),
+	}
+
+	l.record(execution)
+	status, body := l.call(ctx, trigger)
+	complete(execution, trigger, status, body, l.clock.Now())
+	l.record(execution)
+
+	if _, err := queue.Complete(job.ID, worker); err != nil {
+		l.logger.Error("failed to complete job", zap.Stringer("job", job.ID), zap.Error(err))
+	}
+}
+
+// heartbeat extends the lock of worker on job every third of visibility until
+// ctx is done. When the lock is lost, the job was given to another worker, so
+// the run is cancelled through cancel.
+func (l *Local) heartbeat(ctx context.Context, cancel context.CancelFunc, queue repository.Queue, worker string, visibility time.Duration, job *model.Job) {
+	ticker := l.clock.NewTicker(visibility / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C():
+			held, err := queue.Extend(job.ID, worker, visibility, now)
+			if err != nil {
+				l.logger.Warn("failed to extend job", zap.Stringer("job", job.ID), zap.Error(err))
+				continue
+			}
+
+			if !held {
+				l.logger.Warn("lost the lock of job", zap.Stringer("job", job.ID))
+				cancel()
+
+				return
+			}
+		}
+	}
+}
+
+// start runs a trigger in the background. As with the Replace concurrency
+// policy of the cluster backends, a run still going on is cancelled first.
+// It must be called with l.mu held.
+func (l *Local) start(e *entry, now time.Time) *model.Execution {
+	if e.cancel != nil {
+		e.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(l.ctx)
+	e.cancel = cancel
+
+	execution := &model.Execution{
+		ID:        uuid.New(),
+		TriggerID: e.trigger.ID,
+		Name:      fmt.Sprintf("%s-%d", e.trigger.Name, now.Unix()),
+		Phase:     model.Running,
+		StartedAt: now,
+	}
+
+	e.executions = append(e.executions, execution)
+	if len(e.executions) > maxExecutions {
+		e.executions = e.executions[len(e.executions)-maxExecutions:]
+	}
+
+	trigger := *e.trigger
+	snapshot := *execution
+
+	go func() {
+		defer cancel()
+
+		l.record(&snapshot)
+		status, body := l.call(ctx, &trigger)
+		l.finish(execution, &trigger, status, body)
+	}()
+
+	return &snapshot
+}
+
+func (l *Local) finish(execution *model.Execution, trigger *model.Trigger, status int, body []byte) {
+	l.mu.Lock()
+
+	complete(execution, trigger, status, body, l.clock.Now())
+	snapshot := *execution
+
+	l.mu.Unlock()
+
+	l.record(&snapshot)
+}
+
+// complete sets the outcome of an execution, which succeeded when the status
+// is a success for the trigger and the body passes its assertions.
+func complete(execution *model.Execution, trigger *model.Trigger, status int, body []byte, finishedAt time.Time) {
+	execution.FinishedAt = &finishedAt
+	execution.Duration = finishedAt.Sub(execution.StartedAt).Milliseconds()
+	execution.StatusCode = status
+	execution.Phase = model.Failed
+
+	switch {
+	case !trigger.Accepts(status):
+		execution.Message = fmt.Sprintf("status %d is not a success", status)
+	default:
+		if err := trigger.Assert(body); err != nil {
+			execution.Message = err.Error()
+			return
+		}
+
+		execution.Phase = model.Succeeded
+	}
+}
+
+// record stores an execution so its history outlives the process.
+func (l *Local) record(execution *model.Execution) {
+	repository, err := l.registry.Repository("ExecutionRepository")
+	if err != nil {
+		return
+	}
+
+	if _, err := repository.Create(execution); err != nil {
+		l.logger.Error("failed to record execution", zap.Stringer("id", execution.ID), zap.Error(err))
+	}
+}
+
+// call performs the HTTP request of a trigger and returns its status code,
+// zero when no response was received, and body. Responses that are not a
+// success are retried up to Retry times when the retry policy of the trigger says so,
+// waiting the delays of the policy in between.
+func (l *Local) call(ctx context.Context, trigger *model.Trigger) (int, []byte) {
+	policy := trigger.Policy()
+
+	for attempt := 0; ; attempt++ {
+		status, body, err := l.do(ctx, trigger)
+		if err != nil {
+			l.logger.Warn("trigger request failed", zap.Stringer("trigger", trigger.ID), zap.Error(err))
+		}
+
+		if trigger.Accepts(status) || errors.Is(err, context.Canceled) || !policy.Retryable(status) || attempt >= trigger.Retry {
+			return status, body
+		}
+
+		select {
+		case <-ctx.Done():
+			return status, body
+		case <-l.clock.After(policy.Jittered(policy.Delay(attempt), rand.Float64())):
+		}
+	}
+}
+
+func (l *Local) do(ctx context.Context, trigger *model.Trigger) (int, []byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(trigger.Timeout)*time.Second)
+	defer cancel()
+
+	method := trigger.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	body := []byte(trigger.Body)
+
+	req, err := http.NewRequestWithContext(ctx, method, trigger.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	for key, value := range trigger.Headers {
+		req.Header.Set(key, value)
+	}
+
+	if trigger.ContentType != "" {
+		req.Header.Set("Content-Type", trigger.ContentType)
+	}
+
+	signature.SetHeaders(req.Header, trigger.Secret, l.clock.Now(), body)
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	response, err := io.ReadAll(io.LimitReader(resp.Body, maxResponse))
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return resp.StatusCode, response, err
+}
@@ -0,0 +1,761 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/skhaz/scheduler/repository"
+	"github.com/skhaz/scheduler/signature"
+	"github.com/stretchr/testify/assert"
+	"github.com/thanhpk/randstr"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+type TriggerRepository struct {
+	triggers model.TriggerCollection
+	deleted  model.TriggerCollection
+}
+
+func (r *TriggerRepository) Configure(db *gorm.DB)                   {}
+func (r *TriggerRepository) Create(entity any) (any, error)          { return entity, nil }
+func (r *TriggerRepository) Update(id any, entity any) (bool, error) { return true, nil }
+func (r *TriggerRepository) Delete(id any) (bool, error)             { return true, nil }
+func (r *TriggerRepository) List(after time.Time, limit int, scopes ...repository.Scope) (any, error) {
+	return r.triggers, nil
+}
+
+func (r *TriggerRepository) Get(id any) (any, error) {
+	for _, trigger := range r.triggers {
+		if trigger.ID == id {
+			return trigger, nil
+		}
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *TriggerRepository) Deleted(id any) (any, error) {
+	for _, trigger := range r.deleted {
+		if trigger.ID == id {
+			return trigger, nil
+		}
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *TriggerRepository) Restore(id any) (bool, error) { return false, nil }
+func (r *TriggerRepository) Purge(id any) (bool, error)   { return false, nil }
+
+type ExecutionRepository struct {
+	mu         sync.Mutex
+	executions map[uuid.UUID]model.Execution
+}
+
+func (r *ExecutionRepository) Configure(db *gorm.DB)                   {}
+func (r *ExecutionRepository) Get(id any) (any, error)                 { return nil, nil }
+func (r *ExecutionRepository) Update(id any, entity any) (bool, error) { return true, nil }
+func (r *ExecutionRepository) Delete(id any) (bool, error)             { return true, nil }
+func (r *ExecutionRepository) List(after time.Time, limit int, scopes ...repository.Scope) (any, error) {
+	return nil, nil
+}
+
+func (r *ExecutionRepository) Create(entity any) (any, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	execution := entity.(*model.Execution)
+	r.executions[execution.ID] = *execution
+
+	return entity, nil
+}
+
+func (r *ExecutionRepository) get(id uuid.UUID) model.Execution {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.executions[id]
+}
+
+// JobRepository is a queue kept in memory, claims ignore visibility.
+type JobRepository struct {
+	mu   sync.Mutex
+	jobs []*model.Job
+}
+
+func (r *JobRepository) Configure(db *gorm.DB)                   {}
+func (r *JobRepository) Get(id any) (any, error)                 { return nil, nil }
+func (r *JobRepository) Update(id any, entity any) (bool, error) { return true, nil }
+func (r *JobRepository) Delete(id any) (bool, error)             { return true, nil }
+func (r *JobRepository) List(after time.Time, limit int, scopes ...repository.Scope) (any, error) {
+	return nil, nil
+}
+
+func (r *JobRepository) Create(entity any) (any, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job := entity.(*model.Job)
+	job.ID = uuid.New()
+	job.State = model.Queued
+	job.MaxAttempts = 3
+	r.jobs = append(r.jobs, job)
+
+	return entity, nil
+}
+
+func (r *JobRepository) Claim(worker string, limit int, visibility time.Duration, now time.Time) (model.JobCollection, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var claimed model.JobCollection
+	for _, job := range r.jobs {
+		if job.State != model.Queued || job.RunAt.After(now) || len(claimed) == limit {
+			continue
+		}
+
+		job.State = model.Claimed
+		job.ClaimedBy = worker
+		job.Attempts++
+
+		snapshot := *job
+		claimed = append(claimed, &snapshot)
+	}
+
+	return claimed, nil
+}
+
+func (r *JobRepository) Extend(id any, worker string, visibility time.Duration, now time.Time) (bool, error) {
+	return r.set(id, worker, func(job *model.Job) {
+		lockedUntil := now.Add(visibility)
+		job.LockedUntil = &lockedUntil
+	}), nil
+}
+
+func (r *JobRepository) Complete(id any, worker string) (bool, error) {
+	return r.set(id, worker, func(job *model.Job) { job.State = model.Done }), nil
+}
+
+func (r *JobRepository) Fail(id any, worker, reason string, retryAt, now time.Time) (bool, error) {
+	return r.set(id, worker, func(job *model.Job) {
+		job.State = model.Queued
+		if job.Attempts >= job.MaxAttempts {
+			job.State = model.Dead
+		}
+		job.RunAt = retryAt
+		job.LastError = reason
+	}), nil
+}
+
+func (r *JobRepository) Clean(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (r *JobRepository) set(id any, worker string, fn func(job *model.Job)) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, job := range r.jobs {
+		if job.ID == id && job.State == model.Claimed && job.ClaimedBy == worker {
+			fn(job)
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *JobRepository) states() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	states := make([]string, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		states = append(states, job.State)
+	}
+
+	return states
+}
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 30, 0, time.UTC)
+
+func newLocal(triggers ...*model.Trigger) (*Local, *clocktesting.FakeClock, *ExecutionRepository) {
+	clock := clocktesting.NewFakeClock(epoch)
+	executions := &ExecutionRepository{executions: map[uuid.UUID]model.Execution{}}
+	registry := repository.NewRepositoryRegistry(nil, &TriggerRepository{triggers: triggers}, executions)
+
+	return NewLocal(context.Background(), registry, http.DefaultClient, clock, zap.NewNop()), clock, executions
+}
+
+func newLocalTrigger(url string) *model.Trigger {
+	return &model.Trigger{
+		ID:          uuid.New(),
+		Name:        randstr.String(16),
+		Schedule:    "* * * * *",
+		Timezone:    "UTC",
+		Url:         url,
+		Method:      http.MethodPost,
+		Headers:     map[string]string{"Authorization": "Bearer token"},
+		Body:        `{"key":"value"}`,
+		ContentType: "application/json",
+		Success:     http.StatusAccepted,
+		Timeout:     5,
+		Retry:       2,
+		Secret:      randstr.Hex(32),
+	}
+}
+
+// waitExecution waits until the only execution of trigger finished.
+func waitExecution(t *testing.T, l *Local, trigger *model.Trigger) *model.Execution {
+	var execution *model.Execution
+
+	assert.Eventually(t, func() bool {
+		executions, _ := l.Executions(trigger.ID.String())
+		if len(executions) != 1 || executions[0].FinishedAt == nil {
+			return false
+		}
+
+		execution = executions[0]
+		return true
+	}, 5*time.Second, time.Millisecond)
+
+	return execution
+}
+
+func TestLocalStart(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- r
+		bodies <- body
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	trigger := newLocalTrigger(server.URL)
+	l, clock, executions := newLocal(trigger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() { _ = l.Start(ctx) }()
+
+	assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+
+	// Nothing is due before the next minute.
+	clock.Step(time.Second)
+	assert.Len(t, requests, 0)
+
+	clock.Step(29 * time.Second)
+
+	r, body := <-requests, <-bodies
+	assert.Equal(t, http.MethodPost, r.Method)
+	assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+	assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+	assert.Equal(t, trigger.Body, string(body))
+	assert.Equal(t, signature.Sign(trigger.Secret, epoch.Add(30*time.Second).Unix(), body), r.Header.Get(signature.SignatureHeader))
+
+	execution := waitExecution(t, l, trigger)
+	assert.Equal(t, model.Succeeded, execution.Phase)
+	assert.Equal(t, http.StatusAccepted, execution.StatusCode)
+	assert.Equal(t, trigger.ID, execution.TriggerID)
+	assert.Equal(t, epoch.Add(30*time.Second), execution.StartedAt)
+
+	assert.Eventually(t, func() bool {
+		return executions.get(execution.ID).Phase == model.Succeeded
+	}, time.Second, time.Millisecond)
+}
+
+func TestLocalRetry(t *testing.T) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	trigger := newLocalTrigger(server.URL)
+	l, clock, _ := newLocal(trigger)
+	assert.NoError(t, l.Load())
+
+	_, err := l.Submit(trigger.ID.String(), trigger.Name)
+	assert.NoError(t, err)
+
+	assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+	clock.Step(time.Second)
+
+	execution := waitExecution(t, l, trigger)
+	assert.Equal(t, model.Succeeded, execution.Phase)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestLocalRetryPolicy(t *testing.T) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	trigger := newLocalTrigger(server.URL)
+	trigger.RetryPolicy = &model.RetryPolicy{Strategy: model.Fixed, BaseDelay: 10, Statuses: []int{http.StatusConflict}}
+	l, clock, _ := newLocal(trigger)
+	assert.NoError(t, l.Load())
+
+	_, err := l.Submit(trigger.ID.String(), trigger.Name)
+	assert.NoError(t, err)
+
+	for attempt := int32(1); attempt <= 2; attempt++ {
+		assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+		assert.Equal(t, attempt, calls.Load())
+
+		// The delay is fixed, one second short of it is not enough.
+		clock.Step(9 * time.Second)
+		assert.Equal(t, attempt, calls.Load())
+		clock.Step(time.Second)
+	}
+
+	execution := waitExecution(t, l, trigger)
+	assert.Equal(t, model.Succeeded, execution.Phase)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestLocalNotRetryable(t *testing.T) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	trigger := newLocalTrigger(server.URL)
+	trigger.RetryPolicy = &model.RetryPolicy{Statuses: []int{http.StatusTooManyRequests}}
+	l, _, _ := newLocal(trigger)
+	assert.NoError(t, l.Load())
+
+	_, err := l.Submit(trigger.ID.String(), trigger.Name)
+	assert.NoError(t, err)
+
+	execution := waitExecution(t, l, trigger)
+	assert.Equal(t, model.Failed, execution.Phase)
+	assert.Equal(t, http.StatusServiceUnavailable, execution.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestLocalSuccessCriteria(t *testing.T) {
+	for body, phase := range map[string]string{
+		`{"state": "done"}`:    model.Succeeded,
+		`{"state": "pending"}`: model.Failed,
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = w.Write([]byte(body))
+		}))
+
+		trigger := newLocalTrigger(server.URL)
+		trigger.SuccessCriteria = &model.SuccessCriteria{
+			Statuses: []string{"2xx"},
+			Body:     &model.BodyAssertion{JSONPath: "$.state", Equals: json.RawMessage(`"done"`)},
+		}
+		l, _, _ := newLocal(trigger)
+		assert.NoError(t, l.Load())
+
+		_, err := l.Submit(trigger.ID.String(), trigger.Name)
+		assert.NoError(t, err)
+
+		execution := waitExecution(t, l, trigger)
+		assert.Equal(t, phase, execution.Phase)
+		assert.Equal(t, http.StatusPartialContent, execution.StatusCode)
+		if phase == model.Failed {
+			assert.Equal(t, `$.state is not equal to "done"`, execution.Message)
+		}
+
+		server.Close()
+	}
+}
+
+func TestLocalFailure(t *testing.T) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	trigger := newLocalTrigger(server.URL)
+	l, _, _ := newLocal(trigger)
+	assert.NoError(t, l.Load())
+
+	_, err := l.Submit(trigger.ID.String(), trigger.Name)
+	assert.NoError(t, err)
+
+	execution := waitExecution(t, l, trigger)
+	assert.Equal(t, model.Failed, execution.Phase)
+	assert.Equal(t, http.StatusNotFound, execution.StatusCode)
+	assert.Equal(t, "status 404 is not a success", execution.Message)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestLocalSuspend(t *testing.T) {
+	trigger := newLocalTrigger("http://127.0.0.1:0")
+	l, _, _ := newLocal(trigger)
+	assert.NoError(t, l.Load())
+
+	assert.NoError(t, l.Suspend(trigger.ID.String(), trigger.Name, true))
+	l.tick(epoch.Add(time.Hour))
+
+	executions, err := l.Executions(trigger.ID.String())
+	assert.NoError(t, err)
+	assert.Empty(t, executions)
+
+	assert.NoError(t, l.Suspend(trigger.ID.String(), trigger.Name, false))
+	l.tick(epoch.Add(time.Hour))
+
+	executions, err = l.Executions(trigger.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, executions, 1)
+
+	// Triggers scheduled by the leader only are left to its next reload.
+	assert.NoError(t, l.Suspend(uuid.NewString(), trigger.Name, true))
+}
+
+func TestLocalReload(t *testing.T) {
+	kept, gone, added, applied := newLocalTrigger("http://127.0.0.1:0"), newLocalTrigger("http://127.0.0.1:0"), newLocalTrigger("http://127.0.0.1:0"), newLocalTrigger("http://127.0.0.1:0")
+
+	clock := clocktesting.NewFakeClock(epoch)
+	triggers := &TriggerRepository{triggers: model.TriggerCollection{kept, gone}}
+	registry := repository.NewRepositoryRegistry(nil, triggers, &ExecutionRepository{executions: map[uuid.UUID]model.Execution{}})
+	l := NewLocal(context.Background(), registry, http.DefaultClient, clock, zap.NewNop())
+	assert.NoError(t, l.Load())
+
+	next := l.entries[kept.ID.String()].next
+
+	clock.Step(reloadInterval + time.Minute)
+
+	// Applied on the leader, but not committed yet.
+	manifest, err := l.Render(applied)
+	assert.NoError(t, err)
+	_, err = l.Apply(manifest, Deploy)
+	assert.NoError(t, err)
+
+	triggers.triggers = model.TriggerCollection{kept, added}
+	assert.NoError(t, l.Load())
+
+	namespaces, err := l.Namespaces(clock.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{kept.ID.String(), added.ID.String(), applied.ID.String()}, namespaces)
+	assert.Equal(t, next, l.entries[kept.ID.String()].next)
+}
+
+func TestLocalSubmitQueued(t *testing.T) {
+	trigger := newLocalTrigger("http://127.0.0.1:0")
+
+	clock := clocktesting.NewFakeClock(epoch)
+	jobs := &JobRepository{}
+	registry := repository.NewRepositoryRegistry(nil, &TriggerRepository{}, jobs)
+	l := NewLocal(context.Background(), registry, http.DefaultClient, clock, zap.NewNop())
+
+	execution, err := l.Submit(trigger.ID.String(), trigger.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, model.Pending, execution.Phase)
+	assert.Len(t, jobs.jobs, 1)
+	assert.Equal(t, jobs.jobs[0].ID, execution.ID)
+	assert.Equal(t, trigger.ID, jobs.jobs[0].TriggerID)
+	assert.Equal(t, epoch, jobs.jobs[0].RunAt)
+}
+
+func TestLocalTimezone(t *testing.T) {
+	trigger := newLocalTrigger("http://127.0.0.1:0")
+	trigger.Schedule = "0 9 * * *"
+	trigger.Timezone = "America/Sao_Paulo"
+
+	l, _, _ := newLocal(trigger)
+	assert.NoError(t, l.Load())
+
+	assert.Equal(t, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), l.entries[trigger.ID.String()].next.UTC())
+}
+
+func TestLocalApply(t *testing.T) {
+	trigger := newLocalTrigger("http://127.0.0.1:0")
+	l, _, _ := newLocal()
+
+	manifest, err := l.Render(trigger)
+	assert.NoError(t, err)
+	assert.Contains(t, string(manifest), trigger.Secret)
+
+	missing, drifted, err := l.Diff(manifest)
+	assert.NoError(t, err)
+	assert.Equal(t, manifest, missing)
+	assert.Nil(t, drifted)
+
+	results, err := l.Apply(manifest, Deploy)
+	assert.NoError(t, err)
+	assert.Equal(t, Applied, results[0].Action)
+
+	namespaces, err := l.Namespaces(epoch)
+	assert.NoError(t, err)
+	assert.Empty(t, namespaces)
+
+	namespaces, err = l.Namespaces(epoch.Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{trigger.ID.String()}, namespaces)
+
+	missing, drifted, err = l.Diff(manifest)
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+	assert.Nil(t, drifted)
+
+	trigger.Schedule = "0 0 * * *"
+	manifest, err = l.Render(trigger)
+	assert.NoError(t, err)
+
+	_, drifted, err = l.Diff(manifest)
+	assert.NoError(t, err)
+	assert.Equal(t, manifest, drifted)
+
+	results, err = l.Apply(manifest, Displace)
+	assert.NoError(t, err)
+	assert.Equal(t, Deleted, results[0].Action)
+
+	results, err = l.Apply(manifest, Displace)
+	assert.NoError(t, err)
+	assert.Equal(t, Absent, results[0].Action)
+}
+
+func TestLocalApplyDeleted(t *testing.T) {
+	trigger := newLocalTrigger("http://127.0.0.1:0")
+	trigger.DeletedAt = gorm.DeletedAt{Time: epoch, Valid: true}
+	l, _, _ := newLocal()
+
+	manifest, err := l.Render(trigger)
+	assert.NoError(t, err)
+
+	_, err = l.Apply(manifest, Deploy)
+	assert.NoError(t, err)
+	l.tick(epoch.Add(time.Hour))
+
+	executions, err := l.Executions(trigger.ID.String())
+	assert.NoError(t, err)
+	assert.Empty(t, executions)
+
+	trigger.DeletedAt = gorm.DeletedAt{}
+	manifest, err = l.Render(trigger)
+	assert.NoError(t, err)
+
+	_, err = l.Apply(manifest, Replace)
+	assert.NoError(t, err)
+	l.tick(epoch.Add(time.Hour))
+
+	executions, err = l.Executions(trigger.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, executions, 1)
+}
+
+func TestLocalApplyInvalidSchedule(t *testing.T) {
+	trigger := newLocalTrigger("http://127.0.0.1:0")
+	trigger.Schedule = "every minute"
+
+	l, _, _ := newLocal()
+
+	manifest, err := l.Render(trigger)
+	assert.NoError(t, err)
+
+	_, err = l.Apply(manifest, Deploy)
+
+	var e *Error
+	assert.ErrorAs(t, err, &e)
+	assert.Equal(t, Deploy, e.Op)
+}
+
+func TestLocalWork(t *testing.T) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	trigger := newLocalTrigger(server.URL)
+
+	clock := clocktesting.NewFakeClock(epoch)
+	executions := &ExecutionRepository{executions: map[uuid.UUID]model.Execution{}}
+	jobs := &JobRepository{}
+	registry := repository.NewRepositoryRegistry(nil, &TriggerRepository{triggers: model.TriggerCollection{trigger}}, executions, jobs)
+	l := NewLocal(context.Background(), registry, http.DefaultClient, clock, zap.NewNop())
+	assert.NoError(t, l.Load())
+
+	// The leader only enqueues what is due.
+	l.tick(epoch.Add(30 * time.Second))
+	assert.Equal(t, []string{model.Queued}, jobs.states())
+	assert.Zero(t, calls.Load())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() { _ = l.Work(ctx, "replica-1", time.Minute) }()
+
+	assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+	clock.Step(30 * time.Second)
+
+	assert.Eventually(t, func() bool {
+		states := jobs.states()
+		return len(states) == 1 && states[0] == model.Done
+	}, 5*time.Second, time.Millisecond)
+
+	assert.Equal(t, int32(1), calls.Load())
+
+	execution := executions.get(jobs.jobs[0].ID)
+	assert.Equal(t, trigger.ID, execution.TriggerID)
+	assert.Equal(t, model.Succeeded, execution.Phase)
+	assert.Equal(t, http.StatusAccepted, execution.StatusCode)
+}
+
+func TestLocalWorkMissingTrigger(t *testing.T) {
+	clock := clocktesting.NewFakeClock(epoch)
+	jobs := &JobRepository{}
+	registry := repository.NewRepositoryRegistry(nil, &TriggerRepository{}, jobs)
+	l := NewLocal(context.Background(), registry, http.DefaultClient, clock, zap.NewNop())
+
+	_, _ = jobs.Create(&model.Job{TriggerID: uuid.New(), RunAt: epoch})
+	claimed, _ := jobs.Claim("replica-1", 10, time.Minute, epoch)
+	assert.Len(t, claimed, 1)
+
+	l.runJob(context.Background(), jobs, "replica-1", time.Minute, claimed[0])
+
+	assert.Equal(t, []string{model.Queued}, jobs.states())
+	assert.Equal(t, epoch.Add(time.Minute), jobs.jobs[0].RunAt)
+	assert.Equal(t, gorm.ErrRecordNotFound.Error(), jobs.jobs[0].LastError)
+}
+
+func TestLocalWorkDeletedTrigger(t *testing.T) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	trigger := newLocalTrigger(server.URL)
+
+	clock := clocktesting.NewFakeClock(epoch)
+	jobs := &JobRepository{}
+	registry := repository.NewRepositoryRegistry(nil, &TriggerRepository{deleted: model.TriggerCollection{trigger}}, &ExecutionRepository{executions: map[uuid.UUID]model.Execution{}}, jobs)
+	l := NewLocal(context.Background(), registry, http.DefaultClient, clock, zap.NewNop())
+
+	_, _ = jobs.Create(&model.Job{TriggerID: trigger.ID, RunAt: epoch})
+	_, _ = jobs.Create(&model.Job{TriggerID: trigger.ID, RunAt: epoch, Manual: true})
+	claimed, _ := jobs.Claim("replica-1", 10, time.Minute, epoch)
+	assert.Len(t, claimed, 2)
+
+	// Deleted after the jobs were enqueued, so neither runs nor is retried.
+	l.runJob(context.Background(), jobs, "replica-1", time.Minute, claimed[0])
+	l.runJob(context.Background(), jobs, "replica-1", time.Minute, claimed[1])
+
+	assert.Equal(t, []string{model.Done, model.Done}, jobs.states())
+	assert.Empty(t, jobs.jobs[0].LastError)
+	assert.Zero(t, calls.Load())
+}
+
+func TestLocalWorkDisabledTrigger(t *testing.T) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	enabled := false
+	trigger := newLocalTrigger(server.URL)
+	trigger.Enabled = &enabled
+
+	clock := clocktesting.NewFakeClock(epoch)
+	jobs := &JobRepository{}
+	registry := repository.NewRepositoryRegistry(nil, &TriggerRepository{triggers: model.TriggerCollection{trigger}}, &ExecutionRepository{executions: map[uuid.UUID]model.Execution{}}, jobs)
+	l := NewLocal(context.Background(), registry, http.DefaultClient, clock, zap.NewNop())
+
+	_, _ = jobs.Create(&model.Job{TriggerID: trigger.ID, RunAt: epoch})
+	_, _ = jobs.Create(&model.Job{TriggerID: trigger.ID, RunAt: epoch, Manual: true})
+	claimed, _ := jobs.Claim("replica-1", 10, time.Minute, epoch)
+	assert.Len(t, claimed, 2)
+
+	// Paused after the firing was enqueued, but run by hand.
+	l.runJob(context.Background(), jobs, "replica-1", time.Minute, claimed[0])
+	l.runJob(context.Background(), jobs, "replica-1", time.Minute, claimed[1])
+
+	assert.Equal(t, []string{model.Done, model.Done}, jobs.states())
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestLocalWorkHeartbeat(t *testing.T) {
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	trigger := newLocalTrigger(server.URL)
+
+	clock := clocktesting.NewFakeClock(epoch)
+	jobs := &JobRepository{}
+	registry := repository.NewRepositoryRegistry(nil, &TriggerRepository{triggers: model.TriggerCollection{trigger}}, &ExecutionRepository{executions: map[uuid.UUID]model.Execution{}}, jobs)
+	l := NewLocal(context.Background(), registry, http.DefaultClient, clock, zap.NewNop())
+
+	_, _ = jobs.Create(&model.Job{TriggerID: trigger.ID, RunAt: epoch})
+	claimed, _ := jobs.Claim("replica-1", 10, 3*time.Minute, epoch)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.runJob(context.Background(), jobs, "replica-1", 3*time.Minute, claimed[0])
+	}()
+
+	// The run outlasts the visibility, and keeps its lock all along.
+	assert.Eventually(t, clock.HasWaiters, time.Second, time.Millisecond)
+	clock.Step(time.Minute)
+
+	assert.Eventually(t, func() bool {
+		jobs.mu.Lock()
+		defer jobs.mu.Unlock()
+
+		return jobs.jobs[0].LockedUntil != nil && jobs.jobs[0].LockedUntil.Equal(epoch.Add(4*time.Minute))
+	}, time.Second, time.Millisecond)
+
+	close(release)
+	<-done
+
+	assert.Equal(t, []string{model.Done}, jobs.states())
+}
+
+func TestLocalWorkWithoutQueue(t *testing.T) {
+	l, _, _ := newLocal()
+
+	assert.Error(t, l.Work(context.Background(), "replica-1", time.Minute))
+}
@@ -0,0 +1,180 @@
+package workflow
+
+import (
+	"encoding/base64"
+	"reflect"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	ManagedBy      = "scheduler"
+)
+
+var NamespaceResource = schema.GroupVersionResource{
+	Version:  "v1",
+	Resource: "namespaces",
+}
+
+// Diff compares the objects of a manifest with the ones in the cluster and
+// returns two manifests, one with the objects that are missing, to be
+// deployed, and one with the objects that drifted, to be replaced.
+func (wf *Workflow) Diff(manifest []byte) ([]byte, []byte, error) {
+	objects, err := Decode(manifest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var missing, drifted []*unstructured.Unstructured
+
+	for _, obj := range objects {
+		dri, err := wf.resource(obj)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		live, err := dri.Get(wf.ctx, obj.GetName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			missing = append(missing, obj)
+
+			continue
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !Matches(obj, live) {
+			drifted = append(drifted, obj)
+		}
+	}
+
+	if len(missing) == 0 && len(drifted) == 0 {
+		return nil, nil, nil
+	}
+
+	m, err := Encode(missing)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	d, err := Encode(drifted)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return m, d, nil
+}
+
+// Namespaces lists the namespaces created by the scheduler before the given
+// time.
+func (wf *Workflow) Namespaces(before time.Time) ([]string, error) {
+	list, err := wf.api.Resource(NamespaceResource).List(wf.ctx, metav1.ListOptions{
+		LabelSelector: ManagedByLabel + "=" + ManagedBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		if !item.GetCreationTimestamp().Time.Before(before) {
+			continue
+		}
+
+		namespaces = append(namespaces, item.GetName())
+	}
+
+	return namespaces, nil
+}
+
+// Prune deletes a namespace created by the scheduler, along with everything
+// inside of it.
+func (wf *Workflow) Prune(namespace string) error {
+	err := wf.api.Resource(NamespaceResource).Delete(wf.ctx, namespace, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+// Matches reports whether the live object still has the spec, data and
+// labels of the desired one. Fields only present in the live object, such as
+// the ones defaulted by the API server, are ignored.
+func Matches(desired, live *unstructured.Unstructured) bool {
+	if !subset(desired.GetLabels(), live.GetLabels()) {
+		return false
+	}
+
+	if spec, ok := desired.Object["spec"]; ok && !subset(spec, live.Object["spec"]) {
+		return false
+	}
+
+	data := map[string]any{}
+	if d, ok := desired.Object["data"].(map[string]any); ok {
+		for k, v := range d {
+			data[k] = v
+		}
+	}
+
+	if stringData, ok := desired.Object["stringData"].(map[string]any); ok {
+		for k, v := range stringData {
+			if s, ok := v.(string); ok {
+				data[k] = base64.StdEncoding.EncodeToString([]byte(s))
+			}
+		}
+	}
+
+	return subset(data, live.Object["data"])
+}
+
+func subset(desired, live any) bool {
+	switch d := desired.(type) {
+	case map[string]any:
+		l, ok := live.(map[string]any)
+		if !ok {
+			return len(d) == 0
+		}
+
+		for k, v := range d {
+			if !subset(v, l[k]) {
+				return false
+			}
+		}
+
+		return true
+
+	case map[string]string:
+		l, _ := live.(map[string]string)
+
+		for k, v := range d {
+			if lv, ok := l[k]; !ok || lv != v {
+				return false
+			}
+		}
+
+		return true
+
+	case []any:
+		l, ok := live.([]any)
+		if !ok || len(l) != len(d) {
+			return false
+		}
+
+		for i := range d {
+			if !subset(d[i], l[i]) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return reflect.DeepEqual(desired, live)
+}
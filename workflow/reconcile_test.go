@@ -0,0 +1,126 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newNamespace(name string, labels map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]any{}}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("Namespace")
+	obj.SetName(name)
+	obj.SetLabels(labels)
+
+	return obj
+}
+
+func TestDiffInSync(t *testing.T) {
+	wf := newWorkflow()
+
+	_, err := wf.Apply([]byte(manifest), Deploy)
+	assert.NoError(t, err)
+
+	missing, drifted, err := wf.Diff([]byte(manifest))
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+	assert.Nil(t, drifted)
+}
+
+func TestDiffMissing(t *testing.T) {
+	wf := newWorkflow()
+
+	_, err := wf.Apply([]byte(manifest), Deploy)
+	assert.NoError(t, err)
+	assert.NoError(t, wf.api.Resource(CronWorkflowResource).Namespace("trigger").Delete(context.Background(), "curl", metav1.DeleteOptions{}))
+
+	missing, drifted, err := wf.Diff([]byte(manifest))
+	assert.NoError(t, err)
+	assert.Empty(t, drifted)
+
+	objects, err := Decode(missing)
+	assert.NoError(t, err)
+	assert.Len(t, objects, 1)
+	assert.Equal(t, "CronWorkflow", objects[0].GetKind())
+}
+
+func TestDiffDrifted(t *testing.T) {
+	wf := newWorkflow()
+
+	_, err := wf.Apply([]byte(manifest), Deploy)
+	assert.NoError(t, err)
+
+	live, err := wf.api.Resource(CronWorkflowResource).Namespace("trigger").Get(context.Background(), "curl", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, unstructured.SetNestedField(live.Object, "0 0 * * *", "spec", "schedule"))
+	_, err = wf.api.Resource(CronWorkflowResource).Namespace("trigger").Update(context.Background(), live, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+
+	missing, drifted, err := wf.Diff([]byte(manifest))
+	assert.NoError(t, err)
+	assert.Empty(t, missing)
+
+	objects, err := Decode(drifted)
+	assert.NoError(t, err)
+	assert.Len(t, objects, 1)
+	assert.Equal(t, "curl", objects[0].GetName())
+}
+
+func TestNamespaces(t *testing.T) {
+	recent := newNamespace("recent", map[string]string{ManagedByLabel: ManagedBy})
+	recent.SetCreationTimestamp(metav1.Now())
+
+	wf := newWorkflow(
+		newNamespace("managed", map[string]string{ManagedByLabel: ManagedBy}),
+		newNamespace("kube-system", nil),
+		recent,
+	)
+
+	namespaces, err := wf.Namespaces(time.Now().Add(-time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"managed"}, namespaces)
+}
+
+func TestPrune(t *testing.T) {
+	wf := newWorkflow(newNamespace("managed", map[string]string{ManagedByLabel: ManagedBy}))
+
+	assert.NoError(t, wf.Prune("managed"))
+	assert.NoError(t, wf.Prune("managed"))
+
+	_, err := wf.api.Resource(NamespaceResource).Get(context.Background(), "managed", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestMatches(t *testing.T) {
+	desired := &unstructured.Unstructured{Object: map[string]any{
+		"spec":       map[string]any{"schedule": "* * * * *", "args": []any{"a", "b"}},
+		"stringData": map[string]any{"secret": "s3cr3t"},
+	}}
+	desired.SetLabels(map[string]string{ManagedByLabel: ManagedBy})
+
+	live := &unstructured.Unstructured{Object: map[string]any{
+		"spec":   map[string]any{"schedule": "* * * * *", "args": []any{"a", "b"}, "defaulted": true},
+		"data":   map[string]any{"secret": "czNjcjN0"},
+		"status": map[string]any{"active": true},
+	}}
+	live.SetLabels(map[string]string{ManagedByLabel: ManagedBy, "extra": "label"})
+
+	assert.True(t, Matches(desired, live))
+
+	live.Object["data"] = map[string]any{"secret": "b3RoZXI="}
+	assert.False(t, Matches(desired, live))
+
+	live.Object["data"] = map[string]any{"secret": "czNjcjN0"}
+	live.SetLabels(nil)
+	assert.False(t, Matches(desired, live))
+
+	live.SetLabels(map[string]string{ManagedByLabel: ManagedBy})
+	live.Object["spec"] = map[string]any{"schedule": "* * * * *", "args": []any{"a"}}
+	assert.False(t, Matches(desired, live))
+}
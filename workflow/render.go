@@ -0,0 +1,109 @@
+package workflow
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/skhaz/scheduler/model"
+)
+
+//go:embed manifests/*.yaml
+var manifests embed.FS
+
+var templates = template.Must(newTemplate().ParseFS(manifests, "manifests/*.yaml"))
+
+// newTemplate returns the root template with the functions available to the
+// manifests. Every value that comes from the user must go through json so it
+// is quoted as a YAML scalar and reaches the cluster as data instead of markup
+// or shell code.
+func newTemplate() *template.Template {
+	root := template.New("manifests")
+
+	return root.Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"headers": func(headers map[string]string) string {
+			keys := make([]string, 0, len(headers))
+			for key := range headers {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			var b strings.Builder
+			for _, key := range keys {
+				b.WriteString(key + ": " + headers[key] + "\n")
+			}
+			return b.String()
+		},
+		"delays": func(trigger *model.Trigger) string {
+			policy := trigger.Policy()
+
+			delays := make([]string, 0, trigger.Retry)
+			for attempt := 0; attempt < trigger.Retry; attempt++ {
+				delays = append(delays, strconv.Itoa(int(policy.Delay(attempt).Seconds())))
+			}
+			return strings.Join(delays, " ")
+		},
+		"statuses": func(trigger *model.Trigger) string {
+			statuses := make([]string, 0, len(trigger.Policy().Statuses))
+			for _, status := range trigger.Policy().Statuses {
+				statuses = append(statuses, strconv.Itoa(status))
+			}
+			return strings.Join(statuses, " ")
+		},
+		"ranges": func(trigger *model.Trigger) string {
+			var bounds []string
+			for _, r := range trigger.SuccessRanges() {
+				bounds = append(bounds, strconv.Itoa(r.From), strconv.Itoa(r.To))
+			}
+			return strings.Join(bounds, " ")
+		},
+		"assertion": func(trigger *model.Trigger) *model.BodyAssertion {
+			if trigger.SuccessCriteria == nil {
+				return nil
+			}
+			return trigger.SuccessCriteria.Body
+		},
+		"filter": func(assertion *model.BodyAssertion) string {
+			if assertion.JSONPath == "" {
+				return ""
+			}
+			path, err := model.ParseJSONPath(assertion.JSONPath)
+			if err != nil {
+				return ""
+			}
+			return path.Filter()
+		},
+		"include": func(name string, data any) (string, error) {
+			var buffer bytes.Buffer
+			err := root.ExecuteTemplate(&buffer, name, data)
+			return buffer.String(), err
+		},
+		"indent": func(spaces int, s string) string {
+			lines := strings.Split(s, "\n")
+			for i, line := range lines {
+				if line != "" {
+					lines[i] = strings.Repeat(" ", spaces) + line
+				}
+			}
+			return strings.Join(lines, "\n")
+		},
+	})
+}
+
+// Render executes the manifest template called name for trigger.
+func Render(name string, trigger *model.Trigger) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := templates.ExecuteTemplate(&buffer, name, trigger); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
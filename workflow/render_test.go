@@ -0,0 +1,138 @@
+package workflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/thanhpk/randstr"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+func newTrigger() *model.Trigger {
+	return &model.Trigger{
+		ID:          uuid.New(),
+		Name:        randstr.String(16),
+		Schedule:    "* * * * *",
+		Timezone:    "UTC",
+		Url:         `https://example.com/?a=1&b="$(reboot)"`,
+		Method:      "POST; reboot",
+		Headers:     map[string]string{"Authorization": "Bearer ${TOKEN}", "X-Empty": ""},
+		Body:        "{\"key\": \"value\"}\n---\nkind: Namespace",
+		ContentType: "application/json",
+		Timeout:     30,
+		Retry:       3,
+		RetryPolicy: &model.RetryPolicy{BaseDelay: 5, MaxDelay: 15, Jitter: 0.25, Statuses: []int{429, 503}},
+		Success:     200,
+		SuccessCriteria: &model.SuccessCriteria{
+			Statuses: []string{"2xx", "304"},
+			Body: &model.BodyAssertion{
+				Contains: "$(reboot)",
+				Matches:  `^\{"items"`,
+				JSONPath: `$.items[0]['state; reboot']`,
+				Equals:   json.RawMessage(`"done"`),
+			},
+		},
+		Secret: randstr.Hex(32),
+	}
+}
+
+func decodeDocuments(t *testing.T, b []byte) []map[string]any {
+	var documents []map[string]any
+
+	decoder := yamlutil.NewYAMLOrJSONDecoder(bytes.NewReader(b), 4096)
+	for {
+		document := map[string]any{}
+		if err := decoder.Decode(&document); err != nil {
+			break
+		}
+		documents = append(documents, document)
+	}
+
+	assert.Len(t, documents, 3)
+
+	return documents
+}
+
+func assertEnv(t *testing.T, trigger *model.Trigger, entries []any) {
+	env := map[string]any{}
+	for _, e := range entries {
+		e := e.(map[string]any)
+		env[e["name"].(string)] = e["value"]
+	}
+
+	assert.Equal(t, trigger.Url, env["URL"])
+	assert.Equal(t, trigger.Method, env["METHOD"])
+	assert.Equal(t, "Authorization: Bearer ${TOKEN}\nX-Empty: \n", env["HEADERS"])
+	assert.Equal(t, trigger.Body, env["BODY"])
+	assert.Equal(t, trigger.ContentType, env["CONTENT_TYPE"])
+	assert.Equal(t, "$(reboot)", env["CONTAINS"])
+	assert.Equal(t, `^\{"items"`, env["MATCHES"])
+	assert.Equal(t, `.["items"][0]["state; reboot"]`, env["FILTER"])
+	assert.Equal(t, `"done"`, env["EQUALS"])
+}
+
+func assertScript(t *testing.T, script string) {
+	assert.NotContains(t, script, "reboot")
+	assert.Contains(t, script, "--max-time 30\n")
+	assert.NotContains(t, script, "--retry")
+	assert.Contains(t, script, "declare -a DELAYS=(5 10 15)\n")
+	assert.Contains(t, script, "declare -a RETRYABLE=(0 429 503)\n")
+	assert.Contains(t, script, "-v jitter=0.25 ")
+	assert.Contains(t, script, "declare -a SUCCESS=(200 299 304 304)\n")
+}
+
+func TestRenderArgo(t *testing.T) {
+	trigger := newTrigger()
+
+	b, err := newWorkflow().Render(trigger)
+	assert.NoError(t, err)
+
+	documents := decodeDocuments(t, b)
+	assert.Equal(t, ManagedBy, documents[0]["metadata"].(map[string]any)["labels"].(map[string]any)[ManagedByLabel])
+	assert.Equal(t, trigger.Secret, documents[1]["stringData"].(map[string]any)["secret"])
+
+	spec := documents[2]["spec"].(map[string]any)
+	assert.Equal(t, trigger.Timezone, spec["timezone"])
+	assert.Equal(t, false, spec["suspend"])
+
+	templates := spec["workflowSpec"].(map[string]any)["templates"].([]any)
+	script := templates[0].(map[string]any)["script"].(map[string]any)
+
+	retryStrategy := templates[0].(map[string]any)["retryStrategy"].(map[string]any)
+	assert.EqualValues(t, 3, retryStrategy["limit"])
+	assert.Equal(t, "asInt(lastRetry.exitCode) == 75", retryStrategy["expression"])
+	assert.Equal(t, map[string]any{"duration": "5s", "factor": float64(2), "cap": "15s"}, retryStrategy["backoff"])
+
+	assertEnv(t, trigger, script["env"].([]any))
+	assertScript(t, script["source"].(string))
+}
+
+func TestRenderCronJob(t *testing.T) {
+	trigger := newTrigger()
+	enabled := false
+	trigger.Enabled = &enabled
+
+	b, err := newCronJob().Render(trigger)
+	assert.NoError(t, err)
+
+	documents := decodeDocuments(t, b)
+	assert.Equal(t, "CronJob", documents[2]["kind"])
+	assert.Equal(t, trigger.Secret, documents[1]["stringData"].(map[string]any)["secret"])
+
+	spec := documents[2]["spec"].(map[string]any)
+	assert.Equal(t, trigger.Schedule, spec["schedule"])
+	assert.Equal(t, trigger.Timezone, spec["timeZone"])
+	assert.Equal(t, true, spec["suspend"])
+
+	pod := spec["jobTemplate"].(map[string]any)["spec"].(map[string]any)["template"].(map[string]any)["spec"].(map[string]any)
+	container := pod["containers"].([]any)[0].(map[string]any)
+
+	env := container["env"].([]any)
+	assertEnv(t, trigger, env)
+	assert.Equal(t, map[string]any{"name": "RETRIES", "value": "3"}, env[len(env)-1])
+	assertScript(t, container["args"].([]any)[0].(string))
+}
@@ -0,0 +1,33 @@
+package workflow
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Action string
+
+const (
+	Applied Action = "applied"
+	Deleted Action = "deleted"
+	Absent  Action = "absent"
+)
+
+// Result is what Apply did to one of the objects of a manifest. Absent means
+// a displaced object was already gone.
+type Result struct {
+	Kind            string
+	Namespace       string
+	Name            string
+	Action          Action
+	ResourceVersion string
+}
+
+func (r Result) String() string {
+	name := r.Name
+	if r.Namespace != "" {
+		name = r.Namespace + "/" + r.Name
+	}
+
+	return fmt.Sprintf("%s %s %s", strings.ToLower(r.Kind), name, r.Action)
+}
@@ -3,17 +3,30 @@ package workflow
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/dynamic"
 	kubernetes "k8s.io/client-go/kubernetes"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer/yaml"
+	"k8s.io/apimachinery/pkg/types"
 	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
 
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/restmapper"
 )
 
@@ -25,14 +38,39 @@ const (
 	Displace Operation = "displace"
 )
 
+var CronWorkflowResource = schema.GroupVersionResource{
+	Group:    "argoproj.io",
+	Version:  "v1alpha1",
+	Resource: "cronworkflows",
+}
+
+const CronWorkflowLabel = "workflows.argoproj.io/cron-workflow"
+
+// FieldManager owns the fields set through server-side apply.
+const FieldManager = "scheduler"
+
+var WorkflowResource = schema.GroupVersionResource{
+	Group:    "argoproj.io",
+	Version:  "v1alpha1",
+	Resource: "workflows",
+}
+
 type Interface interface {
-	Apply(manifest []byte, op Operation) error
+	Render(trigger *model.Trigger) ([]byte, error)
+	Apply(manifest []byte, op Operation) ([]Result, error)
+	Suspend(namespace, name string, suspend bool) error
+	Executions(namespace string) (model.ExecutionCollection, error)
+	Submit(namespace, name string) (*model.Execution, error)
+	Diff(manifest []byte) ([]byte, []byte, error)
+	Namespaces(before time.Time) ([]string, error)
+	Prune(namespace string) error
 }
 
 type Workflow struct {
 	ctx       context.Context
 	api       dynamic.Interface
 	clientset kubernetes.Interface
+	mapper    *restmapper.DeferredDiscoveryRESTMapper
 }
 
 func NewWorkflow(ctx context.Context, api dynamic.Interface, clientset kubernetes.Interface) *Workflow {
@@ -40,70 +78,312 @@ func NewWorkflow(ctx context.Context, api dynamic.Interface, clientset kubernete
 		ctx:       ctx,
 		api:       api,
 		clientset: clientset,
+		mapper:    restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(clientset.Discovery())),
+	}
+}
+
+// Render returns the Namespace, Secret and CronWorkflow of trigger.
+func (wf *Workflow) Render(trigger *model.Trigger) ([]byte, error) {
+	return Render("argo.yaml", trigger)
+}
+
+// Apply performs op on every object of the manifest, in order, and reports
+// what happened to each of them. Deploy and Replace use server-side apply, so
+// both can be retried, and Displace ignores objects that are already gone.
+// When a deploy fails midway, the objects it created are deleted before
+// returning. The ones that already existed, such as on a deploy retried by the
+// reconciler, are left as they were.
+func (wf *Workflow) Apply(manifest []byte, op Operation) (results []Result, err error) {
+	var created []*unstructured.Unstructured
+
+	defer func() {
+		if err != nil && op == Deploy {
+			wf.cleanup(created)
+		}
+	}()
+
+	objects, err := Decode(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, unstructuredObj := range objects {
+		dri, err := wf.resource(unstructuredObj)
+		if err != nil {
+			return results, wf.error(op, unstructuredObj, err)
+		}
+
+		result := Result{
+			Kind:      unstructuredObj.GetKind(),
+			Namespace: unstructuredObj.GetNamespace(),
+			Name:      unstructuredObj.GetName(),
+		}
+
+		switch op {
+		case Deploy, Replace:
+			exists := true
+			if op == Deploy {
+				_, err := dri.Get(wf.ctx, unstructuredObj.GetName(), metav1.GetOptions{})
+				if err != nil && !apierrors.IsNotFound(err) {
+					return results, wf.error(op, unstructuredObj, err)
+				}
+
+				exists = err == nil
+			}
+
+			obj, err := dri.Apply(wf.ctx, unstructuredObj.GetName(), unstructuredObj, metav1.ApplyOptions{FieldManager: FieldManager, Force: true})
+			if err != nil {
+				return results, wf.error(op, unstructuredObj, err)
+			}
+
+			if !exists {
+				created = append(created, unstructuredObj)
+			}
+
+			result.Action = Applied
+			result.ResourceVersion = obj.GetResourceVersion()
+
+		case Displace:
+			err := dri.Delete(wf.ctx, unstructuredObj.GetName(), metav1.DeleteOptions{})
+			switch {
+			case apierrors.IsNotFound(err):
+				result.Action = Absent
+			case err != nil:
+				return results, wf.error(op, unstructuredObj, err)
+			default:
+				result.Action = Deleted
+			}
+		}
+
+		results = append(results, result)
 	}
+
+	return results, nil
 }
 
-func (wf *Workflow) Apply(manifest []byte, op Operation) error {
+// Decode splits a manifest into its objects, and fails on the first one that
+// cannot be decoded rather than dropping it and the ones after it.
+func Decode(manifest []byte) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+
 	decoder := yamlutil.NewYAMLOrJSONDecoder(bytes.NewReader(manifest), 4096)
 	for {
-		var (
-			rawObj runtime.RawExtension
-			dri    dynamic.ResourceInterface
-		)
+		var rawObj runtime.RawExtension
 
 		if err := decoder.Decode(&rawObj); err != nil {
-			break
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, err
 		}
 
-		obj, gvk, err := yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme).Decode(rawObj.Raw, nil, nil)
+		obj, _, err := yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme).Decode(rawObj.Raw, nil, nil)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		unstructuredMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		unstructuredObj := &unstructured.Unstructured{Object: unstructuredMap}
+		objects = append(objects, &unstructured.Unstructured{Object: unstructuredMap})
+	}
+
+	return objects, nil
+}
+
+// Encode joins objects back into a manifest that Apply accepts.
+func Encode(objects []*unstructured.Unstructured) ([]byte, error) {
+	var buffer bytes.Buffer
 
-		gr, err := restmapper.GetAPIGroupResources(wf.clientset.Discovery())
+	for _, obj := range objects {
+		b, err := obj.MarshalJSON()
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		buffer.Write(b)
+		buffer.WriteString("\n")
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// resource maps obj to its API resource through the cached discovery mapper.
+// A kind the cache does not know about, such as one whose CRD was installed
+// after the cache was filled, resets the cache and is looked up once more.
+func (wf *Workflow) resource(obj *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	gvk := obj.GroupVersionKind()
+
+	mapping, err := wf.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		wf.mapper.Reset()
+		mapping, err = wf.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace("default")
 		}
+		return wf.api.Resource(mapping.Resource).Namespace(obj.GetNamespace()), nil
+	}
+
+	return wf.api.Resource(mapping.Resource), nil
+}
+
+func (wf *Workflow) error(op Operation, obj *unstructured.Unstructured, err error) error {
+	return &Error{Op: op, Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName(), Err: err}
+}
 
-		mapper := restmapper.NewDiscoveryRESTMapper(gr)
-		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+// cleanup deletes, in reverse order, the objects created by a deploy that
+// failed midway. It is best effort, the deploy error is what gets reported.
+func (wf *Workflow) cleanup(created []*unstructured.Unstructured) {
+	for i := len(created) - 1; i >= 0; i-- {
+		dri, err := wf.resource(created[i])
 		if err != nil {
-			return err
+			continue
 		}
 
-		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
-			if unstructuredObj.GetNamespace() == "" {
-				unstructuredObj.SetNamespace("default")
-			}
-			dri = wf.api.Resource(mapping.Resource).Namespace(unstructuredObj.GetNamespace())
-		} else {
-			dri = wf.api.Resource(mapping.Resource)
+		_ = dri.Delete(wf.ctx, created[i].GetName(), metav1.DeleteOptions{})
+	}
+}
+
+func (wf *Workflow) Suspend(namespace, name string, suspend bool) error {
+	return wf.suspend(CronWorkflowResource, namespace, name, suspend)
+}
+
+func (wf *Workflow) suspend(resource schema.GroupVersionResource, namespace, name string, suspend bool) error {
+	patch := []byte(fmt.Sprintf(`{"spec":{"suspend":%t}}`, suspend))
+
+	_, err := wf.api.Resource(resource).Namespace(namespace).Patch(wf.ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+
+	return err
+}
+
+func (wf *Workflow) Executions(namespace string) (model.ExecutionCollection, error) {
+	list, err := wf.api.Resource(WorkflowResource).Namespace(namespace).List(wf.ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	executions := make(model.ExecutionCollection, 0, len(list.Items))
+	for i := range list.Items {
+		execution, err := NewExecution(&list.Items[i])
+		if err != nil {
+			return nil, err
 		}
 
-		switch op {
-		case Deploy:
-			if _, err := dri.Create(wf.ctx, unstructuredObj, metav1.CreateOptions{}); err != nil {
-				return err
-			}
+		executions = append(executions, execution)
+	}
 
-		case Replace:
-			if _, err := dri.Update(wf.ctx, unstructuredObj, metav1.UpdateOptions{}); err != nil {
-				return err
-			}
+	return executions, nil
+}
 
-		case Displace:
-			if err := dri.Delete(wf.ctx, unstructuredObj.GetName(), metav1.DeleteOptions{}); err != nil {
-				return err
-			}
+// Submit creates a one-off Workflow from the spec of a CronWorkflow, which is
+// what "argo submit --from cronwf/<name>" does.
+func (wf *Workflow) Submit(namespace, name string) (*model.Execution, error) {
+	cronWorkflow, err := wf.api.Resource(CronWorkflowResource).Namespace(namespace).Get(wf.ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	spec, found, err := unstructured.NestedMap(cronWorkflow.Object, "spec", "workflowSpec")
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, fmt.Errorf("cronworkflow %s/%s has no workflowSpec", namespace, name)
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]any{"spec": spec}}
+	obj.SetAPIVersion(cronWorkflow.GetAPIVersion())
+	obj.SetKind("Workflow")
+	obj.SetNamespace(namespace)
+	obj.SetGenerateName(name + "-")
+	obj.SetLabels(map[string]string{CronWorkflowLabel: name})
+	obj.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion: cronWorkflow.GetAPIVersion(),
+		Kind:       cronWorkflow.GetKind(),
+		Name:       cronWorkflow.GetName(),
+		UID:        cronWorkflow.GetUID(),
+	}})
+
+	created, err := wf.api.Resource(WorkflowResource).Namespace(namespace).Create(wf.ctx, obj, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewExecution(created)
+}
+
+// NewExecution reads the state of an Argo Workflow, the HTTP status code is
+// the output of the script step that finished last.
+func NewExecution(obj *unstructured.Unstructured) (*model.Execution, error) {
+	id, err := uuid.Parse(string(obj.GetUID()))
+	if err != nil {
+		return nil, err
+	}
+
+	execution := &model.Execution{
+		ID:        id,
+		Name:      obj.GetName(),
+		Phase:     model.Pending,
+		StartedAt: obj.GetCreationTimestamp().Time,
+	}
+
+	if phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase"); phase != "" {
+		execution.Phase = phase
+	}
+
+	execution.Message, _, _ = unstructured.NestedString(obj.Object, "status", "message")
+
+	if startedAt, ok := nestedTime(obj.Object, "status", "startedAt"); ok {
+		execution.StartedAt = startedAt
+	}
+
+	if finishedAt, ok := nestedTime(obj.Object, "status", "finishedAt"); ok {
+		execution.FinishedAt = &finishedAt
+		execution.Duration = finishedAt.Sub(execution.StartedAt).Milliseconds()
+	}
+
+	nodes, _, _ := unstructured.NestedMap(obj.Object, "status", "nodes")
+
+	var last time.Time
+	for _, node := range nodes {
+		node, ok := node.(map[string]any)
+		if !ok || node["type"] != "Pod" {
+			continue
+		}
+
+		result, _, _ := unstructured.NestedString(node, "outputs", "result")
+		code, err := strconv.Atoi(strings.TrimSpace(result))
+		if err != nil {
+			continue
+		}
+
+		if finishedAt, _ := nestedTime(node, "finishedAt"); !finishedAt.Before(last) {
+			last = finishedAt
+			execution.StatusCode = code
 		}
 	}
 
-	return nil
+	return execution, nil
+}
+
+func nestedTime(obj map[string]any, fields ...string) (time.Time, bool) {
+	value, _, _ := unstructured.NestedString(obj, fields...)
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+
+	return t, err == nil
 }
@@ -0,0 +1,489 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skhaz/scheduler/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/thanhpk/randstr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	discoveryfake "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/restmapper"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var listKinds = map[schema.GroupVersionResource]string{
+	CronWorkflowResource: "CronWorkflowList",
+	WorkflowResource:     "WorkflowList",
+	NamespaceResource:    "NamespaceList",
+	CronJobResource:      "CronJobList",
+	JobResource:          "JobList",
+	PodResource:          "PodList",
+}
+
+func newArgoObject(kind, namespace, name string, fields map[string]any) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: fields}
+	obj.SetAPIVersion("argoproj.io/v1alpha1")
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+
+	return obj
+}
+
+var resources = []*metav1.APIResourceList{
+	{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "namespaces", Kind: "Namespace", Namespaced: false},
+			{Name: "secrets", Kind: "Secret", Namespaced: true},
+			{Name: "pods", Kind: "Pod", Namespaced: true},
+		},
+	},
+	{
+		GroupVersion: "batch/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "cronjobs", Kind: "CronJob", Namespaced: true},
+			{Name: "jobs", Kind: "Job", Namespaced: true},
+		},
+	},
+	{
+		GroupVersion: "argoproj.io/v1alpha1",
+		APIResources: []metav1.APIResource{
+			{Name: "cronworkflows", Kind: "CronWorkflow", Namespaced: true},
+			{Name: "workflows", Kind: "Workflow", Namespaced: true},
+		},
+	},
+}
+
+const manifest = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: trigger
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: curl
+  namespace: trigger
+---
+apiVersion: argoproj.io/v1alpha1
+kind: CronWorkflow
+metadata:
+  name: curl
+  namespace: trigger
+spec:
+  schedule: "* * * * *"
+`
+
+var namespaceResource = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+
+var secretResource = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+// serverSideApply makes the fake client create objects on apply, as the API
+// server does, instead of failing because they do not exist yet.
+func serverSideApply(tracker k8stesting.ObjectTracker) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch, ok := action.(k8stesting.PatchAction)
+		if !ok || patch.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(patch.GetPatch()); err != nil {
+			return true, nil, err
+		}
+
+		gvr, namespace := action.GetResource(), action.GetNamespace()
+
+		_, err := tracker.Get(gvr, namespace, patch.GetName())
+		switch {
+		case apierrors.IsNotFound(err):
+			err = tracker.Create(gvr, obj, namespace)
+		case err == nil:
+			err = tracker.Update(gvr, obj, namespace)
+		}
+
+		if err != nil {
+			return true, nil, err
+		}
+
+		live, err := tracker.Get(gvr, namespace, patch.GetName())
+
+		return true, live, err
+	}
+}
+
+func newWorkflow(objects ...runtime.Object) *Workflow {
+	api := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+	api.PrependReactor("patch", "*", serverSideApply(api.Tracker()))
+
+	clientset := kubernetesfake.NewSimpleClientset()
+	clientset.Discovery().(*discoveryfake.FakeDiscovery).Resources = resources
+
+	return NewWorkflow(context.Background(), api, clientset)
+}
+
+func TestNewExecution(t *testing.T) {
+	id := uuid.New()
+	started := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	finished := started.Add(1500 * time.Millisecond)
+
+	obj := newArgoObject("Workflow", uuid.NewString(), randstr.String(16), map[string]any{
+		"status": map[string]any{
+			"phase":      model.Failed,
+			"startedAt":  started.Format(time.RFC3339),
+			"finishedAt": finished.Add(500 * time.Millisecond).Format(time.RFC3339),
+			"nodes": map[string]any{
+				"first": map[string]any{
+					"type":       "Pod",
+					"finishedAt": started.Format(time.RFC3339),
+					"outputs":    map[string]any{"result": "503"},
+				},
+				"last": map[string]any{
+					"type":       "Pod",
+					"finishedAt": finished.Format(time.RFC3339),
+					"outputs":    map[string]any{"result": "500\n"},
+				},
+				"root": map[string]any{
+					"type": "Retry",
+				},
+			},
+		},
+	})
+	obj.SetUID(types.UID(id.String()))
+
+	execution, err := NewExecution(obj)
+	assert.NoError(t, err)
+	assert.Equal(t, id, execution.ID)
+	assert.Equal(t, obj.GetName(), execution.Name)
+	assert.Equal(t, model.Failed, execution.Phase)
+	assert.Equal(t, 500, execution.StatusCode)
+	assert.Equal(t, started, execution.StartedAt.UTC())
+	assert.Equal(t, int64(2000), execution.Duration)
+}
+
+func TestNewExecutionPending(t *testing.T) {
+	obj := newArgoObject("Workflow", uuid.NewString(), randstr.String(16), map[string]any{})
+	obj.SetUID(types.UID(uuid.NewString()))
+
+	execution, err := NewExecution(obj)
+	assert.NoError(t, err)
+	assert.Equal(t, model.Pending, execution.Phase)
+	assert.Nil(t, execution.FinishedAt)
+	assert.Zero(t, execution.StatusCode)
+}
+
+func TestNewExecutionInvalidUID(t *testing.T) {
+	obj := newArgoObject("Workflow", uuid.NewString(), randstr.String(16), map[string]any{})
+
+	_, err := NewExecution(obj)
+	assert.Error(t, err)
+}
+
+func TestExecutions(t *testing.T) {
+	namespace := uuid.NewString()
+
+	obj := newArgoObject("Workflow", namespace, randstr.String(16), map[string]any{
+		"status": map[string]any{"phase": model.Succeeded},
+	})
+	obj.SetUID(types.UID(uuid.NewString()))
+
+	other := newArgoObject("Workflow", uuid.NewString(), randstr.String(16), map[string]any{})
+	other.SetUID(types.UID(uuid.NewString()))
+
+	executions, err := newWorkflow(obj, other).Executions(namespace)
+	assert.NoError(t, err)
+	assert.Len(t, executions, 1)
+	assert.Equal(t, model.Succeeded, executions[0].Phase)
+}
+
+func TestSuspend(t *testing.T) {
+	namespace, name := uuid.NewString(), randstr.String(16)
+
+	obj := newArgoObject("CronWorkflow", namespace, name, map[string]any{
+		"spec": map[string]any{"suspend": false},
+	})
+
+	wf := newWorkflow(obj)
+
+	assert.NoError(t, wf.Suspend(namespace, name, true))
+
+	actual, err := wf.api.Resource(CronWorkflowResource).Namespace(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	suspend, _, _ := unstructured.NestedBool(actual.Object, "spec", "suspend")
+	assert.True(t, suspend)
+}
+
+func TestSubmit(t *testing.T) {
+	namespace, name := uuid.NewString(), randstr.String(16)
+	id := uuid.New()
+
+	obj := newArgoObject("CronWorkflow", namespace, name, map[string]any{
+		"spec": map[string]any{
+			"schedule": "* * * * *",
+			"workflowSpec": map[string]any{
+				"entrypoint": "curl",
+			},
+		},
+	})
+	obj.SetUID(types.UID(uuid.NewString()))
+
+	wf := newWorkflow(obj)
+	wf.api.(*dynamicfake.FakeDynamicClient).PrependReactor("create", "workflows", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		created := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		created.SetName(created.GetGenerateName() + randstr.String(5))
+		created.SetUID(types.UID(id.String()))
+		return false, created, nil
+	})
+
+	execution, err := wf.Submit(namespace, name)
+	assert.NoError(t, err)
+	assert.Equal(t, id, execution.ID)
+	assert.Equal(t, model.Pending, execution.Phase)
+
+	list, err := wf.api.Resource(WorkflowResource).Namespace(namespace).List(context.Background(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, list.Items, 1)
+
+	created := list.Items[0]
+	assert.Equal(t, name, created.GetLabels()[CronWorkflowLabel])
+	assert.Equal(t, obj.GetUID(), created.GetOwnerReferences()[0].UID)
+
+	entrypoint, _, _ := unstructured.NestedString(created.Object, "spec", "entrypoint")
+	assert.Equal(t, "curl", entrypoint)
+}
+
+func TestSubmitNotFound(t *testing.T) {
+	_, err := newWorkflow().Submit(uuid.NewString(), randstr.String(16))
+	assert.Error(t, err)
+}
+
+func TestApplyDeploy(t *testing.T) {
+	wf := newWorkflow()
+
+	results, err := wf.Apply([]byte(manifest), Deploy)
+	assert.NoError(t, err)
+	assert.Equal(t, []Result{
+		{Kind: "Namespace", Name: "trigger", Action: Applied},
+		{Kind: "Secret", Namespace: "trigger", Name: "curl", Action: Applied},
+		{Kind: "CronWorkflow", Namespace: "trigger", Name: "curl", Action: Applied},
+	}, results)
+
+	_, err = wf.api.Resource(CronWorkflowResource).Namespace("trigger").Get(context.Background(), "curl", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
+
+func TestApplyDeployIdempotent(t *testing.T) {
+	wf := newWorkflow()
+
+	_, err := wf.Apply([]byte(manifest), Deploy)
+	assert.NoError(t, err)
+
+	_, err = wf.Apply([]byte(manifest), Deploy)
+	assert.NoError(t, err)
+}
+
+func TestApplyServerSide(t *testing.T) {
+	wf := newWorkflow()
+
+	var patchTypes []types.PatchType
+	wf.api.(*dynamicfake.FakeDynamicClient).PrependReactor("*", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if patch, ok := action.(k8stesting.PatchAction); ok {
+			patchTypes = append(patchTypes, patch.GetPatchType())
+		}
+		assert.NotEqual(t, "create", action.GetVerb())
+		assert.NotEqual(t, "update", action.GetVerb())
+		return false, nil, nil
+	})
+
+	_, err := wf.Apply([]byte(manifest), Replace)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.PatchType{types.ApplyPatchType, types.ApplyPatchType, types.ApplyPatchType}, patchTypes)
+}
+
+func TestApplyReplace(t *testing.T) {
+	wf := newWorkflow()
+
+	_, err := wf.Apply([]byte(manifest), Deploy)
+	assert.NoError(t, err)
+
+	_, err = wf.Apply([]byte(strings.Replace(manifest, "* * * * *", "0 0 * * *", 1)), Replace)
+	assert.NoError(t, err)
+
+	obj, err := wf.api.Resource(CronWorkflowResource).Namespace("trigger").Get(context.Background(), "curl", metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	schedule, _, _ := unstructured.NestedString(obj.Object, "spec", "schedule")
+	assert.Equal(t, "0 0 * * *", schedule)
+}
+
+func TestApplyDeployCleanup(t *testing.T) {
+	wf := newWorkflow()
+	wf.api.(*dynamicfake.FakeDynamicClient).PrependReactor("patch", "cronworkflows", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("admission webhook denied the request")
+	})
+
+	results, err := wf.Apply([]byte(manifest), Deploy)
+	assert.Len(t, results, 2)
+
+	var e *Error
+	assert.ErrorAs(t, err, &e)
+	assert.Equal(t, Deploy, e.Op)
+	assert.Equal(t, "CronWorkflow", e.Kind)
+	assert.Equal(t, "curl", e.Name)
+
+	_, err = wf.api.Resource(namespaceResource).Get(context.Background(), "trigger", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	_, err = wf.api.Resource(secretResource).Namespace("trigger").Get(context.Background(), "curl", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestApplyDeployCleanupKeepsExisting(t *testing.T) {
+	wf := newWorkflow()
+
+	_, err := wf.Apply([]byte(manifest), Deploy)
+	assert.NoError(t, err)
+
+	wf.api.(*dynamicfake.FakeDynamicClient).PrependReactor("patch", "cronworkflows", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("admission webhook denied the request")
+	})
+
+	_, err = wf.Apply([]byte(manifest), Deploy)
+	assert.Error(t, err)
+
+	_, err = wf.api.Resource(namespaceResource).Get(context.Background(), "trigger", metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	_, err = wf.api.Resource(secretResource).Namespace("trigger").Get(context.Background(), "curl", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
+
+func TestApplyDisplace(t *testing.T) {
+	wf := newWorkflow()
+
+	_, err := wf.Apply([]byte(manifest), Deploy)
+	assert.NoError(t, err)
+
+	results, err := wf.Apply([]byte(manifest), Displace)
+	assert.NoError(t, err)
+	assert.Equal(t, Deleted, results[2].Action)
+
+	_, err = wf.api.Resource(CronWorkflowResource).Namespace("trigger").Get(context.Background(), "curl", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestApplyDisplaceNotFound(t *testing.T) {
+	wf := newWorkflow()
+
+	results, err := wf.Apply([]byte(manifest), Displace)
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+
+	for _, result := range results {
+		assert.Equal(t, Absent, result.Action)
+	}
+}
+
+func TestResultString(t *testing.T) {
+	assert.Equal(t, "namespace trigger deleted", Result{Kind: "Namespace", Name: "trigger", Action: Deleted}.String())
+	assert.Equal(t, "cronworkflow trigger/curl applied", Result{Kind: "CronWorkflow", Namespace: "trigger", Name: "curl", Action: Applied}.String())
+}
+
+func TestResourceResetsOnNoMatch(t *testing.T) {
+	api := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	clientset := kubernetesfake.NewSimpleClientset()
+	discovery := clientset.Discovery().(*discoveryfake.FakeDiscovery)
+	discovery.Resources = resources[:1]
+
+	wf := NewWorkflow(context.Background(), api, clientset)
+	obj := newArgoObject("CronWorkflow", "trigger", "curl", map[string]any{})
+
+	_, err := wf.resource(obj)
+	assert.True(t, meta.IsNoMatchError(err))
+
+	// The Argo CRDs are installed after the cache was filled.
+	discovery.Resources = resources
+
+	_, err = wf.resource(obj)
+	assert.NoError(t, err)
+}
+
+func TestResourceCached(t *testing.T) {
+	wf := newWorkflow()
+	obj := newArgoObject("CronWorkflow", "trigger", "curl", map[string]any{})
+
+	_, err := wf.resource(obj)
+	assert.NoError(t, err)
+
+	discovery := wf.clientset.Discovery().(*discoveryfake.FakeDiscovery)
+	discovery.ClearActions()
+
+	for i := 0; i < 10; i++ {
+		_, err := wf.resource(obj)
+		assert.NoError(t, err)
+	}
+
+	assert.Empty(t, discovery.Actions())
+}
+
+func BenchmarkResource(b *testing.B) {
+	wf := newWorkflow()
+	obj := newArgoObject("CronWorkflow", "trigger", "curl", map[string]any{})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := wf.resource(obj); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkResourceUncached measures the discovery round-trip per object that
+// resource did before the mapper was cached, as a baseline.
+func BenchmarkResourceUncached(b *testing.B) {
+	wf := newWorkflow()
+	obj := newArgoObject("CronWorkflow", "trigger", "curl", map[string]any{})
+	gvk := obj.GroupVersionKind()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		gr, err := restmapper.GetAPIGroupResources(wf.clientset.Discovery())
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if _, err := restmapper.NewDiscoveryRESTMapper(gr).RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	objects, err := Decode([]byte("apiVersion: v1\nkind: Namespace\nmetadata:\n  name: a\n---\napiVersion: v1\nkind: Namespace\nmetadata:\n  name: b\n"))
+	assert.NoError(t, err)
+	assert.Len(t, objects, 2)
+	assert.Equal(t, "b", objects[1].GetName())
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	objects, err := Decode([]byte("apiVersion: v1\nkind: Namespace\nmetadata:\n  name: a\n---\nkind: [Namespace\n"))
+	assert.Error(t, err)
+	assert.Nil(t, objects)
+}